@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/circleci/llm-agent-rules/internal/linker"
+	"github.com/circleci/llm-agent-rules/internal/rules"
+)
+
+// bundleRuleNames returns the names of the rules of the bundles, in order
+// and without repeats
+func bundleRuleNames(rulesManager *rules.Manager, bundles []string) ([]string, error) {
+	names := make([]string, 0)
+	for _, bundle := range bundles {
+		selected, err := rulesManager.ResolveBundle(bundle)
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range selected {
+			names = appendMissing(names, linker.RuleID(rule))
+		}
+	}
+	return names, nil
+}
+
+// appendMissing appends the names that are not in names yet
+func appendMissing(names []string, more ...string) []string {
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		seen[name] = true
+	}
+	for _, name := range more {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// bundleSummary describes a bundle in the rule list: its description and
+// how many rules it links, or why it cannot be linked
+func bundleSummary(rulesManager *rules.Manager, name, description string) string {
+	summary := ""
+	if description != "" {
+		summary = ": " + ruleDescStyle.Render(description)
+	}
+	selected, err := rulesManager.ResolveBundle(name)
+	if err != nil {
+		return summary + fmt.Sprintf(" (invalid: %v)", err)
+	}
+	return summary + fmt.Sprintf(" (%d rules)", len(selected))
+}
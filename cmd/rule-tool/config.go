@@ -0,0 +1,223 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/circleci/llm-agent-rules/internal/config"
+	"github.com/circleci/llm-agent-rules/internal/linker"
+	"github.com/circleci/llm-agent-rules/internal/output"
+	"github.com/circleci/llm-agent-rules/internal/ui"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// configUsage describes the config subcommands
+const configUsage = `Usage: rule-tool config <command> [flags] [arguments]
+
+Inspect and edit the configuration files.
+
+Commands:
+  path              Print the path of the user (or, with --project, project) configuration file
+  list              Print every setting with its effective value and where it came from
+  get <key>         Print the effective value of a setting
+  set <key> [value...]
+                    Write a setting to the user (or, with --project, project) configuration
+                    file; list settings take several values, and no value removes the key
+
+Settings: sources, editor, mode, theme; project files also accept rules, editors and
+vars.<name> for the value of a template variable (get vars lists them all).
+Precedence, highest first: flags, environment variables, the project's .rule-tool.yaml,
+the user configuration file, built-in defaults.
+
+`
+
+// runConfig implements the config subcommand and returns the exit code
+func runConfig(args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Fprint(os.Stderr, configUsage)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+
+	command := args[0]
+	fs := flag.NewFlagSet("config "+command, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), configUsage)
+		fs.PrintDefaults()
+	}
+	targetPath := fs.String("target-path", "", "Path to the target project (overrides RULE_TARGET_PATH environment variable if set)")
+	project := new(bool)
+	if command == "path" || command == "set" {
+		fs.BoolVar(project, "project", false, "Use the target project's "+config.ProjectFileName+" instead of the user configuration file")
+	}
+	format := addOutputFlag(fs)
+	if code, ok := parseFlags(fs, args[1:]); !ok {
+		return code
+	}
+	out, ok := newOutput(*format, "settings")
+	if !ok {
+		return exitUsage
+	}
+
+	cfg := config.New()
+	if *targetPath != "" {
+		cfg.SetTargetProjectPath(*targetPath)
+	}
+	path := config.UserPath()
+	if *project {
+		path = config.ProjectPath(cfg.TargetProjectPath)
+	}
+
+	switch command {
+	case "path":
+		if fs.NArg() != 0 {
+			fs.Usage()
+			return exitUsage
+		}
+		if out.Structured() {
+			out.Record(output.Setting{Path: path})
+			return closeOutput(out)
+		}
+		fmt.Println(path)
+		return exitOK
+
+	case "list", "get":
+		if (command == "list" && fs.NArg() != 0) || (command == "get" && fs.NArg() != 1) {
+			fs.Usage()
+			return exitUsage
+		}
+		if err := cfg.LoadFiles(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+		keys := config.UserKeys
+		if command == "get" {
+			keys = fs.Args()
+		}
+		for _, key := range keys {
+			values, err := effectiveSetting(cfg, key)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return exitUsage
+			}
+			if out.Structured() {
+				out.Record(output.Setting{Key: key, Values: values, Origin: cfg.Origin(key)})
+				continue
+			}
+			if command == "get" {
+				for _, value := range values {
+					fmt.Println(value)
+				}
+				continue
+			}
+			fmt.Printf("%s = %s (%s)\n", key, strings.Join(values, ", "), cfg.Origin(key))
+		}
+		return closeOutput(out)
+
+	case "set":
+		if fs.NArg() < 1 {
+			fs.Usage()
+			return exitUsage
+		}
+		key, values := fs.Arg(0), fs.Args()[1:]
+		if len(values) == 1 && values[0] == "" {
+			values = nil
+		}
+		if err := validateSetting(key, values, *project); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		if err := config.SetFileKey(path, key, values); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+		if out.Structured() {
+			out.Record(output.Setting{Key: key, Values: values, Path: path})
+			return closeOutput(out)
+		}
+		if len(values) == 0 {
+			fmt.Printf("Removed %s from %s\n", key, path)
+		} else {
+			fmt.Printf("Set %s in %s\n", key, path)
+		}
+		return exitOK
+	}
+
+	fmt.Fprintf(os.Stderr, "Unknown config command: %s\n\n", command)
+	fmt.Fprint(os.Stderr, configUsage)
+	return exitUsage
+}
+
+// effectiveSetting returns a setting's value, filling in the built-in default
+func effectiveSetting(cfg *config.Config, key string) ([]string, error) {
+	values, err := cfg.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 1 && values[0] == "" {
+		switch key {
+		case config.KeyEditor:
+			values = []string{linker.DefaultEditor}
+		case config.KeyMode:
+			values = []string{string(linker.ModeSymlink)}
+		case config.KeyTheme:
+			values = []string{ui.ThemeDefault}
+		}
+	}
+	return values, nil
+}
+
+// validateSetting checks a key and its values before they are written to a
+// configuration file
+func validateSetting(key string, values []string, project bool) error {
+	if name, ok := config.VarName(key); ok {
+		switch {
+		case !project:
+			return fmt.Errorf("template values are set in the project's %s, use --project", config.ProjectFileName)
+		case !models.ValidVariableName(name):
+			return fmt.Errorf("invalid template variable name %q", name)
+		case len(values) > 1:
+			return fmt.Errorf("%s takes a single value", key)
+		}
+		return nil
+	}
+	if key == config.KeyVars {
+		return fmt.Errorf("set template values one at a time with %s<name>", config.VarsPrefix)
+	}
+
+	keys := config.UserKeys
+	if project {
+		keys = config.ProjectKeys
+	}
+	if !slices.Contains(keys, key) {
+		return fmt.Errorf("unknown setting %q, known settings: %s", key, strings.Join(keys, ", "))
+	}
+	if len(values) > 1 && !config.IsListKey(key) {
+		return fmt.Errorf("%s takes a single value", key)
+	}
+
+	for _, value := range values {
+		var err error
+		switch key {
+		case config.KeyEditor, config.KeyEditors:
+			_, err = linker.LookupAdapter(value)
+		case config.KeyMode:
+			_, err = linker.ParseLinkMode(value)
+		case config.KeyTheme:
+			if !slices.Contains(ui.Themes, value) {
+				err = fmt.Errorf("unknown theme %q, supported themes: %s", value, strings.Join(ui.Themes, ", "))
+			}
+		case config.KeySources:
+			err = (&config.Config{}).AddSource(value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
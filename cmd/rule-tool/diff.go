@@ -0,0 +1,131 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/circleci/llm-agent-rules/internal/diff"
+	"github.com/circleci/llm-agent-rules/internal/linker"
+	"github.com/circleci/llm-agent-rules/internal/output"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// runDiff implements the diff command and returns the exit code
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: rule-tool diff [flags] [rule...]\n\n")
+		fmt.Fprintf(fs.Output(), "Show how installed rules differ from their source rules as unified diffs,\n")
+		fmt.Fprintf(fs.Output(), "including frontmatter. Without rules, every rule installed for the editor is compared.\n\n")
+		fs.PrintDefaults()
+	}
+	project := addProjectFlags(fs)
+	project.addFormatFlag(fs)
+	check := fs.Bool("check", false, "Exit with status 1 when any installed rule differs from its source")
+	format := addOutputFlag(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	out, ok := newOutput(*format, "diffs")
+	if !ok {
+		return exitUsage
+	}
+
+	rulesManager, l, err := project.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	editor, err := project.editor()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	failed := 0
+	rulesToDiff := make([]*models.Rule, 0)
+	names := splitRuleNames(fs.Args()...)
+	if len(names) == 0 {
+		rulesToDiff, err = installedRules(rulesManager.All(), l, editor)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+		if len(rulesToDiff) == 0 && !*project.quiet {
+			fmt.Fprintf(os.Stderr, "No rules installed for %s\n", editor)
+		}
+	}
+	for _, ruleName := range names {
+		rule := rulesManager.GetRuleByName(ruleName)
+		if rule == nil {
+			fmt.Fprintf(os.Stderr, "Rule not found: %s\n", ruleName)
+			out.Record(output.Diff{Rule: ruleName, Format: editor, Error: "rule not found"})
+			failed++
+			continue
+		}
+		rulesToDiff = append(rulesToDiff, rule)
+	}
+
+	changed := 0
+	for _, rule := range rulesToDiff {
+		ruleName := linker.RuleID(rule)
+		comparison, err := l.Compare(rule, editor)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error comparing %s: %v\n", ruleName, err)
+			out.Record(output.Diff{Rule: ruleName, Format: editor, Error: err.Error()})
+			failed++
+			continue
+		}
+
+		unified := comparison.Unified()
+		if comparison.Changed() {
+			changed++
+		}
+		out.Record(output.Diff{
+			Rule:        ruleName,
+			Format:      editor,
+			Destination: comparison.Destination,
+			Mode:        string(comparison.Mode),
+			Changed:     comparison.Changed(),
+			Diff:        unified,
+		})
+		if !out.Structured() {
+			fmt.Print(diff.Colorize(unified))
+		}
+	}
+
+	if !out.Structured() && !*project.quiet && len(rulesToDiff) > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d installed rules differ from their source\n", changed, len(rulesToDiff))
+	}
+
+	total := len(rulesToDiff)
+	if len(names) > 0 {
+		total = len(names)
+	}
+	code := failureExit(failed, total)
+	if code == exitOK && *check && changed > 0 {
+		code = exitError
+	}
+	return worstExit(code, closeOutput(out))
+}
+
+// installedRules returns the source rules of the rules installed for editor,
+// warning about installed rules whose source is gone
+func installedRules(all []*models.Rule, l *linker.Linker, editor string) ([]*models.Rule, error) {
+	lock, err := l.Lockfile()
+	if err != nil {
+		return nil, err
+	}
+
+	installed := make([]*models.Rule, 0)
+	for _, entry := range lock.Entries(editor) {
+		rule, ok := l.EntryRule(entry, all)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Skipping %s: source rule %s no longer exists\n", entry.ID, entry.Path)
+			continue
+		}
+		installed = append(installed, rule)
+	}
+	return installed, nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/circleci/llm-agent-rules/internal/linker"
+	"github.com/circleci/llm-agent-rules/internal/output"
+	"github.com/circleci/llm-agent-rules/internal/rules"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// activationOrder ranks the activations in the explain listing
+var activationOrder = map[rules.Activation]int{
+	rules.ActivationAlways:         0,
+	rules.ActivationGlob:           1,
+	rules.ActivationAgentRequested: 2,
+	rules.ActivationManual:         3,
+	rules.ActivationNoMatch:        4,
+}
+
+// runExplain implements the explain command and returns the exit code
+func runExplain(args []string) int {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: rule-tool explain [flags] <file>\n\n")
+		fmt.Fprintf(fs.Output(), "List the installed rules in effect for a file of the target project: rules\n")
+		fmt.Fprintf(fs.Output(), "that always apply, rules with a glob matching the file, and rules with only a\n")
+		fmt.Fprintf(fs.Output(), "description that the agent applies when relevant. Relative paths are taken\n")
+		fmt.Fprintf(fs.Output(), "from the target project.\n\n")
+		fs.PrintDefaults()
+	}
+	project := addProjectFlags(fs)
+	project.addFormatFlag(fs)
+	all := fs.Bool("all", false, "Also list the installed rules that do not apply to the file")
+	format := addOutputFlag(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	out, ok := newOutput(*format, "rules")
+	if !ok {
+		return exitUsage
+	}
+
+	rulesManager, l, err := project.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	editor, err := project.editor()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	file, err := targetFile(l.TargetDir, fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	adapter, err := linker.LookupAdapter(editor)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	installed, err := installedRules(rulesManager.All(), l, editor)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	explanations := make([]output.Explanation, 0, len(installed))
+	for _, rule := range installed {
+		activation, glob := linker.Activate(adapter, rule, file)
+		if !activation.Applies() && !*all {
+			continue
+		}
+		explanations = append(explanations, output.Explanation{
+			Rule:        linker.RuleID(rule),
+			File:        file,
+			Format:      editor,
+			Description: rule.Description,
+			Activation:  string(activation),
+			Glob:        glob,
+			Applies:     activation.Applies(),
+			Why:         explainWhy(adapter, rule, activation, glob),
+		})
+	}
+	sort.SliceStable(explanations, func(i, j int) bool {
+		a, b := activationOrder[rules.Activation(explanations[i].Activation)], activationOrder[rules.Activation(explanations[j].Activation)]
+		if a != b {
+			return a < b
+		}
+		return explanations[i].Rule < explanations[j].Rule
+	})
+
+	if !out.Structured() {
+		if err := writeExplanations(os.Stdout, file, editor, explanations); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing explanation: %v\n", err)
+			return exitError
+		}
+		return exitOK
+	}
+	for _, explanation := range explanations {
+		if err := out.Record(explanation); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			return exitError
+		}
+	}
+	return closeOutput(out)
+}
+
+// targetFile returns file as a slash-separated path relative to the target
+// project, taking relative paths from the target project
+func targetFile(targetDir, file string) (string, error) {
+	rel := filepath.Clean(file)
+	if filepath.IsAbs(file) {
+		root, err := filepath.Abs(targetDir)
+		if err != nil {
+			return "", err
+		}
+		if rel, err = filepath.Rel(root, file); err != nil {
+			return "", err
+		}
+	}
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is not a file in the target project %s", file, targetDir)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// writeExplanations prints why each installed rule applies to file, or not
+func writeExplanations(w io.Writer, file, editor string, explanations []output.Explanation) error {
+	if len(explanations) == 0 {
+		_, err := fmt.Fprintf(w, "No installed %s rules apply to %s\n", editor, file)
+		return err
+	}
+
+	fmt.Fprintf(w, "Rules for %s (%s):\n\n", file, editor)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACTIVATION\tRULE\tWHY\t")
+	for _, explanation := range explanations {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t\n", explanation.Activation, explanation.Rule, explanation.Why)
+	}
+	return tw.Flush()
+}
+
+// explainWhy describes why a rule applies to a file, or does not
+func explainWhy(adapter linker.EditorAdapter, rule *models.Rule, activation rules.Activation, glob string) string {
+	switch activation {
+	case rules.ActivationAlways:
+		if rule.AlwaysApply {
+			return "alwaysApply: true"
+		}
+		return adapter.DisplayName() + " loads it for every file"
+	case rules.ActivationGlob:
+		return "matches " + glob
+	case rules.ActivationAgentRequested:
+		return "when relevant: " + rule.Description
+	case rules.ActivationManual:
+		return "only when mentioned"
+	}
+	return "no glob matches"
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/circleci/llm-agent-rules/internal/linker"
+	"github.com/circleci/llm-agent-rules/internal/lockfile"
+	"github.com/circleci/llm-agent-rules/internal/output"
+)
+
+// runUpdate implements the update subcommand and returns the exit code
+func runUpdate(args []string) int {
+	fs := flag.NewFlagSet("update", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: rule-tool update [flags]\n\n")
+		fmt.Fprintf(fs.Output(), "Reinstall rules in %s whose source changed or whose installed file is missing.\n\n", lockfile.FileName)
+		fs.PrintDefaults()
+	}
+	project := addProjectFlags(fs)
+	project.addLinkFlags(fs)
+	format := addOutputFlag(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	out, ok := newOutput(*format, "results")
+	if !ok {
+		return exitUsage
+	}
+
+	rulesManager, l, err := project.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	updated, err := l.Update(rulesManager.All())
+	verb := "Updated"
+	if *project.dryRun {
+		verb = "Would update"
+	}
+	for _, entry := range updated {
+		out.Record(entryResult("update", entry, *project.dryRun))
+		if !out.Structured() {
+			fmt.Printf("%s rule: %s (%s)\n", verb, entry.ID, entry.Format)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error updating rules: %v\n", err)
+		failed := 0
+		for _, entryErr := range unwrapErrors(err) {
+			failed++
+			var updateErr *linker.EntryError
+			if errors.As(entryErr, &updateErr) {
+				result := entryResult("update", updateErr.Entry, *project.dryRun)
+				result.OK, result.Error = false, updateErr.Err.Error()
+				out.Record(result)
+				continue
+			}
+			out.Record(output.Failed("update", "", "", entryErr))
+		}
+		return worstExit(failureExit(failed, failed+len(updated)), closeOutput(out))
+	}
+	if len(updated) == 0 && !out.Structured() {
+		fmt.Println("All installed rules are up to date")
+	}
+	return closeOutput(out)
+}
+
+// runPrune implements the prune subcommand and returns the exit code
+func runPrune(args []string) int {
+	fs := flag.NewFlagSet("prune", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: rule-tool prune [flags]\n\n")
+		fmt.Fprintf(fs.Output(), "Remove installed rules whose source rule was deleted or renamed.\n\n")
+		fs.PrintDefaults()
+	}
+	project := addProjectFlags(fs)
+	project.addLinkFlags(fs)
+	format := addOutputFlag(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	out, ok := newOutput(*format, "results")
+	if !ok {
+		return exitUsage
+	}
+
+	rulesManager, l, err := project.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	pruned, err := l.Prune(rulesManager.All())
+	verb := "Pruned"
+	if *project.dryRun {
+		verb = "Would prune"
+	}
+	for _, entry := range pruned {
+		out.Record(entryResult("prune", entry, *project.dryRun))
+		if !out.Structured() {
+			fmt.Printf("%s rule: %s (%s, %s)\n", verb, entry.ID, entry.Format, entry.Destination)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error pruning rules: %v\n", err)
+		out.Record(output.Failed("prune", "", "", err))
+		return worstExit(failureExit(1, 1+len(pruned)), closeOutput(out))
+	}
+	if len(pruned) == 0 && !out.Structured() {
+		fmt.Println("Nothing to prune")
+	}
+	return closeOutput(out)
+}
+
+// entryResult returns the record of a successful operation on an installed rule
+func entryResult(operation string, entry lockfile.Entry, dryRun bool) output.Result {
+	return output.Result{
+		Operation:   operation,
+		Rule:        entry.ID,
+		Format:      entry.Format,
+		Destination: entry.Destination,
+		DryRun:      dryRun,
+		OK:          true,
+	}
+}
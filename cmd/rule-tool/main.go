@@ -1,226 +1,286 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"strings"
 
-	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
-	"github.com/circleci/llm-agent-rules/internal/config"
 	"github.com/circleci/llm-agent-rules/internal/linker"
+	"github.com/circleci/llm-agent-rules/internal/output"
 	"github.com/circleci/llm-agent-rules/internal/rules"
-	"github.com/circleci/llm-agent-rules/internal/ui"
 )
 
+// Exit codes shared by every command
+const (
+	exitOK      = 0 // success
+	exitError   = 1 // the command failed
+	exitUsage   = 2 // invalid flags or arguments
+	exitPartial = 3 // some of the requested changes failed
+)
+
+// command is a rule-tool subcommand
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+// commands are the subcommands in the order they are listed in the usage
+var commands []command
+
+func init() {
+	commands = []command{
+		{"list", "List the available rules", runList},
+		{"show", "Show a rule's metadata and content", runShow},
+		{"recommend", "Recommend rules matching the files of the target project", runRecommend},
+		{"explain", "List the installed rules that apply to a file", runExplain},
+		{"link", "Install rules into the target project", runLink},
+		{"unlink", "Remove installed rules from the target project", runUnlink},
+		{"status", "Show how installed rules differ from the rules repository", runStatus},
+		{"diff", "Show the changes between installed rules and their source", runDiff},
+		{"sync", "Reconcile the target project with its declared rules", runSync},
+		{"update", "Reinstall rules whose source changed", runUpdate},
+		{"prune", "Remove installed rules whose source rule is gone", runPrune},
+		{"validate", "Lint every rule in the rules repository", runValidate},
+		{"config", "Inspect and edit the configuration files", runConfig},
+		{"tui", "Select rules in the interactive terminal UI (the default)", runTUI},
+		{"help", "Show help for a command", runHelp},
+	}
+}
+
 func main() {
-	// Initialize configuration
-	cfg := config.New()
-
-	// Parse command-line flags
-	repoPath := flag.String("repo-path", "", "Path to the rules repository (overrides RULE_TOOL_PATH environment variable if set)")
-	targetPath := flag.String("target-path", "", "Path to the target project (overrides RULE_TARGET_PATH environment variable if set)")
-	nonInteractive := flag.Bool("non-interactive", false, "Run in non-interactive mode")
-	dryRun := flag.Bool("dry-run", false, "Show what would be done without making changes")
-	listRules := flag.Bool("list", false, "List available rules")
-	linkRule := flag.String("link", "", "Link a specific rule or comma-separated list of rules")
-	unlinkRule := flag.String("unlink", "", "Unlink a specific rule or comma-separated list of rules")
-	verbose := flag.Bool("verbose", false, "Enable verbose output")
-	flag.Parse()
-
-	// Set paths from flags if provided (flags take precedence over environment variables)
-	if *repoPath != "" {
-		cfg.SetRulesRepoPath(*repoPath)
-	}
-
-	if *targetPath != "" {
-		cfg.SetTargetProjectPath(*targetPath)
-	}
-
-	// Display configuration source if verbose
-	if *verbose {
-		if *repoPath != "" {
-			fmt.Println("Using rules path from command line flag")
-		} else if os.Getenv(config.EnvRulesPath) != "" {
-			fmt.Printf("Using rules path from %s environment variable\n", config.EnvRulesPath)
-		} else {
-			fmt.Println("Using current directory as rules path")
+	os.Exit(run(os.Args[1:]))
+}
+
+// run dispatches to the subcommand named by the first argument. Without
+// one, the top-level flags are handled as before subcommands existed.
+func run(args []string) int {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return runLegacy(args)
+	}
+	if cmd, ok := lookupCommand(args[0]); ok {
+		return cmd.run(args[1:])
+	}
+	fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", args[0])
+	printUsage()
+	return exitUsage
+}
+
+// lookupCommand returns the subcommand with the given name
+func lookupCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
 		}
+	}
+	return command{}, false
+}
 
-		if *targetPath != "" {
-			fmt.Println("Using target path from command line flag")
-		} else if os.Getenv(config.EnvTargetPath) != "" {
-			fmt.Printf("Using target path from %s environment variable\n", config.EnvTargetPath)
-		} else {
-			fmt.Println("Using current directory as target path")
+// printUsage prints the top-level help to stderr
+func printUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: rule-tool <command> [flags] [arguments]\n\n")
+	fmt.Fprintf(os.Stderr, "Manage the LLM agent rules installed in a project.\n\n")
+	fmt.Fprintf(os.Stderr, "Commands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun 'rule-tool help <command>' for the flags of a command.\n")
+	fmt.Fprintf(os.Stderr, "Exit codes: 0 success, 1 error, 2 usage error, 3 partial failure.\n")
+}
+
+// runHelp implements the help command and returns the exit code
+func runHelp(args []string) int {
+	if len(args) == 0 {
+		printUsage()
+		return exitOK
+	}
+	cmd, ok := lookupCommand(args[0])
+	if !ok || cmd.name == "help" {
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", args[0])
 		}
+		printUsage()
+		if !ok {
+			return exitUsage
+		}
+		return exitOK
 	}
+	cmd.run([]string{"-h"})
+	return exitOK
+}
 
-	// Validate paths
-	if !cfg.ValidateRulesRepoPath() {
-		fmt.Printf("Invalid rules repository path: %s\n", cfg.RulesRepoPath)
-		os.Exit(1)
+// parseFlags parses a command's flags, treating -h as a successful request for help
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK, false
+		}
+		return exitUsage, false
 	}
+	return exitOK, true
+}
 
-	if !cfg.ValidateTargetProjectPath() {
-		fmt.Printf("Invalid target project path: %s\n", cfg.TargetProjectPath)
-		os.Exit(1)
+// runLegacy handles the top-level flags rule-tool had before subcommands.
+// --list, --link, --unlink and --non-interactive still work but are
+// deprecated in favour of the list, link and unlink commands; without them
+// the interactive UI starts.
+func runLegacy(args []string) int {
+	fs := flag.NewFlagSet("rule-tool", flag.ContinueOnError)
+	fs.Usage = func() {
+		printUsage()
+		fmt.Fprintf(fs.Output(), "\nFlags without a command start the interactive UI:\n")
+		fs.PrintDefaults()
+	}
+	project := addProjectFlags(fs)
+	project.addLinkFlags(fs)
+	project.addEditorFlags(fs)
+	nonInteractive := fs.Bool("non-interactive", false, "Deprecated: use 'rule-tool list'")
+	listRules := fs.Bool("list", false, "Deprecated: use 'rule-tool list'")
+	linkRule := fs.String("link", "", "Deprecated: use 'rule-tool link <rule>...'")
+	unlinkRule := fs.String("unlink", "", "Deprecated: use 'rule-tool unlink <rule>...'")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
 	}
 
-	// If verbose, show the resolved absolute paths
-	if *verbose {
-		fmt.Printf("Resolved rules path: %s\n", cfg.RulesRepoPath)
-		fmt.Printf("Resolved target path: %s\n", cfg.TargetProjectPath)
+	if !*nonInteractive && !*listRules && *linkRule == "" && *unlinkRule == "" {
+		return startTUI(project)
 	}
 
-	// Initialize rules manager
-	rulesDir := cfg.GetRulesDir()
-	rulesManager := rules.NewManager(rulesDir)
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "list", "non-interactive":
+			fmt.Fprintf(os.Stderr, "Warning: --%s is deprecated, use 'rule-tool list'\n", f.Name)
+		case "link", "unlink":
+			fmt.Fprintf(os.Stderr, "Warning: --%s is deprecated, use 'rule-tool %s <rule>...'\n", f.Name, f.Name)
+		}
+	})
 
-	// Load rules
-	err := rulesManager.LoadRules()
+	rulesManager, l, err := project.load()
 	if err != nil {
-		fmt.Printf("Error loading rules: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
 	}
-
 	if len(rulesManager.Rules) == 0 {
-		fmt.Println("No rules found in repository")
-		os.Exit(1)
-	}
-
-	// Initialize linker
-	linkerInstance := linker.NewLinker(cfg.TargetProjectPath)
-
-	// If dry run is enabled, set it on the linker
-	if *dryRun {
-		linkerInstance.SetDryRun(true)
-	}
-
-	// If verbose is enabled, set it on the linker
-	if *verbose {
-		linkerInstance.SetVerbose(true)
-	}
-
-	// Check which rules are already installed and mark them as selected
-	for _, rule := range rulesManager.Rules {
-		rule.IsInstalled = linkerInstance.IsRuleLinked(rule, ".cursor")
-		// Initialize Selected to match IsInstalled as a starting point
-		rule.Selected = rule.IsInstalled
-	}
-
-	// Define styles based on mode
-	var titleStyle, ruleNameStyle, descStyle lipgloss.Style
-
-	if *nonInteractive {
-		// Plain text for non-interactive mode
-		titleStyle = lipgloss.NewStyle()
-		ruleNameStyle = lipgloss.NewStyle()
-		descStyle = lipgloss.NewStyle()
-	} else {
-		// Colorful styles for interactive mode
-		titleStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#00FFFF"))
-		ruleNameStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF69B4"))
-		descStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#87CEEB"))
-	}
-
-	// Common header
-	fmt.Println(titleStyle.Render("Rule Tool CLI"))
-	fmt.Println(titleStyle.Render("---------------"))
-	fmt.Printf("Rules repository: %s\n", cfg.RulesRepoPath)
-	fmt.Printf("Target project: %s\n", cfg.TargetProjectPath)
-	fmt.Printf("Found %d rules\n", len(rulesManager.Rules))
-
-	// Handle non-interactive modes if requested
-	if *nonInteractive || *listRules || *linkRule != "" || *unlinkRule != "" {
-		// List all available rules
-		if *listRules || *nonInteractive {
-			fmt.Println("\nAvailable Rules:")
-			for i, rule := range rulesManager.Rules {
-				ruleName := rule.Name
-				if rule.Topic != "" {
-					ruleName = rule.Topic + "/" + rule.Name
-				}
-				fmt.Printf("%d. %s: %s\n",
-					i+1,
-					ruleNameStyle.Render(ruleName),
-					descStyle.Render(rule.Description))
-			}
-		}
+		fmt.Fprintln(os.Stderr, "No rules found in repository")
+		return exitError
+	}
+	editor, err := project.editor()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	if !*project.quiet {
+		printBanner(rulesManager, project.config.TargetProjectPath)
+	}
 
-		// Link specific rules
-		if *linkRule != "" {
-			rulesToLink := strings.Split(*linkRule, ",")
-			for _, ruleName := range rulesToLink {
-				ruleName = strings.TrimSpace(ruleName)
-				rule := rulesManager.GetRuleByName(ruleName)
-				if rule != nil {
-					if *verbose {
-						fmt.Printf("Debug - Rule found: %s\n", ruleName)
-						fmt.Printf("Debug - Rule topic: %s\n", rule.Topic)
-						fmt.Printf("Debug - Rule path: %s\n", rule.Path)
-					}
-
-					if *dryRun {
-						fmt.Printf("Would link rule: %s\n", ruleName)
-
-						// Display subfolder structure if applicable
-						if rule.Topic != "" && *verbose {
-							fmt.Printf("Would maintain subfolder structure: %s\n", rule.Topic)
-						}
-					} else {
-						err := linkerInstance.LinkRule(rule, ".cursor")
-						if err != nil {
-							fmt.Printf("Error linking rule %s: %v\n", ruleName, err)
-						} else {
-							fmt.Printf("Linked rule: %s\n", ruleName)
-						}
-					}
-				} else {
-					fmt.Printf("Rule not found: %s\n", ruleName)
-				}
-			}
-		}
+	if *listRules || *nonInteractive {
+		fmt.Println("\nAvailable Rules:")
+		printRuleList(rulesManager, rules.TagFilter{})
+	}
+
+	text, _ := output.NewWriter(os.Stdout, output.FormatText, "")
+	code := exitOK
+	if *linkRule != "" {
+		code = worstExit(code, linkRules(rulesManager, l, editor, splitRuleNames(*linkRule), *project.verbose, false, text))
+	}
+	if *unlinkRule != "" {
+		code = worstExit(code, unlinkRules(rulesManager, l, editor, splitRuleNames(*unlinkRule), text))
+	}
+	return code
+}
 
-		// Unlink specific rules
-		if *unlinkRule != "" {
-			rulesToUnlink := strings.Split(*unlinkRule, ",")
-			for _, ruleName := range rulesToUnlink {
-				ruleName = strings.TrimSpace(ruleName)
-				if *dryRun {
-					fmt.Printf("Would unlink rule: %s\n", ruleName)
-				} else {
-					err := linkerInstance.UnlinkRule(ruleName, ".cursor")
-					if err != nil {
-						fmt.Printf("Error unlinking rule %s: %v\n", ruleName, err)
-					} else {
-						fmt.Printf("Unlinked rule: %s\n", ruleName)
-					}
-				}
+// addOutputFlag registers the flag choosing between text output and structured records
+func addOutputFlag(fs *flag.FlagSet) *string {
+	return fs.String("output", output.FormatText, "Output format: "+strings.Join(output.Formats, ", "))
+}
+
+// newOutput creates the writer of a command's records, reporting an unknown
+// format as a usage error
+func newOutput(format, key string) (*output.Writer, bool) {
+	out, err := output.NewWriter(os.Stdout, format, key)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil, false
+	}
+	return out, true
+}
+
+// splitRuleNames splits comma-separated rule names, dropping empty ones
+func splitRuleNames(names ...string) []string {
+	split := make([]string, 0, len(names))
+	for _, name := range names {
+		for _, part := range strings.Split(name, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				split = append(split, part)
 			}
 		}
+	}
+	return split
+}
 
-		return
+// listFlag collects repeated, comma-separated values
+type listFlag []string
+
+// String implements flag.Value
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set implements flag.Value
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, splitRuleNames(value)...)
+	return nil
+}
+
+// worstExit returns the more severe of two exit codes, where an error
+// outranks a partial failure
+func worstExit(a, b int) int {
+	if a == exitError || b == exitError {
+		return exitError
 	}
+	if a > b {
+		return a
+	}
+	return b
+}
 
-	// Interactive mode - Initialize UI model
-	model := ui.New(cfg, rulesManager, linkerInstance)
-	manager := ui.NewManager(model)
+// unwrapErrors returns the errors joined in err, or err itself
+func unwrapErrors(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
 
-	// Log paths for debugging
-	fmt.Printf("Starting UI with Rules repository: %s\n", cfg.RulesRepoPath)
-	fmt.Printf("Starting UI with Target project: %s\n", cfg.TargetProjectPath)
+// printLinkError prints a linking error to stderr, listing each conflicting
+// rule on its own line for collisions
+func printLinkError(err error) {
+	var collisionErr *linker.CollisionError
+	if !errors.As(err, &collisionErr) {
+		fmt.Fprintf(os.Stderr, "Error linking rules: %v\n", err)
+		return
+	}
 
-	// Run the application with full screen and mouse support
-	p := tea.NewProgram(
-		manager,
-		tea.WithAltScreen(),       // Use alternate screen buffer
-		tea.WithMouseCellMotion(), // Enable mouse support
-	)
+	fmt.Fprintln(os.Stderr, "Error: rules would overwrite each other when linked:")
+	for _, c := range collisionErr.Collisions {
+		fmt.Fprintf(os.Stderr, "  %s:\n", c.FileName)
+		for _, id := range c.Rules {
+			fmt.Fprintf(os.Stderr, "    - %s\n", id)
+		}
+		if c.Existing != "" {
+			fmt.Fprintf(os.Stderr, "    - existing link to %s\n", c.Existing)
+		}
+	}
+	fmt.Fprintln(os.Stderr, "Link the rules separately, rename one of them, or use --on-collision suffix")
+}
 
-	if _, err := p.Run(); err != nil {
-		fmt.Printf("Error running program: %v\n", err)
-		os.Exit(1)
+// editorNames returns the names of every registered editor adapter
+func editorNames() []string {
+	names := make([]string, 0)
+	for _, adapter := range linker.Adapters() {
+		names = append(names, adapter.Name())
 	}
+	return names
 }
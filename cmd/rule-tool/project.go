@@ -0,0 +1,212 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/circleci/llm-agent-rules/internal/config"
+	"github.com/circleci/llm-agent-rules/internal/linker"
+	"github.com/circleci/llm-agent-rules/internal/rules"
+	"github.com/circleci/llm-agent-rules/internal/ui"
+)
+
+// projectFlags are the flags shared by subcommands that work on the rules
+// installed in a target project
+type projectFlags struct {
+	repoPath   *string
+	sources    *sourceFlag
+	targetPath *string
+	quiet      *bool
+	dryRun     *bool
+	verbose    *bool
+	force      *bool
+	vars       *varsFlag
+
+	targetFormat *string
+	mode         *string
+	onCollision  *string
+
+	// config is the resolved configuration, set by load
+	config *config.Config
+}
+
+// addProjectFlags registers the repository, source, target path and quiet flags on fs
+func addProjectFlags(fs *flag.FlagSet) *projectFlags {
+	p := &projectFlags{
+		repoPath:     fs.String("repo-path", "", "Path or git URL of the rules repository (overrides RULE_TOOL_PATH environment variable if set)"),
+		sources:      new(sourceFlag),
+		targetPath:   fs.String("target-path", "", "Path to the target project (overrides RULE_TARGET_PATH environment variable if set)"),
+		quiet:        fs.Bool("quiet", false, "Suppress the banner and progress messages"),
+		dryRun:       new(bool),
+		verbose:      new(bool),
+		force:        new(bool),
+		vars:         new(varsFlag),
+		targetFormat: new(string),
+		mode:         new(string),
+		onCollision:  new(string),
+	}
+	fs.Var(p.sources, "source", sourceUsage)
+	return p
+}
+
+// addLinkFlags registers the flags of subcommands that change installed rules
+func (p *projectFlags) addLinkFlags(fs *flag.FlagSet) {
+	fs.BoolVar(p.dryRun, "dry-run", false, "Show what would be done without making changes")
+	fs.BoolVar(p.verbose, "verbose", false, "Enable verbose output")
+	fs.BoolVar(p.force, "force", false, "Overwrite copied rules that were edited in the target project")
+	fs.Var(p.vars, "set", varsUsage)
+}
+
+// addEditorFlags registers the flags choosing the editor format and how rules are installed for it
+func (p *projectFlags) addEditorFlags(fs *flag.FlagSet) {
+	p.addFormatFlag(fs)
+	fs.StringVar(p.mode, "mode", "", "How rules are installed: symlink or copy (overrides RULE_TOOL_MODE environment variable if set)")
+	fs.StringVar(p.onCollision, "on-collision", string(linker.CollisionFail), "How to handle rules that link to the same file name: fail or suffix")
+}
+
+// addFormatFlag registers the flag choosing the editor format rules are installed for
+func (p *projectFlags) addFormatFlag(fs *flag.FlagSet) {
+	fs.StringVar(p.targetFormat, "target-format", "", "Editor or agent format to install rules for: "+strings.Join(editorNames(), ", ")+" (default "+linker.DefaultEditor+")")
+}
+
+// editor returns the name of the editor adapter rules are installed with
+func (p *projectFlags) editor() (string, error) {
+	adapter, err := linker.LookupAdapter(firstNonEmpty(p.config.Editor, linker.DefaultEditor))
+	if err != nil {
+		return "", err
+	}
+	return adapter.Name(), nil
+}
+
+// load resolves the configuration, loads the rules and creates a linker for the target project
+func (p *projectFlags) load() (*rules.Manager, *linker.Linker, error) {
+	cfg := config.New()
+	if *p.repoPath != "" {
+		cfg.SetRulesRepoPath(*p.repoPath)
+	}
+	if *p.targetPath != "" {
+		cfg.SetTargetProjectPath(*p.targetPath)
+	}
+	if *p.targetFormat != "" {
+		cfg.SetEditor(*p.targetFormat)
+	}
+	if *p.mode != "" {
+		cfg.SetLinkMode(*p.mode)
+	}
+	if err := addSources(cfg, *p.sources); err != nil {
+		return nil, nil, err
+	}
+	if err := cfg.LoadFiles(); err != nil {
+		return nil, nil, err
+	}
+	p.config = cfg
+	if err := ui.ApplyTheme(cfg.Theme); err != nil {
+		return nil, nil, err
+	}
+
+	if *p.verbose {
+		reportPathOrigins(*p.repoPath, *p.targetPath)
+	}
+	if err := resolveRulesRepo(cfg, *p.quiet); err != nil {
+		return nil, nil, err
+	}
+	if !cfg.ValidateRulesRepoPath() {
+		return nil, nil, fmt.Errorf("invalid rules repository path: %s", cfg.RulesRepoPath)
+	}
+	if !cfg.ValidateTargetProjectPath() {
+		return nil, nil, fmt.Errorf("invalid target project path: %s", cfg.TargetProjectPath)
+	}
+	if *p.verbose {
+		fmt.Fprintf(os.Stderr, "Resolved rules path: %s\n", cfg.RulesRepoPath)
+		fmt.Fprintf(os.Stderr, "Resolved target path: %s\n", cfg.TargetProjectPath)
+	}
+
+	rulesManager := rules.NewLayeredManager(cfg.RuleSources())
+	if err := rulesManager.LoadRules(); err != nil {
+		return nil, nil, fmt.Errorf("error loading rules: %w", err)
+	}
+	for _, err := range rulesManager.Invalid {
+		fmt.Fprintf(os.Stderr, "Warning: skipping %v\n", err)
+	}
+	if len(rulesManager.Invalid) > 0 {
+		fmt.Fprintln(os.Stderr, "Run 'rule-tool validate' for details")
+	}
+
+	l := linker.NewLinker(cfg.TargetProjectPath)
+	l.SetDryRun(*p.dryRun)
+	l.SetVerbose(*p.verbose)
+	l.SetForce(*p.force)
+	l.SetSourceRoot(cfg.RulesRepoPath)
+	l.SetSources(cfg.RuleSources())
+	vars := maps.Clone(cfg.Vars)
+	if vars == nil {
+		vars = make(map[string]string)
+	}
+	maps.Copy(vars, *p.vars)
+	l.SetVars(vars)
+
+	if cfg.LinkMode != "" {
+		mode, err := linker.ParseLinkMode(cfg.LinkMode)
+		if err != nil {
+			return nil, nil, err
+		}
+		l.SetMode(mode)
+	}
+	if *p.onCollision != "" {
+		strategy, err := linker.ParseCollisionStrategy(*p.onCollision)
+		if err != nil {
+			return nil, nil, err
+		}
+		l.SetCollisionStrategy(strategy)
+	}
+
+	if err := recoverInterrupted(l); err != nil {
+		return nil, nil, err
+	}
+	return rulesManager, l, nil
+}
+
+// recoverInterrupted rolls back the changes of a run that was interrupted
+// while changing the target project. Dry runs only warn about it.
+func recoverInterrupted(l *linker.Linker) error {
+	if l.DryRun {
+		if _, err := os.Stat(filepath.Join(l.TargetDir, linker.JournalDir)); err == nil {
+			fmt.Fprintln(os.Stderr, "Warning: a previous run was interrupted; run without --dry-run to recover")
+		}
+		return nil
+	}
+
+	restored, err := l.Recover()
+	if err != nil {
+		return err
+	}
+	if restored > 0 {
+		fmt.Fprintf(os.Stderr, "Recovered from an interrupted run: restored %d paths\n", restored)
+	}
+	return nil
+}
+
+// reportPathOrigins prints where the rules and target paths were taken from
+func reportPathOrigins(repoPath, targetPath string) {
+	switch {
+	case repoPath != "":
+		fmt.Fprintln(os.Stderr, "Using rules path from command line flag")
+	case os.Getenv(config.EnvRulesPath) != "":
+		fmt.Fprintf(os.Stderr, "Using rules path from %s environment variable\n", config.EnvRulesPath)
+	default:
+		fmt.Fprintln(os.Stderr, "Using current directory as rules path")
+	}
+
+	switch {
+	case targetPath != "":
+		fmt.Fprintln(os.Stderr, "Using target path from command line flag")
+	case os.Getenv(config.EnvTargetPath) != "":
+		fmt.Fprintf(os.Stderr, "Using target path from %s environment variable\n", config.EnvTargetPath)
+	default:
+		fmt.Fprintln(os.Stderr, "Using current directory as target path")
+	}
+}
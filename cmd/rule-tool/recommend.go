@@ -0,0 +1,148 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/circleci/llm-agent-rules/internal/linker"
+	"github.com/circleci/llm-agent-rules/internal/output"
+	"github.com/circleci/llm-agent-rules/internal/rules"
+	"github.com/circleci/llm-agent-rules/internal/scan"
+)
+
+// recommendExamples is the number of matching files shown for each recommended rule
+const recommendExamples = 3
+
+// runRecommend implements the recommend command and returns the exit code
+func runRecommend(args []string) int {
+	fs := flag.NewFlagSet("recommend", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: rule-tool recommend [flags]\n\n")
+		fmt.Fprintf(fs.Output(), "Recommend rules for the target project by matching the globs of each rule\n")
+		fmt.Fprintf(fs.Output(), "against its files, skipping files ignored by git. Rules are ranked by how many\n")
+		fmt.Fprintf(fs.Output(), "files they apply to; --link installs the recommended rules and, after\n")
+		fmt.Fprintf(fs.Output(), "confirmation, the rules they require.\n\n")
+		fs.PrintDefaults()
+	}
+	project := addProjectFlags(fs)
+	project.addLinkFlags(fs)
+	project.addEditorFlags(fs)
+	tags := addTagFlags(fs)
+	limit := fs.Int("limit", 0, "Only recommend this many rules (0 recommends every matching rule)")
+	link := fs.Bool("link", false, "Link the recommended rules that are not installed")
+	yes := fs.Bool("yes", false, "Link the rules required by the recommended rules without asking for confirmation")
+	format := addOutputFlag(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 0 || *limit < 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	out, ok := newOutput(*format, "recommendations")
+	if !ok {
+		return exitUsage
+	}
+
+	rulesManager, l, err := project.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	editor, err := project.editor()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	files, err := scan.Files(l.TargetDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error scanning %s: %v\n", l.TargetDir, err)
+		return exitError
+	}
+	recommendations := rules.Recommend(rulesManager.Filter(tags.filter()), files)
+	if *limit > 0 && len(recommendations) > *limit {
+		recommendations = recommendations[:*limit]
+	}
+	if !*project.quiet {
+		fmt.Fprintf(os.Stderr, "Scanned %d files in %s; %d rules apply to them\n", len(files), l.TargetDir, len(recommendations))
+	}
+
+	records := make([]output.Recommendation, 0, len(recommendations))
+	toLink := make([]string, 0)
+	for _, recommendation := range recommendations {
+		ruleName := linker.RuleID(recommendation.Rule)
+		installed := isInstalled(rulesManager, l, recommendation.Rule, editor)
+		records = append(records, output.Recommendation{
+			Rule:      ruleName,
+			Files:     len(recommendation.Files),
+			Globs:     recommendation.Globs,
+			Examples:  recommendation.Files[:min(len(recommendation.Files), recommendExamples)],
+			Installed: installed,
+			Format:    editor,
+		})
+		if !installed {
+			toLink = append(toLink, ruleName)
+		}
+	}
+	if !out.Structured() {
+		if err := writeRecommendations(os.Stdout, records); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing recommendations: %v\n", err)
+			return exitError
+		}
+	}
+
+	code := exitOK
+	if *link && len(toLink) > 0 {
+		// Structured output carries the recommendations, marked as linked, so
+		// the link results are only reported on stderr
+		results := out
+		if out.Structured() {
+			results, _ = output.NewWriter(io.Discard, output.FormatJSON, "")
+		} else {
+			fmt.Println()
+		}
+		code = linkRules(rulesManager, l, editor, toLink, *project.verbose, *yes, results)
+		for i := range records {
+			rule := rulesManager.GetRuleByName(records[i].Rule)
+			records[i].Linked = !records[i].Installed && !l.DryRun && isInstalled(rulesManager, l, rule, editor)
+		}
+	} else if *link && !*project.quiet {
+		fmt.Fprintln(os.Stderr, "Every recommended rule is already installed")
+	}
+
+	for _, record := range records {
+		if err := out.Record(record); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			return exitError
+		}
+	}
+	return worstExit(code, closeOutput(out))
+}
+
+// writeRecommendations prints the recommended rules as a ranked table
+func writeRecommendations(w io.Writer, records []output.Recommendation) error {
+	if len(records) == 0 {
+		_, err := fmt.Fprintln(w, "No rule globs match the files of the target project")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tRULE\tFILES\tGLOBS\tEXAMPLES\t")
+	for i, record := range records {
+		ruleName := record.Rule
+		if record.Installed {
+			ruleName += " [INSTALLED]"
+		}
+		examples := strings.Join(record.Examples, ", ")
+		if more := record.Files - len(record.Examples); more > 0 {
+			examples += fmt.Sprintf(" (+%d more)", more)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%s\t\n", i+1, ruleName, record.Files, strings.Join(record.Globs, ", "), examples)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/circleci/llm-agent-rules/internal/linker"
+	"github.com/circleci/llm-agent-rules/internal/output"
+	"github.com/circleci/llm-agent-rules/internal/rules"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// addRequirements appends the rules that the named rules require, directly
+// or through other requirements, and that are not installed for editor, to
+// names. It fails when a requirement is unknown or when the rules conflict
+// with each other or with the installed rules. Unknown names are left for
+// linkRules to report.
+func addRequirements(rulesManager *rules.Manager, l *linker.Linker, editor string, names []string) ([]string, []rules.Requirement, error) {
+	named := make([]*models.Rule, 0, len(names))
+	for _, name := range names {
+		if rule := rulesManager.GetRuleByName(name); rule != nil {
+			named = append(named, rule)
+		}
+	}
+	requirements, err := rulesManager.Requirements(named)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	missing := make([]rules.Requirement, 0, len(requirements))
+	adding := named
+	for _, requirement := range requirements {
+		if isInstalled(rulesManager, l, requirement.Rule, editor) {
+			continue
+		}
+		missing = append(missing, requirement)
+		adding = append(adding, requirement.Rule)
+		names = append(names, linker.RuleID(requirement.Rule))
+	}
+
+	installed := make([]*models.Rule, 0)
+	if lock, err := l.Lockfile(); err == nil {
+		for _, entry := range lock.Entries(editor) {
+			if rule, ok := l.EntryRule(entry, rulesManager.All()); ok {
+				installed = append(installed, rule)
+			}
+		}
+	}
+	if conflicts := rulesManager.Conflicts(adding, installed); len(conflicts) > 0 {
+		return nil, nil, conflictError(conflicts, installed)
+	}
+	return names, missing, nil
+}
+
+// resolveRequirements adds the rules the named rules require to names,
+// after listing them and asking for confirmation unless yes is set or the
+// linker is in dry-run mode, and refuses rules that conflict. It returns
+// false when nothing should be linked, which it has reported on stderr.
+func resolveRequirements(rulesManager *rules.Manager, l *linker.Linker, editor string, names []string, yes bool, out *output.Writer) ([]string, bool) {
+	names, requirements, err := addRequirements(rulesManager, l, editor, names)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil, false
+	}
+	if len(requirements) == 0 {
+		return names, true
+	}
+
+	// Keep stdout for the records in structured output
+	w := os.Stdout
+	if out.Structured() {
+		w = os.Stderr
+	}
+	if err := writeRequirements(w, requirements); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing requirements: %v\n", err)
+		return nil, false
+	}
+	if !yes && !l.DryRun && !confirm(os.Stdin, "Link these rules as well? [y/N]: ") {
+		fmt.Fprintln(os.Stderr, "Link cancelled.")
+		return nil, false
+	}
+	return names, true
+}
+
+// conflictError explains which rules conflict, marking the installed ones
+func conflictError(conflicts []rules.Conflict, installed []*models.Rule) error {
+	installedSet := make(map[*models.Rule]bool, len(installed))
+	for _, rule := range installed {
+		installedSet[rule] = true
+	}
+	describe := func(rule *models.Rule) string {
+		if installedSet[rule] {
+			return linker.RuleID(rule) + " (installed)"
+		}
+		return linker.RuleID(rule)
+	}
+
+	var b strings.Builder
+	hint := "Leave one rule of each pair out"
+	b.WriteString("Cannot link rules that conflict:\n")
+	for _, conflict := range conflicts {
+		fmt.Fprintf(&b, "  %s declares a conflict with %s\n", describe(conflict.Rule), describe(conflict.With))
+		if installedSet[conflict.Rule] || installedSet[conflict.With] {
+			hint = "Leave one rule of each pair out, or unlink the installed one first"
+		}
+	}
+	b.WriteString(hint)
+	return errors.New(b.String())
+}
+
+// writeRequirements prints the required rules that are linked along with the named ones
+func writeRequirements(w io.Writer, requirements []rules.Requirement) error {
+	fmt.Fprintf(w, "These rules are required by the rules being linked and will be linked too:\n\n")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, requirement := range requirements {
+		fmt.Fprintf(tw, "  + %s\t(required by %s)\n", linker.RuleID(requirement.Rule), linker.RuleID(requirement.RequiredBy))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,572 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/circleci/llm-agent-rules/internal/linker"
+	"github.com/circleci/llm-agent-rules/internal/output"
+	"github.com/circleci/llm-agent-rules/internal/rules"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// Styles of the plain-text rule listings, which lipgloss drops when stdout is not a terminal
+var (
+	bannerStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#00FFFF"))
+	ruleNameStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF69B4"))
+	ruleDescStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#87CEEB"))
+)
+
+// runList implements the list command and returns the exit code
+func runList(args []string) int {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: rule-tool list [flags]\n\n")
+		fmt.Fprintf(fs.Output(), "List the rules in the rules repository, with the rules hidden by a later source.\n\n")
+		fs.PrintDefaults()
+	}
+	project := addProjectFlags(fs)
+	project.addFormatFlag(fs)
+	tags := addTagFlags(fs)
+	format := addOutputFlag(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	out, ok := newOutput(*format, "rules")
+	if !ok {
+		return exitUsage
+	}
+
+	rulesManager, l, err := project.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	if !out.Structured() {
+		printRuleList(rulesManager, tags.filter())
+		return exitOK
+	}
+
+	editor, err := project.editor()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	for _, rule := range rules.FilterRules(rulesManager.All(), tags.filter()) {
+		if err := out.Record(ruleRecord(rulesManager, l, rule, editor)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			return exitError
+		}
+	}
+	return closeOutput(out)
+}
+
+// runShow implements the show command and returns the exit code
+func runShow(args []string) int {
+	fs := flag.NewFlagSet("show", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: rule-tool show [flags] <rule>\n\n")
+		fmt.Fprintf(fs.Output(), "Show a rule's metadata, whether it is installed, and its content.\n")
+		fmt.Fprintf(fs.Output(), "The rule is given as name, topic/name or source:topic/name.\n\n")
+		fs.PrintDefaults()
+	}
+	project := addProjectFlags(fs)
+	project.addFormatFlag(fs)
+	format := addOutputFlag(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	out, ok := newOutput(*format, "")
+	if !ok {
+		return exitUsage
+	}
+
+	rulesManager, l, err := project.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	editor, err := project.editor()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	rule := rulesManager.GetRuleByName(fs.Arg(0))
+	if rule == nil {
+		fmt.Fprintf(os.Stderr, "Rule not found: %s\n", fs.Arg(0))
+		return exitError
+	}
+
+	if out.Structured() {
+		record := ruleRecord(rulesManager, l, rule, editor)
+		record.Content = rule.Body
+		if rule.Templated() {
+			record.Vars = describeVars(rule)
+		}
+		if err := out.Record(record); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			return exitError
+		}
+		return closeOutput(out)
+	}
+
+	fmt.Printf("Name:         %s\n", ruleNameStyle.Render(linker.RuleID(rule)))
+	if rulesManager.Layered() {
+		fmt.Printf("Source:       %s%s\n", rule.Source, overrideNote(rule))
+		if rule.ShadowedBy != "" {
+			fmt.Printf("Shadowed by:  %s\n", rule.ShadowedBy)
+		}
+	}
+	fmt.Printf("Path:         %s\n", rule.Path)
+	fmt.Printf("Description:  %s\n", ruleDescStyle.Render(rule.Description))
+	if len(rule.Globs) > 0 {
+		fmt.Printf("Globs:        %s\n", strings.Join(rule.Globs, ", "))
+	}
+	fmt.Printf("Always apply: %t\n", rule.AlwaysApply)
+	if len(rule.Tags) > 0 {
+		fmt.Printf("Tags:         %s\n", strings.Join(rule.Tags, ", "))
+	}
+	if len(rule.Requires) > 0 {
+		fmt.Printf("Requires:     %s\n", strings.Join(rule.Requires, ", "))
+	}
+	if len(rule.Conflicts) > 0 {
+		fmt.Printf("Conflicts:    %s\n", strings.Join(rule.Conflicts, ", "))
+	}
+	if rule.Templated() {
+		fmt.Printf("Vars:         %s\n", strings.Join(describeVars(rule), ", "))
+	}
+	fmt.Printf("Installed:    %t (%s)\n", isInstalled(rulesManager, l, rule, editor), editor)
+	fmt.Printf("\n%s", rule.Body)
+	if !strings.HasSuffix(rule.Body, "\n") {
+		fmt.Println()
+	}
+	return exitOK
+}
+
+// runLink implements the link command and returns the exit code
+func runLink(args []string) int {
+	fs := flag.NewFlagSet("link", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: rule-tool link [flags] <rule>...\n")
+		fmt.Fprintf(fs.Output(), "       rule-tool link [flags] --bundle <bundle>...\n")
+		fmt.Fprintf(fs.Output(), "       rule-tool link [flags] --tag <tag>...\n\n")
+		fmt.Fprintf(fs.Output(), "Install rules into the target project. Rules are given as name, topic/name\n")
+		fmt.Fprintf(fs.Output(), "or source:topic/name, as separate arguments or comma-separated, or as bundles\n")
+		fmt.Fprintf(fs.Output(), "defined in the rules repository. Without rules or bundles, every rule matching\n")
+		fmt.Fprintf(fs.Output(), "the --tag and --exclude-tag filters is linked.\n")
+		fmt.Fprintf(fs.Output(), "The rules these rules require are linked too, after confirmation, and rules\n")
+		fmt.Fprintf(fs.Output(), "that conflict with each other or with the installed rules are not linked.\n")
+		fmt.Fprintf(fs.Output(), "Exits with status 3 when only some of the rules could be linked.\n\n")
+		fs.PrintDefaults()
+	}
+	project := addProjectFlags(fs)
+	project.addLinkFlags(fs)
+	project.addEditorFlags(fs)
+	tags := addTagFlags(fs)
+	bundles := new(listFlag)
+	fs.Var(bundles, "bundle", "Link the rules of a bundle from the rules repository, repeatable or comma-separated")
+	yes := fs.Bool("yes", false, "Link the rules required by the linked rules without asking for confirmation")
+	format := addOutputFlag(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	names := splitRuleNames(fs.Args()...)
+	filter := tags.filter()
+	if len(names) == 0 && len(*bundles) == 0 && filter.Empty() {
+		fs.Usage()
+		return exitUsage
+	}
+	out, ok := newOutput(*format, "results")
+	if !ok {
+		return exitUsage
+	}
+
+	rulesManager, l, err := project.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	editor, err := project.editor()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	if len(*bundles) > 0 {
+		bundleNames, err := bundleRuleNames(rulesManager, *bundles)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return worstExit(exitError, closeOutput(out))
+		}
+		names = appendMissing(names, bundleNames...)
+	}
+	if !filter.Empty() {
+		if len(names) == 0 {
+			names = taggedRuleNames(rulesManager, filter)
+		} else {
+			names = filterRuleNames(rulesManager, names, filter, *project.quiet)
+		}
+		if len(names) == 0 {
+			fmt.Fprintf(os.Stderr, "No rules match %s\n", filter)
+			return worstExit(exitError, closeOutput(out))
+		}
+	}
+
+	code := linkRules(rulesManager, l, editor, names, *project.verbose, *yes, out)
+	return worstExit(code, closeOutput(out))
+}
+
+// runUnlink implements the unlink command and returns the exit code
+func runUnlink(args []string) int {
+	fs := flag.NewFlagSet("unlink", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: rule-tool unlink [flags] <rule>...\n\n")
+		fmt.Fprintf(fs.Output(), "Remove installed rules from the target project. Rules are given as separate\n")
+		fmt.Fprintf(fs.Output(), "arguments or comma-separated.\n")
+		fmt.Fprintf(fs.Output(), "Exits with status 3 when only some of the rules could be unlinked.\n\n")
+		fs.PrintDefaults()
+	}
+	project := addProjectFlags(fs)
+	project.addLinkFlags(fs)
+	project.addFormatFlag(fs)
+	format := addOutputFlag(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	names := splitRuleNames(fs.Args()...)
+	if len(names) == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	out, ok := newOutput(*format, "results")
+	if !ok {
+		return exitUsage
+	}
+
+	rulesManager, l, err := project.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	editor, err := project.editor()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	code := unlinkRules(rulesManager, l, editor, names, out)
+	return worstExit(code, closeOutput(out))
+}
+
+// printBanner prints the rules sources and target project
+func printBanner(rulesManager *rules.Manager, targetPath string) {
+	fmt.Println(bannerStyle.Render("Rule Tool CLI"))
+	fmt.Println(bannerStyle.Render("---------------"))
+	if rulesManager.Layered() {
+		fmt.Println("Rules sources (later sources override earlier ones):")
+		for _, source := range rulesManager.Sources {
+			fmt.Printf("  %s: %s\n", source.ID, describeSource(source))
+		}
+	} else {
+		fmt.Printf("Rules repository: %s\n", describeSource(rulesManager.Sources[0]))
+	}
+	fmt.Printf("Target project: %s\n", targetPath)
+	fmt.Printf("Found %d rules\n", len(rulesManager.Rules))
+}
+
+// printRuleList prints the available rules matching filter, numbered,
+// followed by the shadowed ones
+func printRuleList(rulesManager *rules.Manager, filter rules.TagFilter) {
+	for i, rule := range rulesManager.Filter(filter) {
+		ruleName := linker.RuleID(rule)
+		if rulesManager.Layered() {
+			ruleName = rules.QualifiedName(rule)
+		}
+		fmt.Printf("%d. %s%s: %s\n",
+			i+1,
+			ruleNameStyle.Render(ruleName),
+			overrideNote(rule),
+			ruleDescStyle.Render(rule.Description))
+	}
+
+	if len(rulesManager.Bundles) > 0 && filter.Empty() {
+		fmt.Println("\nBundles:")
+		for _, bundle := range rulesManager.Bundles {
+			fmt.Printf("- %s%s\n", ruleNameStyle.Render(bundle.Name), bundleSummary(rulesManager, bundle.Name, bundle.Description))
+		}
+	}
+
+	shadowed := rules.FilterRules(rulesManager.Shadowed, filter)
+	if len(shadowed) > 0 {
+		fmt.Println("\nShadowed Rules:")
+		for _, rule := range shadowed {
+			fmt.Printf("- %s (overridden by %s)\n", ruleNameStyle.Render(rules.QualifiedName(rule)), rule.ShadowedBy)
+		}
+	}
+}
+
+// linkRules links the named rules for editor, and the rules they require
+// once confirmed (yes skips the confirmation), writing a result record for
+// each, and returns the exit code. Conflicting rules are not linked at all.
+// Unknown rules and rules that fail to link are reported on stderr without
+// stopping the others.
+func linkRules(rulesManager *rules.Manager, l *linker.Linker, editor string, names []string, verbose, yes bool, out *output.Writer) int {
+	names, ok := resolveRequirements(rulesManager, l, editor, names, yes, out)
+	if !ok {
+		return exitError
+	}
+
+	failed := 0
+	fail := func(ruleName string, err error) {
+		failed++
+		out.Record(output.Failed("link", ruleName, editor, err))
+	}
+
+	rulesToLink := make([]*models.Rule, 0)
+	for _, ruleName := range names {
+		rule := rulesManager.GetRuleByName(ruleName)
+		if rule == nil {
+			fmt.Fprintf(os.Stderr, "Rule not found: %s\n", ruleName)
+			fail(ruleName, errors.New("rule not found"))
+			continue
+		}
+
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Debug - Rule found: %s\n", ruleName)
+			fmt.Fprintf(os.Stderr, "Debug - Rule topic: %s\n", rule.Topic)
+			fmt.Fprintf(os.Stderr, "Debug - Rule path: %s\n", rule.Path)
+		}
+		rulesToLink = append(rulesToLink, rule)
+	}
+	if len(rulesToLink) == 0 {
+		return exitError
+	}
+
+	// Work out every target file name up front so no rule overwrites another
+	plan, err := l.PlanTargets(rulesToLink, editor)
+	if err != nil {
+		printLinkError(err)
+		for _, rule := range rulesToLink {
+			fail(linker.RuleID(rule), err)
+		}
+		return exitError
+	}
+
+	if l.DryRun {
+		for _, rule := range rulesToLink {
+			ruleName := linker.RuleID(rule)
+			out.Record(output.Result{Operation: "link", Rule: ruleName, Format: editor, Destination: plannedDestination(editor, plan[rule.Path]), DryRun: true, OK: true})
+			if out.Structured() {
+				continue
+			}
+			fmt.Printf("Would link rule: %s -> %s\n", ruleName, plan[rule.Path])
+
+			// Display subfolder structure if applicable
+			if rule.Topic != "" && verbose {
+				fmt.Printf("Would maintain subfolder structure: %s\n", rule.Topic)
+			}
+		}
+		return failureExit(failed, len(names))
+	}
+
+	// Link the rules in one transaction, so no rule stays linked if another fails
+	index, err := applyBatch(l, len(rulesToLink), func(i int) error {
+		return l.LinkRuleAs(rulesToLink[i], editor, plan[rulesToLink[i].Path])
+	})
+	if err != nil {
+		ruleNames := make([]string, 0, len(rulesToLink))
+		for i, rule := range rulesToLink {
+			ruleNames = append(ruleNames, linker.RuleID(rule))
+			fail(ruleNames[i], batchError(i, index, err))
+		}
+		printBatchError("linking", ruleNames, index, err)
+		return exitError
+	}
+
+	for _, rule := range rulesToLink {
+		ruleName := linker.RuleID(rule)
+		out.Record(output.Result{Operation: "link", Rule: ruleName, Format: editor, Destination: installedDestination(l, editor, ruleName), OK: true})
+		if !out.Structured() {
+			fmt.Printf("Linked rule: %s\n", ruleName)
+		}
+	}
+	return failureExit(failed, len(names))
+}
+
+// unlinkRules unlinks the named rules for editor, writing a result record
+// for each, and returns the exit code
+func unlinkRules(rulesManager *rules.Manager, l *linker.Linker, editor string, names []string, out *output.Writer) int {
+	ruleNames := make([]string, 0, len(names))
+	results := make([]output.Result, 0, len(names))
+	for _, ruleName := range names {
+		// Installed rules are recorded without their source
+		if strings.Contains(ruleName, models.SourceSeparator) {
+			if rule := rulesManager.GetRuleByName(ruleName); rule != nil {
+				ruleName = linker.RuleID(rule)
+			}
+		}
+		ruleNames = append(ruleNames, ruleName)
+		results = append(results, output.Result{Operation: "unlink", Rule: ruleName, Format: editor, Destination: installedDestination(l, editor, ruleName), DryRun: l.DryRun, OK: true})
+	}
+
+	// Unlink the rules in one transaction, so none is removed if another
+	// fails. Dry runs look the rules up the same way without removing them.
+	index, err := applyBatch(l, len(ruleNames), func(i int) error {
+		return l.UnlinkRule(ruleNames[i], editor)
+	})
+	if err != nil {
+		for i, ruleName := range ruleNames {
+			out.Record(output.Failed("unlink", ruleName, editor, batchError(i, index, err)))
+		}
+		printBatchError("unlinking", ruleNames, index, err)
+		return exitError
+	}
+
+	for i, result := range results {
+		out.Record(result)
+		if out.Structured() {
+			continue
+		}
+		if l.DryRun {
+			fmt.Printf("Would unlink rule: %s\n", ruleNames[i])
+		} else {
+			fmt.Printf("Unlinked rule: %s\n", ruleNames[i])
+		}
+	}
+	return exitOK
+}
+
+// errRolledBack is recorded for the rules of a batch that were rolled back
+// because another rule in it failed
+var errRolledBack = errors.New("rolled back because another rule failed")
+
+// applyBatch runs op for each of count rules in one transaction. If op fails
+// for a rule, the changes made for the rules before it are rolled back and
+// its index is returned with the error; the index is -1 when the
+// transaction itself failed.
+func applyBatch(l *linker.Linker, count int, op func(i int) error) (int, error) {
+	index := -1
+	err := l.Transact(func() error {
+		for i := 0; i < count; i++ {
+			if err := op(i); err != nil {
+				index = i
+				return err
+			}
+		}
+		return nil
+	})
+	return index, err
+}
+
+// batchError returns the error recorded for rule i of a failed batch
+func batchError(i, index int, err error) error {
+	if index >= 0 && i != index {
+		return errRolledBack
+	}
+	return err
+}
+
+// printBatchError reports a failed batch and that its changes were rolled back
+func printBatchError(verb string, ruleNames []string, index int, err error) {
+	if index >= 0 {
+		fmt.Fprintf(os.Stderr, "Error %s rule %s: %v\n", verb, ruleNames[index], err)
+	} else {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+	if len(ruleNames) > 1 {
+		fmt.Fprintln(os.Stderr, "No changes were made; the other rules were rolled back")
+	}
+}
+
+// ruleRecord returns the structured record of a rule
+func ruleRecord(rulesManager *rules.Manager, l *linker.Linker, rule *models.Rule, editor string) output.Rule {
+	return output.NewRule(rule, linker.RuleID(rule), editor, isInstalled(rulesManager, l, rule, editor))
+}
+
+// isInstalled reports whether a rule is installed for editor. Of several
+// rules with the same topic/name, only the one the lockfile entry was
+// installed from counts as installed.
+func isInstalled(rulesManager *rules.Manager, l *linker.Linker, rule *models.Rule, editor string) bool {
+	if !l.IsRuleLinked(rule, editor) {
+		return false
+	}
+	lock, err := l.Lockfile()
+	if err != nil {
+		return true
+	}
+	entry, ok := lock.Get(editor, linker.RuleID(rule))
+	if !ok {
+		return true
+	}
+	installed, _ := l.EntryRule(entry, rulesManager.All())
+	return installed == rule
+}
+
+// installedDestination returns where a rule is installed for editor,
+// relative to the target project, as recorded in the lockfile
+func installedDestination(l *linker.Linker, editor, id string) string {
+	lock, err := l.Lockfile()
+	if err != nil {
+		return ""
+	}
+	entry, _ := lock.Find(editor, id)
+	return entry.Destination
+}
+
+// plannedDestination returns where a rule would be linked as fileName,
+// relative to the target project
+func plannedDestination(editor, fileName string) string {
+	adapter, err := linker.LookupAdapter(editor)
+	if err != nil {
+		return fileName
+	}
+	return path.Join(adapter.TargetDir(), fileName)
+}
+
+// closeOutput writes the json document of a command and returns the exit code
+func closeOutput(out *output.Writer) int {
+	if err := out.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// failureExit returns the exit code of a command where failed of total changes failed
+func failureExit(failed, total int) int {
+	switch {
+	case failed == 0:
+		return exitOK
+	case failed < total:
+		return exitPartial
+	}
+	return exitError
+}
+
+// describeSource returns a source's location for display, with the commit
+// of repositories fetched from git
+func describeSource(source models.Source) string {
+	if source.URL != "" {
+		return fmt.Sprintf("%s (%s)", source.URL, source.Commit)
+	}
+	return source.Root
+}
+
+// overrideNote describes the sources a rule overrides, if any
+func overrideNote(rule *models.Rule) string {
+	if len(rule.Overrides) == 0 {
+		return ""
+	}
+	return " (overrides " + strings.Join(rule.Overrides, ", ") + ")"
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/circleci/llm-agent-rules/internal/config"
+	"github.com/circleci/llm-agent-rules/internal/source"
+)
+
+// sourceFlag collects repeated --source flags in order
+type sourceFlag []string
+
+// String implements flag.Value
+func (s *sourceFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+// Set implements flag.Value
+func (s *sourceFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// sourceUsage is the help text of the --source flag
+const sourceUsage = "Additional rules repository as [id=]path-or-git-url, repeatable; later sources override earlier ones"
+
+// addSources adds the --source flags to the configuration
+func addSources(cfg *config.Config, sources sourceFlag) error {
+	for _, spec := range sources {
+		if err := cfg.AddSource(spec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveRulesRepo fetches the rules repository and any added sources given
+// as git URLs into the cache, and reports the commits they resolved to
+// unless quiet. Progress goes to stderr so machine-readable output on stdout
+// stays clean.
+func resolveRulesRepo(cfg *config.Config, quiet bool) error {
+	checkouts := make([]*source.Checkout, 0)
+	if cfg.IsRemoteRulesRepo() {
+		checkout, err := cfg.ResolveRulesRepo()
+		if err != nil {
+			return fmt.Errorf("failed to fetch rules repository: %w", err)
+		}
+		checkouts = append(checkouts, checkout)
+	}
+
+	fetched, err := cfg.ResolveSources()
+	if err != nil {
+		return fmt.Errorf("failed to fetch rules repository: %w", err)
+	}
+	checkouts = append(checkouts, fetched...)
+
+	for _, checkout := range checkouts {
+		if checkout.FetchErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\nUsing the cached copy\n", checkout.FetchErr)
+		}
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "Using rules repository %s at commit %s\n", checkout.Spec, source.ShortCommit(checkout.Commit))
+		}
+	}
+	return cfg.ValidateSources()
+}
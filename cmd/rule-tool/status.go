@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/circleci/llm-agent-rules/internal/output"
+	"github.com/circleci/llm-agent-rules/internal/status"
+)
+
+// runStatus implements the status subcommand and returns the exit code
+func runStatus(args []string) int {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: rule-tool status [flags]\n\n")
+		fmt.Fprintf(fs.Output(), "Show how the rules installed in the target project differ from the rules repository.\n\n")
+		fs.PrintDefaults()
+	}
+	project := addProjectFlags(fs)
+	format := fs.String("output", status.FormatTable, "Output format: "+strings.Join(status.Formats, ", "))
+	targetFormat := fs.String("target-format", "", "Only check rules installed for this editor format (default: every installed format)")
+	check := fs.Bool("check", false, "Exit with status 1 when any installed rule is not up to date")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	// text is the human-readable output of every command, which for status is the table
+	if *format == output.FormatText {
+		*format = status.FormatTable
+	}
+
+	rulesManager, l, err := project.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	formats := make([]string, 0)
+	if *targetFormat != "" {
+		formats = append(formats, *targetFormat)
+	}
+
+	report, err := status.New(l, rulesManager.All()).Check(formats...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error checking status: %v\n", err)
+		return exitError
+	}
+
+	if err := status.Write(os.Stdout, report, *format); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing status: %v\n", err)
+		return exitUsage
+	}
+
+	if *check && report.Drifted() {
+		return exitError
+	}
+	return exitOK
+}
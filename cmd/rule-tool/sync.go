@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/circleci/llm-agent-rules/internal/config"
+	"github.com/circleci/llm-agent-rules/internal/linker"
+	"github.com/circleci/llm-agent-rules/internal/output"
+	"github.com/circleci/llm-agent-rules/internal/plan"
+	"github.com/circleci/llm-agent-rules/internal/rules"
+)
+
+// runSync implements the sync subcommand and returns the exit code
+func runSync(args []string) int {
+	fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: rule-tool sync [flags]\n\n")
+		fmt.Fprintf(fs.Output(), "Reconcile the target project with the rules declared in its %s.\n", config.ProjectFileName)
+		fmt.Fprintf(fs.Output(), "With --tag or --exclude-tag, only rules matching the filters are linked or removed.\n")
+		fmt.Fprintf(fs.Output(), "The rules that declared rules require are linked with them.\n\n")
+		fs.PrintDefaults()
+	}
+	project := addProjectFlags(fs)
+	project.addLinkFlags(fs)
+	tags := addTagFlags(fs)
+	yes := fs.Bool("yes", false, "Apply the plan without asking for confirmation")
+	format := addOutputFlag(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	out, ok := newOutput(*format, "changes")
+	if !ok {
+		return exitUsage
+	}
+
+	rulesManager, l, err := project.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	declared, err := config.LoadProject(l.TargetDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	desired, err := rulesManager.Select(declared.Rules)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error in %s: %v\n", config.ProjectFileName, err)
+		return exitError
+	}
+
+	filter := tags.filter()
+	desired = rules.FilterRules(desired, filter)
+
+	// The rules the declared ones require are installed with them
+	requirements, err := rulesManager.Requirements(desired)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error in %s: %v\n", config.ProjectFileName, err)
+		return exitError
+	}
+	requiredBy := make(map[string]string, len(requirements))
+	for _, requirement := range requirements {
+		desired = append(desired, requirement.Rule)
+		requiredBy[linker.RuleID(requirement.Rule)] = linker.RuleID(requirement.RequiredBy)
+	}
+	if conflicts := rulesManager.Conflicts(desired, nil); len(conflicts) > 0 {
+		fmt.Fprintln(os.Stderr, conflictError(conflicts, nil))
+		return exitError
+	}
+
+	formats := declared.Editors
+	if len(formats) == 0 {
+		formats = []string{firstNonEmpty(project.config.Editor, linker.DefaultEditor)}
+	}
+
+	// The project's mode is already merged into the configuration, under
+	// RULE_TOOL_MODE
+	mode := linker.ModeSymlink
+	if project.config.LinkMode != "" {
+		if mode, err = linker.ParseLinkMode(project.config.LinkMode); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+	}
+
+	changes, err := plan.Compute(l, rulesManager.All(), desired, formats, mode)
+	if err != nil {
+		printLinkError(err)
+		return exitError
+	}
+	if !filter.Empty() {
+		// Leave installed rules outside the filter alone; rules whose source
+		// is gone carry no tags and are left to prune
+		changes.Retain(func(change plan.Change) bool {
+			rule := rulesManager.GetRuleByName(change.Rule)
+			return rule != nil && (filter.Matches(rule) || requiredBy[change.Rule] != "")
+		})
+	}
+	for i, change := range changes.Changes {
+		if by, ok := requiredBy[change.Rule]; ok && change.Action == plan.ActionLink {
+			changes.Changes[i].Reason = "required by " + by
+		}
+	}
+
+	if !out.Structured() {
+		if err := plan.Write(os.Stdout, changes); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing plan: %v\n", err)
+			return exitError
+		}
+	}
+
+	if changes.Empty() || *project.dryRun {
+		return writeChanges(out, changes, exitOK)
+	}
+
+	if !*yes && !confirm(os.Stdin, "\nApply these changes? [y/N]: ") {
+		fmt.Fprintln(os.Stderr, "Sync cancelled.")
+		return writeChanges(out, changes, exitError)
+	}
+
+	if err := changes.Apply(l); err != nil {
+		fmt.Fprintf(os.Stderr, "Error applying plan: %v\n", err)
+		fmt.Fprintln(os.Stderr, "No changes were made; the plan was rolled back")
+		return writeChanges(out, changes, exitError)
+	}
+	if !out.Structured() {
+		fmt.Println("Sync complete.")
+	}
+	return writeChanges(out, changes, exitOK)
+}
+
+// writeChanges writes a record for every change of the plan, recording
+// whether it was applied, and returns code unless writing fails
+func writeChanges(out *output.Writer, changes *plan.Plan, code int) int {
+	for _, change := range changes.Changes {
+		if err := out.Record(change); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			return exitError
+		}
+	}
+	return worstExit(code, closeOutput(out))
+}
+
+// confirm prints prompt to stderr, keeping stdout for the command's output,
+// and reports whether the user answered yes
+func confirm(r io.Reader, prompt string) bool {
+	fmt.Fprint(os.Stderr, prompt)
+	answer, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Fprintln(os.Stderr)
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// firstNonEmpty returns the first value that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
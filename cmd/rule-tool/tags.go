@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/circleci/llm-agent-rules/internal/linker"
+	"github.com/circleci/llm-agent-rules/internal/rules"
+)
+
+// tagFlags holds the --tag and --exclude-tag flags of a command
+type tagFlags struct {
+	include listFlag
+	exclude listFlag
+}
+
+// addTagFlags registers the --tag and --exclude-tag flags on fs
+func addTagFlags(fs *flag.FlagSet) *tagFlags {
+	t := &tagFlags{}
+	fs.Var(&t.include, "tag", "Only rules tagged with every given tag, repeatable or comma-separated")
+	fs.Var(&t.exclude, "exclude-tag", "Leave out rules tagged with any given tag, repeatable or comma-separated")
+	return t
+}
+
+// filter returns the tag filter the flags describe
+func (t *tagFlags) filter() rules.TagFilter {
+	return rules.TagFilter{Include: t.include, Exclude: t.exclude}
+}
+
+// filterRuleNames keeps the named rules that match filter, reporting the
+// others on stderr unless quiet. Unknown names are kept so they are reported
+// as not found.
+func filterRuleNames(rulesManager *rules.Manager, names []string, filter rules.TagFilter, quiet bool) []string {
+	kept := make([]string, 0, len(names))
+	for _, name := range names {
+		rule := rulesManager.GetRuleByName(name)
+		if rule != nil && !filter.Matches(rule) {
+			if !quiet {
+				fmt.Fprintf(os.Stderr, "Skipping %s: does not match %s\n", linker.RuleID(rule), filter)
+			}
+			continue
+		}
+		kept = append(kept, name)
+	}
+	return kept
+}
+
+// taggedRuleNames returns the names of the rules matching filter
+func taggedRuleNames(rulesManager *rules.Manager, filter rules.TagFilter) []string {
+	names := make([]string, 0)
+	for _, rule := range rulesManager.Filter(filter) {
+		names = append(names, linker.RuleID(rule))
+	}
+	return names
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/circleci/llm-agent-rules/internal/ui"
+)
+
+// runTUI implements the tui command and returns the exit code
+func runTUI(args []string) int {
+	fs := flag.NewFlagSet("tui", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: rule-tool tui [flags]\n\n")
+		fmt.Fprintf(fs.Output(), "Select the rules installed in the target project in the interactive terminal UI.\n")
+		fmt.Fprintf(fs.Output(), "This is what rule-tool runs without a command.\n\n")
+		fs.PrintDefaults()
+	}
+	project := addProjectFlags(fs)
+	project.addLinkFlags(fs)
+	project.addEditorFlags(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	return startTUI(project)
+}
+
+// startTUI loads the rules and runs the interactive UI until the user quits
+func startTUI(project *projectFlags) int {
+	rulesManager, l, err := project.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	if len(rulesManager.Rules) == 0 {
+		fmt.Fprintln(os.Stderr, "No rules found in repository")
+		return exitError
+	}
+	editor, err := project.editor()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	// Check which rules are already installed for the banner; SetEditor
+	// starts the selection from them
+	for _, rule := range rulesManager.Rules {
+		rule.IsInstalled = l.IsRuleLinked(rule, editor)
+	}
+
+	if !*project.quiet {
+		printBanner(rulesManager, project.config.TargetProjectPath)
+	}
+
+	model := ui.New(project.config, rulesManager, l)
+	model.SetEditor(editor)
+	manager := ui.NewManager(model)
+
+	// Run the application with full screen and mouse support
+	p := tea.NewProgram(
+		manager,
+		tea.WithAltScreen(),       // Use alternate screen buffer
+		tea.WithMouseCellMotion(), // Enable mouse support
+	)
+
+	if _, err := p.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
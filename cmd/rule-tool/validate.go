@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/circleci/llm-agent-rules/internal/config"
+	"github.com/circleci/llm-agent-rules/internal/rules"
+	"github.com/circleci/llm-agent-rules/internal/validator"
+)
+
+// runValidate implements the validate subcommand and returns the exit code
+func runValidate(args []string) int {
+	cfg := config.New()
+
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: rule-tool validate [flags]\n\n")
+		fmt.Fprintf(fs.Output(), "Lint every rule in the rules repository and exit non-zero on errors.\n\n")
+		fs.PrintDefaults()
+	}
+	repoPath := fs.String("repo-path", "", "Path or git URL of the rules repository (overrides RULE_TOOL_PATH environment variable if set)")
+	format := fs.String("output", validator.FormatText, "Output format: "+strings.Join(validator.Formats, ", "))
+	strict := fs.Bool("strict", false, "Treat warnings as errors")
+	quiet := fs.Bool("quiet", false, "Suppress progress messages")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	if *repoPath != "" {
+		cfg.SetRulesRepoPath(*repoPath)
+	}
+
+	if err := resolveRulesRepo(cfg, *quiet); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	if !cfg.ValidateRulesRepoPath() {
+		fmt.Fprintf(os.Stderr, "Invalid rules repository path: %s\n", cfg.RulesRepoPath)
+		return exitError
+	}
+
+	v := validator.New(rules.NewManager(cfg.GetRulesDir()), cfg.RulesRepoPath)
+	result, err := v.Validate()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error validating rules: %v\n", err)
+		return exitError
+	}
+
+	if err := validator.Write(os.Stdout, result, *format); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing results: %v\n", err)
+		return exitUsage
+	}
+
+	if result.ErrorCount() > 0 || (*strict && result.WarningCount() > 0) {
+		return exitError
+	}
+	return exitOK
+}
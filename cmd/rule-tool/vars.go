@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// varsFlag collects repeated --set name=value flags
+type varsFlag map[string]string
+
+// String implements flag.Value
+func (v *varsFlag) String() string {
+	pairs := make([]string, 0, len(*v))
+	for _, name := range slices.Sorted(maps.Keys(*v)) {
+		pairs = append(pairs, name+"="+(*v)[name])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Set implements flag.Value
+func (v *varsFlag) Set(value string) error {
+	name, val, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("expected name=value, got %q", value)
+	}
+	name = strings.TrimSpace(name)
+	if !models.ValidVariableName(name) {
+		return fmt.Errorf("invalid template variable name %q", name)
+	}
+	if *v == nil {
+		*v = make(varsFlag)
+	}
+	(*v)[name] = val
+	return nil
+}
+
+// varsUsage is the help text of the --set flag
+const varsUsage = "Value of a rule template variable as name=value, repeatable; overrides vars in the project configuration"
+
+// describeVars lists the template variables of a rule as name=default, or
+// as name (required) for variables without a default
+func describeVars(rule *models.Rule) []string {
+	described := make([]string, 0, len(rule.Vars))
+	for _, variable := range rule.Vars {
+		if variable.Required {
+			described = append(described, variable.Name+" (required)")
+			continue
+		}
+		described = append(described, variable.Name+"="+variable.Default)
+	}
+	return described
+}
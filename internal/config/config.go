@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/circleci/llm-agent-rules/internal/source"
+	"github.com/circleci/llm-agent-rules/pkg/models"
 	"github.com/sethvargo/go-envconfig"
 )
 
@@ -14,15 +16,63 @@ const (
 	EnvRulesPath = "RULE_TOOL_PATH"
 	// EnvTargetPath is the environment variable name for specifying the target project path
 	EnvTargetPath = "RULE_TARGET_PATH"
+	// EnvLinkMode is the environment variable name for choosing between symlinked and copied rules
+	EnvLinkMode = "RULE_TOOL_MODE"
+	// EnvEditor is the environment variable name for the default editor format
+	EnvEditor = "RULE_TOOL_EDITOR"
+	// EnvTheme is the environment variable name for the terminal UI theme
+	EnvTheme = "RULE_TOOL_THEME"
+	// EnvConfigFile is the environment variable name for the user configuration file path
+	EnvConfigFile = "RULE_TOOL_CONFIG"
+	// EnvCacheDir is the environment variable name for the directory remote rules repositories are cloned into
+	EnvCacheDir = "RULE_TOOL_CACHE_DIR"
 )
 
 // Config holds the global application configuration
 type Config struct {
-	// RulesRepoPath is the path to the rules repository
+	// RulesRepoPath is the path to the rules repository. It may be a git URL
+	// until ResolveRulesRepo replaces it with the path of the cached checkout.
 	RulesRepoPath string `env:"RULE_TOOL_PATH"`
 
+	// RulesSource is the git URL the rules repository was fetched from, if any
+	RulesSource string
+
+	// RulesCommit is the commit the remote rules repository was checked out at
+	RulesCommit string
+
+	// CacheDir is where remote rules repositories are cloned
+	CacheDir string `env:"RULE_TOOL_CACHE_DIR"`
+
+	// Sources are further rules repositories layered over the rules
+	// repository, lowest precedence first
+	Sources []models.Source
+
+	// rulesRepoSet records whether the rules repository was set explicitly
+	rulesRepoSet bool
+
+	// origins records where each setting came from, by key
+	origins map[string]string
+
 	// TargetProjectPath is the path to the target project where rules will be linked
 	TargetProjectPath string `env:"RULE_TARGET_PATH"`
+
+	// LinkMode is how rules are installed into the target project: symlink or copy
+	LinkMode string `env:"RULE_TOOL_MODE"`
+
+	// Editor is the format rules are installed for when none is chosen
+	Editor string `env:"RULE_TOOL_EDITOR"`
+
+	// Theme is the color theme of the terminal UI
+	Theme string `env:"RULE_TOOL_THEME"`
+
+	// Vars are the values of rule template variables declared by the
+	// target project's configuration file
+	Vars map[string]string
+
+	// Rules and Editors are the rule selectors and formats declared by the
+	// target project's configuration file
+	Rules   []string
+	Editors []string
 }
 
 // New creates a new configuration with default values
@@ -38,10 +88,17 @@ func New() *Config {
 		cwd = "."
 	}
 
+	cfg.rulesRepoSet = cfg.RulesRepoPath != ""
+	for key, value := range map[string]string{KeyMode: cfg.LinkMode, KeyEditor: cfg.Editor, KeyTheme: cfg.Theme} {
+		if value != "" {
+			cfg.setOrigin(key, OriginEnv)
+		}
+	}
+
 	// Default to current working directory for rules repo if not set
 	if cfg.RulesRepoPath == "" {
 		cfg.RulesRepoPath = cwd
-	} else if !filepath.IsAbs(cfg.RulesRepoPath) {
+	} else if !filepath.IsAbs(cfg.RulesRepoPath) && !source.IsRemote(cfg.RulesRepoPath) {
 		// Convert relative path to absolute path
 		cfg.RulesRepoPath = filepath.Join(cwd, cfg.RulesRepoPath)
 	}
@@ -54,15 +111,19 @@ func New() *Config {
 		cfg.TargetProjectPath = filepath.Join(cwd, cfg.TargetProjectPath)
 	}
 
+	if cfg.CacheDir == "" {
+		cfg.CacheDir = source.DefaultCacheDir()
+	}
+
 	return &cfg
 }
 
 // SetRulesRepoPath sets the path to the rules repository
 // Command line flags take precedence over environment variables
-// Converts relative paths to absolute paths
+// Converts relative paths to absolute paths; git URLs are kept as they are
 func (c *Config) SetRulesRepoPath(path string) {
 	// Convert relative paths to absolute paths
-	if !filepath.IsAbs(path) {
+	if !filepath.IsAbs(path) && !source.IsRemote(path) {
 		// Get current working directory
 		cwd, err := os.Getwd()
 		if err == nil {
@@ -70,6 +131,7 @@ func (c *Config) SetRulesRepoPath(path string) {
 		}
 	}
 	c.RulesRepoPath = path
+	c.rulesRepoSet = true
 }
 
 // SetTargetProjectPath sets the path to the target project
@@ -87,6 +149,49 @@ func (c *Config) SetTargetProjectPath(path string) {
 	c.TargetProjectPath = path
 }
 
+// SetLinkMode sets how rules are installed into the target project
+// Command line flags take precedence over environment variables
+func (c *Config) SetLinkMode(mode string) {
+	c.LinkMode = mode
+	c.setOrigin(KeyMode, OriginFlag)
+}
+
+// SetEditor sets the format rules are installed for
+// Command line flags take precedence over environment variables and configuration files
+func (c *Config) SetEditor(editor string) {
+	c.Editor = editor
+	c.setOrigin(KeyEditor, OriginFlag)
+}
+
+// IsRemoteRulesRepo reports whether the rules repository is a git URL that
+// has not been fetched yet
+func (c *Config) IsRemoteRulesRepo() bool {
+	return source.IsRemote(c.RulesRepoPath)
+}
+
+// ResolveRulesRepo fetches a remote rules repository into the cache directory
+// and points RulesRepoPath at the checkout. Local paths are left alone and
+// return a nil checkout.
+func (c *Config) ResolveRulesRepo() (*source.Checkout, error) {
+	if !c.IsRemoteRulesRepo() {
+		return nil, nil
+	}
+
+	spec, err := source.Parse(c.RulesRepoPath)
+	if err != nil {
+		return nil, err
+	}
+	checkout, err := source.NewFetcher(c.CacheDir).Fetch(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	c.RulesSource = spec.String()
+	c.RulesCommit = checkout.Commit
+	c.RulesRepoPath = checkout.Root
+	return checkout, nil
+}
+
 // ValidateRulesRepoPath checks if the rules repository path is valid
 func (c *Config) ValidateRulesRepoPath() bool {
 	// Check if path exists
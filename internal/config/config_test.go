@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -172,3 +173,74 @@ func TestDefaultPathValues(t *testing.T) {
 		t.Errorf("ValidateTargetProjectPath should return true for current directory %q", cwd)
 	}
 }
+
+func TestLinkModeEnvVariable(t *testing.T) {
+	t.Setenv(EnvLinkMode, "copy")
+
+	cfg := New()
+	if cfg.LinkMode != "copy" {
+		t.Errorf("Expected LinkMode to be copy, got %s", cfg.LinkMode)
+	}
+
+	// Command line flags take precedence over the environment variable
+	cfg.SetLinkMode("symlink")
+	if cfg.LinkMode != "symlink" {
+		t.Errorf("Expected LinkMode to be symlink, got %s", cfg.LinkMode)
+	}
+}
+
+func TestRemoteRulesRepoPath(t *testing.T) {
+	remote := "https://github.com/org/rules.git//web?ref=v1"
+	t.Setenv(EnvRulesPath, remote)
+	t.Setenv(EnvCacheDir, "/tmp/rule-tool-cache")
+
+	cfg := New()
+	if cfg.RulesRepoPath != remote {
+		t.Errorf("Expected the git URL to be kept, got %s", cfg.RulesRepoPath)
+	}
+	if !cfg.IsRemoteRulesRepo() {
+		t.Error("Expected the rules repository to be remote")
+	}
+	if cfg.CacheDir != "/tmp/rule-tool-cache" {
+		t.Errorf("Expected CacheDir from the environment, got %s", cfg.CacheDir)
+	}
+
+	cfg.SetRulesRepoPath("git@github.com:org/rules.git")
+	if cfg.RulesRepoPath != "git@github.com:org/rules.git" {
+		t.Errorf("Expected the scp-style URL to be kept, got %s", cfg.RulesRepoPath)
+	}
+
+	cfg.SetRulesRepoPath("rules")
+	if cfg.IsRemoteRulesRepo() || !filepath.IsAbs(cfg.RulesRepoPath) {
+		t.Errorf("Expected a local path to be made absolute, got %s", cfg.RulesRepoPath)
+	}
+	if checkout, err := cfg.ResolveRulesRepo(); checkout != nil || err != nil {
+		t.Errorf("ResolveRulesRepo = %v, %v; want a local path left alone", checkout, err)
+	}
+}
+
+func TestLoadProject(t *testing.T) {
+	dir := t.TempDir()
+	content := "rules:\n  - go/testing\n  - \"process/**\"\n  - tag:security\neditors: [cursor, claude]\nmode: copy\n"
+	if err := os.WriteFile(ProjectPath(dir), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write project config: %v", err)
+	}
+
+	project, err := LoadProject(dir)
+	if err != nil {
+		t.Fatalf("LoadProject failed: %v", err)
+	}
+	if len(project.Rules) != 3 || project.Rules[2] != "tag:security" {
+		t.Errorf("Rules = %v", project.Rules)
+	}
+	if len(project.Editors) != 2 || project.Mode != "copy" {
+		t.Errorf("Editors = %v, Mode = %q", project.Editors, project.Mode)
+	}
+
+	if err := os.WriteFile(ProjectPath(dir), []byte("rulez:\n  - go/testing\n"), 0644); err != nil {
+		t.Fatalf("Failed to write project config: %v", err)
+	}
+	if _, err := LoadProject(dir); err == nil {
+		t.Error("Expected an error for an unknown key")
+	}
+}
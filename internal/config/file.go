@@ -0,0 +1,350 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/circleci/llm-agent-rules/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+// UserFileName is the user configuration file within the rule-tool configuration directory
+const UserFileName = "config.yaml"
+
+// Setting keys, as written in the configuration files
+const (
+	KeySources = "sources"
+	KeyEditor  = "editor"
+	KeyMode    = "mode"
+	KeyTheme   = "theme"
+	KeyRules   = "rules"
+	KeyEditors = "editors"
+	KeyVars    = "vars"
+)
+
+// VarsPrefix starts the project configuration keys of template values, as
+// in "vars.team", which set one entry of the vars mapping
+const VarsPrefix = KeyVars + "."
+
+// Origins of a setting, lowest precedence first
+const (
+	OriginDefault = "default"
+	OriginUser    = "user"
+	OriginProject = "project"
+	OriginEnv     = "env"
+	OriginFlag    = "flag"
+)
+
+// originRank orders origins by precedence
+var originRank = map[string]int{
+	OriginDefault: 0,
+	OriginUser:    1,
+	OriginProject: 2,
+	OriginEnv:     3,
+	OriginFlag:    4,
+}
+
+// Settings are the options shared by the user configuration file and the
+// project configuration file, which overrides them
+type Settings struct {
+	// Sources are rules repositories as [id=]path-or-git-url, lowest
+	// precedence first. Relative paths are relative to the file.
+	Sources []string `yaml:"sources,omitempty"`
+	// Editor is the format rules are installed for by default
+	Editor string `yaml:"editor,omitempty"`
+	// Mode is how rules are installed: symlink or copy
+	Mode string `yaml:"mode,omitempty"`
+	// Theme is the color theme of the terminal UI
+	Theme string `yaml:"theme,omitempty"`
+}
+
+// UserKeys are the keys the user configuration file accepts
+var UserKeys = []string{KeySources, KeyEditor, KeyMode, KeyTheme}
+
+// ProjectKeys are the keys the project configuration file accepts
+var ProjectKeys = []string{KeyRules, KeyEditors, KeySources, KeyEditor, KeyMode, KeyTheme}
+
+// VarName returns the template variable a vars.<name> key sets
+func VarName(key string) (string, bool) {
+	name, ok := strings.CutPrefix(key, VarsPrefix)
+	return name, ok && name != ""
+}
+
+// IsListKey reports whether a key holds a list of values
+func IsListKey(key string) bool {
+	return key == KeySources || key == KeyRules || key == KeyEditors
+}
+
+// UserPath returns the user configuration file path, which RULE_TOOL_CONFIG
+// overrides. The file lives in $XDG_CONFIG_HOME, or ~/.config, on every
+// platform, rather than in the macOS or Windows application data directory.
+func UserPath() string {
+	if path := os.Getenv(EnvConfigFile); path != "" {
+		return path
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if !filepath.IsAbs(dir) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = "."
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "rule-tool", UserFileName)
+}
+
+// LoadUser reads the user configuration file. A missing file yields empty settings.
+func LoadUser(path string) (*Settings, error) {
+	var settings Settings
+	if err := decodeFile(path, &settings); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// mappingValue returns the value of a key in a YAML mapping node, or nil
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue sets a key in a YAML mapping node, in place when it
+// exists. A nil value removes the key.
+func setMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != key {
+			continue
+		}
+		if value == nil {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+		} else {
+			mapping.Content[i+1] = value
+		}
+		return
+	}
+	if value != nil {
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	}
+}
+
+// decodeFile strictly decodes a YAML file, rejecting unknown keys
+func decodeFile(path string, out interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadFiles merges the user configuration file and the target project's
+// configuration file into the configuration. Settings from environment
+// variables and flags, applied before, take precedence over both; the
+// project file takes precedence over the user file. Sources from the files
+// come before sources added with flags.
+func (c *Config) LoadFiles() error {
+	user, err := LoadUser(UserPath())
+	if err != nil {
+		return err
+	}
+
+	var project Settings
+	if loaded, err := LoadProject(c.TargetProjectPath); err == nil {
+		project = loaded.Settings
+		c.Vars = loaded.Vars
+		for name := range c.Vars {
+			c.setOrigin(VarsPrefix+name, OriginProject)
+		}
+		c.Rules, c.Editors = loaded.Rules, loaded.Editors
+		if len(c.Rules) > 0 {
+			c.setOrigin(KeyRules, OriginProject)
+		}
+		if len(c.Editors) > 0 {
+			c.setOrigin(KeyEditors, OriginProject)
+		}
+	} else if _, statErr := os.Stat(ProjectPath(c.TargetProjectPath)); statErr == nil {
+		return err
+	}
+
+	added := c.Sources
+	c.Sources = nil
+	layers := []struct {
+		settings Settings
+		origin   string
+		dir      string
+	}{
+		{*user, OriginUser, filepath.Dir(UserPath())},
+		{project, OriginProject, c.TargetProjectPath},
+	}
+	for _, layer := range layers {
+		c.setFrom(KeyEditor, &c.Editor, layer.settings.Editor, layer.origin)
+		c.setFrom(KeyMode, &c.LinkMode, layer.settings.Mode, layer.origin)
+		c.setFrom(KeyTheme, &c.Theme, layer.settings.Theme, layer.origin)
+		for _, spec := range layer.settings.Sources {
+			if err := c.addSource(spec, layer.dir, layer.origin); err != nil {
+				return fmt.Errorf("%s configuration: %w", layer.origin, err)
+			}
+		}
+	}
+	c.Sources = mergeSources(c.Sources, added)
+	return nil
+}
+
+// setFrom sets a setting unless it was already set with higher precedence
+func (c *Config) setFrom(key string, field *string, value, origin string) {
+	if value == "" || originRank[origin] < originRank[c.Origin(key)] {
+		return
+	}
+	*field = value
+	c.setOrigin(key, origin)
+}
+
+// setOrigin records where a setting came from
+func (c *Config) setOrigin(key, origin string) {
+	if c.origins == nil {
+		c.origins = make(map[string]string)
+	}
+	c.origins[key] = origin
+}
+
+// Origin returns where a setting came from
+func (c *Config) Origin(key string) string {
+	if origin, ok := c.origins[key]; ok {
+		return origin
+	}
+	return OriginDefault
+}
+
+// Get returns the effective value of a setting
+func (c *Config) Get(key string) ([]string, error) {
+	switch key {
+	case KeySources:
+		values := make([]string, 0, len(c.Sources))
+		for _, source := range c.Sources {
+			values = append(values, source.ID+"="+source.Root)
+		}
+		return values, nil
+	case KeyEditor:
+		return []string{c.Editor}, nil
+	case KeyMode:
+		return []string{c.LinkMode}, nil
+	case KeyTheme:
+		return []string{c.Theme}, nil
+	case KeyRules:
+		return c.Rules, nil
+	case KeyEditors:
+		return c.Editors, nil
+	case KeyVars:
+		values := make([]string, 0, len(c.Vars))
+		for _, name := range slices.Sorted(maps.Keys(c.Vars)) {
+			values = append(values, name+"="+c.Vars[name])
+		}
+		return values, nil
+	}
+	if name, ok := VarName(key); ok {
+		if value, ok := c.Vars[name]; ok {
+			return []string{value}, nil
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown setting %q, known settings: %s", key, strings.Join(ProjectKeys, ", "))
+}
+
+// SetFileKey sets a key in a configuration file, creating the file if
+// needed. Comments and other keys are preserved. No values removes the key.
+// A vars.<name> key sets one entry of the vars mapping.
+func SetFileKey(path, key string, values []string) error {
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s is not a mapping", path)
+	}
+
+	var value *yaml.Node
+	switch {
+	case len(values) == 0:
+	case IsListKey(key):
+		value = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range values {
+			value.Content = append(value.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: item})
+		}
+	default:
+		value = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: values[0]}
+	}
+
+	if name, ok := VarName(key); ok {
+		vars := mappingValue(root, KeyVars)
+		if vars == nil || vars.Tag == "!!null" {
+			vars = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		} else if vars.Kind != yaml.MappingNode {
+			return fmt.Errorf("%s: %s is not a mapping", path, KeyVars)
+		}
+		setMappingValue(vars, name, value)
+		value = nil
+		if len(vars.Content) > 0 {
+			value = vars
+		}
+		key = KeyVars
+	}
+	setMappingValue(root, key, value)
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// mergeSources appends sources to base, replacing any source with the same ID in place
+func mergeSources(base, sources []models.Source) []models.Source {
+	for _, source := range sources {
+		replaced := false
+		for i := range base {
+			if base[i].ID == source.ID {
+				base[i] = source
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			base = append(base, source)
+		}
+	}
+	return base
+}
@@ -0,0 +1,202 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadFilesPrecedence(t *testing.T) {
+	tmpDir := t.TempDir()
+	userPath := filepath.Join(tmpDir, "home", "config.yaml")
+	target := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(target, 0755); err != nil {
+		t.Fatalf("Failed to create target: %v", err)
+	}
+
+	t.Setenv(EnvConfigFile, userPath)
+	t.Setenv(EnvRulesPath, "")
+	t.Setenv(EnvLinkMode, "")
+	t.Setenv(EnvEditor, "")
+	t.Setenv(EnvTheme, "mono")
+
+	if err := SetFileKey(userPath, KeySources, []string{"org=org-rules", "team=/srv/team"}); err != nil {
+		t.Fatalf("SetFileKey failed: %v", err)
+	}
+	for key, value := range map[string]string{KeyEditor: "claude", KeyMode: "copy", KeyTheme: "default"} {
+		if err := SetFileKey(userPath, key, []string{value}); err != nil {
+			t.Fatalf("SetFileKey failed: %v", err)
+		}
+	}
+	project := "rules: [go/testing]\neditors: [cursor, claude]\neditor: windsurf\nsources:\n  - team=team-rules\n"
+	if err := os.WriteFile(ProjectPath(target), []byte(project), 0644); err != nil {
+		t.Fatalf("Failed to write project config: %v", err)
+	}
+
+	cfg := New()
+	cfg.SetTargetProjectPath(target)
+	cfg.SetLinkMode("symlink")
+	if err := cfg.AddSource("me=/srv/me"); err != nil {
+		t.Fatalf("AddSource failed: %v", err)
+	}
+	if err := cfg.LoadFiles(); err != nil {
+		t.Fatalf("LoadFiles failed: %v", err)
+	}
+
+	testCases := []struct {
+		key, value, origin string
+	}{
+		{KeyEditor, "windsurf", OriginProject},
+		{KeyMode, "symlink", OriginFlag},
+		{KeyTheme, "mono", OriginEnv},
+		{KeySources, "org=" + filepath.Join(tmpDir, "home", "org-rules") + ",team=" + filepath.Join(target, "team-rules") + ",me=/srv/me", OriginFlag},
+		{KeyRules, "go/testing", OriginProject},
+		{KeyEditors, "cursor,claude", OriginProject},
+	}
+	for _, tc := range testCases {
+		values, err := cfg.Get(tc.key)
+		if err != nil {
+			t.Fatalf("Get(%s) failed: %v", tc.key, err)
+		}
+		if got := strings.Join(values, ","); got != tc.value {
+			t.Errorf("%s = %q, want %q", tc.key, got, tc.value)
+		}
+		if got := cfg.Origin(tc.key); got != tc.origin {
+			t.Errorf("%s origin = %q, want %q", tc.key, got, tc.origin)
+		}
+	}
+	if _, err := cfg.Get("editr"); err == nil || !strings.Contains(err.Error(), KeyEditors) {
+		t.Errorf("Get(editr) error = %v, want the project keys listed", err)
+	}
+}
+
+func TestUserPath(t *testing.T) {
+	home := t.TempDir()
+	testCases := []struct {
+		name    string
+		config  string
+		xdgHome string
+		want    string
+	}{
+		{name: "Default", want: filepath.Join(home, ".config", "rule-tool", "config.yaml")},
+		{name: "XDG_CONFIG_HOME", xdgHome: "/xdg", want: filepath.Join("/xdg", "rule-tool", "config.yaml")},
+		{name: "Relative XDG_CONFIG_HOME is ignored", xdgHome: "xdg", want: filepath.Join(home, ".config", "rule-tool", "config.yaml")},
+		{name: "RULE_TOOL_CONFIG", config: "/etc/rule-tool.yaml", xdgHome: "/xdg", want: "/etc/rule-tool.yaml"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("HOME", home)
+			t.Setenv("XDG_CONFIG_HOME", tc.xdgHome)
+			t.Setenv(EnvConfigFile, tc.config)
+			if got := UserPath(); got != tc.want {
+				t.Errorf("UserPath() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLoadFilesRejectsUnknownKeys(t *testing.T) {
+	userPath := filepath.Join(t.TempDir(), "config.yaml")
+	t.Setenv(EnvConfigFile, userPath)
+	if err := os.WriteFile(userPath, []byte("editr: cursor\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg := New()
+	cfg.SetTargetProjectPath(t.TempDir())
+	if err := cfg.LoadFiles(); err == nil {
+		t.Error("Expected an error for an unknown key")
+	}
+}
+
+func TestSetFileKeyPreservesComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ProjectFileName)
+	content := "# Rules for this service\nrules:\n  - go/testing # keep in sync with CI\nmode: copy\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if err := SetFileKey(path, KeyEditors, []string{"cursor", "claude"}); err != nil {
+		t.Fatalf("SetFileKey failed: %v", err)
+	}
+	if err := SetFileKey(path, KeyMode, nil); err != nil {
+		t.Fatalf("SetFileKey failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	got := string(data)
+	for _, want := range []string{"# Rules for this service", "# keep in sync with CI", "editors:\n  - cursor\n  - claude"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Config does not contain %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "mode") {
+		t.Errorf("Expected mode to be removed:\n%s", got)
+	}
+
+	project, err := LoadProject(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("LoadProject failed: %v", err)
+	}
+	if len(project.Editors) != 2 || project.Rules[0] != "go/testing" {
+		t.Errorf("Project = %+v", project)
+	}
+}
+
+func TestSetFileKeyVars(t *testing.T) {
+	target := t.TempDir()
+	path := ProjectPath(target)
+	if err := os.WriteFile(path, []byte("rules: [go/testing]\nvars:\n  # the owning team\n  team: web\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if err := SetFileKey(path, VarsPrefix+"team", []string{"Acme: Payments"}); err != nil {
+		t.Fatalf("SetFileKey failed: %v", err)
+	}
+	if err := SetFileKey(path, VarsPrefix+"owner", []string{"platform"}); err != nil {
+		t.Fatalf("SetFileKey failed: %v", err)
+	}
+	if got := readConfig(t, path); !strings.Contains(got, "# the owning team") {
+		t.Errorf("Expected the comment to be kept:\n%s", got)
+	}
+
+	t.Setenv(EnvConfigFile, filepath.Join(t.TempDir(), "config.yaml"))
+	cfg := New()
+	cfg.SetTargetProjectPath(target)
+	if err := cfg.LoadFiles(); err != nil {
+		t.Fatalf("LoadFiles failed: %v", err)
+	}
+	if values, err := cfg.Get(VarsPrefix + "team"); err != nil || strings.Join(values, ",") != "Acme: Payments" {
+		t.Errorf("Get(vars.team) = %v, %v", values, err)
+	}
+	if values, err := cfg.Get(KeyVars); err != nil || strings.Join(values, ",") != "owner=platform,team=Acme: Payments" {
+		t.Errorf("Get(vars) = %v, %v", values, err)
+	}
+	if origin := cfg.Origin(VarsPrefix + "owner"); origin != OriginProject {
+		t.Errorf("vars.owner origin = %q, want project", origin)
+	}
+
+	// Removing the last value removes the mapping
+	for _, name := range []string{"team", "owner"} {
+		if err := SetFileKey(path, VarsPrefix+name, nil); err != nil {
+			t.Fatalf("SetFileKey failed: %v", err)
+		}
+	}
+	if got := readConfig(t, path); strings.Contains(got, "vars") || !strings.Contains(got, "go/testing") {
+		t.Errorf("Expected only the vars mapping to be removed:\n%s", got)
+	}
+}
+
+func readConfig(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	return string(data)
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// ProjectFileName is the project configuration file in the root of the target project
+const ProjectFileName = ".rule-tool.yaml"
+
+// Project is the configuration a target project declares for itself
+type Project struct {
+	// Rules selects the rules the project wants by name, topic/name, topic
+	// glob ("go/**") or tag ("tag:security")
+	Rules []string `yaml:"rules"`
+	// Editors are the formats rules are installed for
+	Editors []string `yaml:"editors,omitempty"`
+	// Vars are the values of rule template variables for this project
+	Vars map[string]string `yaml:"vars,omitempty"`
+	// Settings override the user configuration file for this project
+	Settings `yaml:",inline"`
+}
+
+// ProjectPath returns the project configuration path for a target project
+func ProjectPath(targetDir string) string {
+	return filepath.Join(targetDir, ProjectFileName)
+}
+
+// LoadProject reads the project configuration of a target project. Unknown
+// keys are rejected so typos do not silently change the declared rule set.
+func LoadProject(targetDir string) (*Project, error) {
+	path := ProjectPath(targetDir)
+	var project Project
+	if err := decodeFile(path, &project); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		return nil, err
+	}
+	for name := range project.Vars {
+		if !models.ValidVariableName(name) {
+			return nil, fmt.Errorf("failed to parse %s: invalid template variable name %q", path, name)
+		}
+	}
+	return &project, nil
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/circleci/llm-agent-rules/internal/source"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// sourceIDPattern matches the IDs sources can be given on the command line
+var sourceIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// invalidSourceIDChars matches the characters replaced when deriving a source ID
+var invalidSourceIDChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// reservedSourceIDs cannot name a source because they already mean
+// something in front of a colon in rule selectors
+var reservedSourceIDs = map[string]bool{"tag": true}
+
+// AddSource adds a rules repository layered over the ones added before it.
+// The spec is a path or git URL, optionally prefixed with an ID as in
+// "team=/path/to/team-rules". Without an ID, one is derived from the path.
+// A source with the ID of an earlier source replaces it.
+func (c *Config) AddSource(spec string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		cwd = "."
+	}
+	return c.addSource(spec, cwd, OriginFlag)
+}
+
+// addSource adds a source whose relative path is relative to dir
+func (c *Config) addSource(spec, dir, origin string) error {
+	id, location := "", spec
+	if before, after, ok := strings.Cut(spec, "="); ok && sourceIDPattern.MatchString(before) {
+		id, location = before, after
+	}
+	if location == "" {
+		return fmt.Errorf("missing path in source %q", spec)
+	}
+
+	if strings.HasPrefix(location, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			location = filepath.Join(home, location[2:])
+		}
+	}
+	if !source.IsRemote(location) && !filepath.IsAbs(location) {
+		location = filepath.Join(dir, location)
+	}
+	if id == "" {
+		id = deriveSourceID(location)
+	}
+	if reservedSourceIDs[id] {
+		return fmt.Errorf("%q cannot be used as a source ID", id)
+	}
+
+	c.Sources = mergeSources(c.Sources, []models.Source{{ID: id, Root: location}})
+	if originRank[origin] > originRank[c.Origin(KeySources)] {
+		c.setOrigin(KeySources, origin)
+	}
+	return nil
+}
+
+// RuleSources returns the rules repositories to load, lowest precedence
+// first. Without added sources this is just the rules repository. With
+// added sources, the rules repository is only included, first, when it was
+// set explicitly rather than defaulting to the current directory.
+func (c *Config) RuleSources() []models.Source {
+	primary := models.Source{
+		ID:     deriveSourceID(c.RulesRepoPath),
+		Root:   c.RulesRepoPath,
+		URL:    c.RulesSource,
+		Commit: c.RulesCommit,
+	}
+	if c.RulesSource != "" {
+		primary.ID = deriveSourceID(c.RulesSource)
+	}
+	if len(c.Sources) == 0 {
+		return []models.Source{primary}
+	}
+	if !c.rulesRepoSet {
+		return c.Sources
+	}
+
+	for _, added := range c.Sources {
+		if added.ID == primary.ID {
+			primary.ID = "default"
+			break
+		}
+	}
+	return append([]models.Source{primary}, c.Sources...)
+}
+
+// ResolveSources fetches every added source given as a git URL into the
+// cache directory, like ResolveRulesRepo does for the rules repository
+func (c *Config) ResolveSources() ([]*source.Checkout, error) {
+	checkouts := make([]*source.Checkout, 0)
+	for i, added := range c.Sources {
+		if !source.IsRemote(added.Root) {
+			continue
+		}
+
+		spec, err := source.Parse(added.Root)
+		if err != nil {
+			return nil, err
+		}
+		checkout, err := source.NewFetcher(c.CacheDir).Fetch(spec)
+		if err != nil {
+			return nil, fmt.Errorf("source %s: %w", added.ID, err)
+		}
+
+		c.Sources[i].URL = spec.String()
+		c.Sources[i].Commit = checkout.Commit
+		c.Sources[i].Root = checkout.Root
+		checkouts = append(checkouts, checkout)
+	}
+	return checkouts, nil
+}
+
+// ValidateSources checks that every added source is a directory
+func (c *Config) ValidateSources() error {
+	for _, added := range c.Sources {
+		info, err := os.Stat(added.Root)
+		if err != nil || !info.IsDir() {
+			return fmt.Errorf("invalid path for source %s: %s", added.ID, added.Root)
+		}
+	}
+	return nil
+}
+
+// deriveSourceID names a source after the last element of its path or URL
+func deriveSourceID(location string) string {
+	if spec, err := source.Parse(location); err == nil {
+		location = spec.URL
+		if spec.Subdir != "" {
+			location = spec.Subdir
+		}
+		location = location[strings.LastIndexAny(location, ":/")+1:]
+		location = strings.TrimSuffix(location, ".git")
+	} else {
+		location = path.Base(filepath.ToSlash(filepath.Clean(location)))
+	}
+
+	id := strings.Trim(invalidSourceIDChars.ReplaceAllString(location, "-"), "-")
+	if id == "" || reservedSourceIDs[id] {
+		return "rules"
+	}
+	return id
+}
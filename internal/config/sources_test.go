@@ -0,0 +1,47 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAddSource(t *testing.T) {
+	t.Setenv(EnvRulesPath, "")
+
+	cfg := New()
+	for _, spec := range []string{"/srv/org-rules", "team=https://github.com/org/rules.git//team/web?ref=v1", "git@github.com:me/dotfiles.git//rules"} {
+		if err := cfg.AddSource(spec); err != nil {
+			t.Fatalf("AddSource(%q) failed: %v", spec, err)
+		}
+	}
+
+	ids := make([]string, 0)
+	for _, source := range cfg.RuleSources() {
+		ids = append(ids, source.ID)
+	}
+	// The rules repository defaulted to the current directory, so only the added sources are used
+	if want := []string{"org-rules", "team", "rules"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Source IDs = %v, want %v", ids, want)
+	}
+	if cfg.Sources[1].Root != "https://github.com/org/rules.git//team/web?ref=v1" {
+		t.Errorf("Expected the git URL to be kept until resolved, got %s", cfg.Sources[1].Root)
+	}
+
+	// A source with an existing ID replaces it in place
+	if err := cfg.AddSource("team=/srv/other"); err != nil {
+		t.Fatalf("AddSource failed: %v", err)
+	}
+	if len(cfg.Sources) != 3 || cfg.Sources[1].Root != "/srv/other" {
+		t.Errorf("Sources = %+v, want team replaced", cfg.Sources)
+	}
+	if err := cfg.AddSource("tag=/srv/tags"); err == nil {
+		t.Error("Expected an error for a reserved source ID")
+	}
+
+	// An explicit rules repository is layered under the added sources
+	cfg.SetRulesRepoPath("/srv/base")
+	sources := cfg.RuleSources()
+	if len(sources) != 4 || sources[0].ID != "base" || sources[0].Root != "/srv/base" {
+		t.Errorf("RuleSources = %+v, want /srv/base first", sources)
+	}
+}
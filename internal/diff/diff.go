@@ -0,0 +1,247 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// DefaultContext is the number of unchanged lines shown around each change
+const DefaultContext = 3
+
+// Op is the kind of a diff line
+type Op byte
+
+const (
+	// Equal marks a line both texts have
+	Equal Op = ' '
+	// Delete marks a line only the old text has
+	Delete Op = '-'
+	// Insert marks a line only the new text has
+	Insert Op = '+'
+)
+
+// Line is one line of a diff. Text includes the line's newline, unless it is
+// the last line of a text that does not end with one.
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// Hunk is a run of changes with the unchanged lines around them
+type Hunk struct {
+	// FromLine and ToLine are the 1-based first lines of the hunk in the old
+	// and new text, and FromCount and ToCount the number of lines it spans in each
+	FromLine, FromCount int
+	ToLine, ToCount     int
+	Lines               []Line
+}
+
+// splitLines splits text into lines, keeping each line's newline
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Lines returns the line diff turning a into b, found from their longest
+// common subsequence of lines
+func Lines(a, b string) []Line {
+	from, to := splitLines(a), splitLines(b)
+
+	// Lines shared at the start and end need no search
+	prefix := 0
+	for prefix < len(from) && prefix < len(to) && from[prefix] == to[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(from)-prefix && suffix < len(to)-prefix && from[len(from)-1-suffix] == to[len(to)-1-suffix] {
+		suffix++
+	}
+	middleFrom, middleTo := from[prefix:len(from)-suffix], to[prefix:len(to)-suffix]
+
+	// lcs[i][j] is the length of the longest common subsequence of middleFrom[i:] and middleTo[j:]
+	lcs := make([][]int, len(middleFrom)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(middleTo)+1)
+	}
+	for i := len(middleFrom) - 1; i >= 0; i-- {
+		for j := len(middleTo) - 1; j >= 0; j-- {
+			if middleFrom[i] == middleTo[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := make([]Line, 0, len(from)+len(to))
+	for _, text := range from[:prefix] {
+		lines = append(lines, Line{Equal, text})
+	}
+	i, j := 0, 0
+	for i < len(middleFrom) || j < len(middleTo) {
+		switch {
+		case i < len(middleFrom) && j < len(middleTo) && middleFrom[i] == middleTo[j]:
+			lines = append(lines, Line{Equal, middleFrom[i]})
+			i++
+			j++
+		case i < len(middleFrom) && (j == len(middleTo) || lcs[i+1][j] >= lcs[i][j+1]):
+			// Deletions go before insertions, as in diff -u
+			lines = append(lines, Line{Delete, middleFrom[i]})
+			i++
+		default:
+			lines = append(lines, Line{Insert, middleTo[j]})
+			j++
+		}
+	}
+	for _, text := range from[len(from)-suffix:] {
+		lines = append(lines, Line{Equal, text})
+	}
+	return lines
+}
+
+// Hunks groups the changes of a line diff with up to context unchanged
+// lines around them. Changes closer than twice the context share a hunk.
+func Hunks(lines []Line, context int) []Hunk {
+	hunks := make([]Hunk, 0)
+	var current *Hunk
+	fromLine, toLine := 1, 1
+	// trailing counts the unchanged lines at the end of the current hunk
+	trailing := 0
+
+	for index, line := range lines {
+		if line.Op != Equal {
+			if current == nil || trailing > 2*context {
+				if current != nil {
+					// Drop the unchanged lines beyond the context of the previous hunk
+					current.trim(trailing - context)
+					hunks = append(hunks, *current)
+				}
+				start := max(index-context, 0)
+				if current != nil {
+					start = max(start, index-trailing)
+				}
+				current = &Hunk{FromLine: fromLine, ToLine: toLine}
+				for _, before := range lines[start:index] {
+					current.add(before)
+					current.FromLine--
+					current.ToLine--
+				}
+			}
+			trailing = 0
+			current.add(line)
+		} else if current != nil {
+			trailing++
+			current.add(line)
+		}
+
+		if line.Op != Insert {
+			fromLine++
+		}
+		if line.Op != Delete {
+			toLine++
+		}
+	}
+
+	if current != nil {
+		current.trim(trailing - context)
+		hunks = append(hunks, *current)
+	}
+	return hunks
+}
+
+// add appends a line to the hunk, counting it in the texts it belongs to
+func (h *Hunk) add(line Line) {
+	h.Lines = append(h.Lines, line)
+	if line.Op != Insert {
+		h.FromCount++
+	}
+	if line.Op != Delete {
+		h.ToCount++
+	}
+}
+
+// trim drops n unchanged lines from the end of the hunk
+func (h *Hunk) trim(n int) {
+	if n <= 0 {
+		return
+	}
+	h.Lines = h.Lines[:len(h.Lines)-n]
+	h.FromCount -= n
+	h.ToCount -= n
+}
+
+// Unified returns the unified diff turning a into b, with fromName and
+// toName in its header, or an empty string when they are equal
+func Unified(fromName, toName, a, b string) string {
+	if a == b {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for _, hunk := range Hunks(Lines(a, b), DefaultContext) {
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(hunk.FromLine, hunk.FromCount), hunkRange(hunk.ToLine, hunk.ToCount))
+		for _, line := range hunk.Lines {
+			out.WriteByte(byte(line.Op))
+			out.WriteString(line.Text)
+			if !strings.HasSuffix(line.Text, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+	return out.String()
+}
+
+// hunkRange formats the start and length of a hunk in one text. An empty
+// range starts at the line before it, as in diff -u.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// Styles of the diff lines, which keep tabs so the diff text is unchanged
+var (
+	headerStyle = lipgloss.NewStyle().Bold(true).TabWidth(lipgloss.NoTabConversion)
+	hunkStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#00FFFF")).TabWidth(lipgloss.NoTabConversion)
+	deleteStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5F5F")).TabWidth(lipgloss.NoTabConversion)
+	insertStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#5FFF5F")).TabWidth(lipgloss.NoTabConversion)
+)
+
+// Colorize colors the lines of a unified diff: removed lines red, added
+// lines green and hunk headers cyan. Without color support it returns the
+// diff unchanged.
+func Colorize(unified string) string {
+	lines := strings.SplitAfter(unified, "\n")
+	for i, line := range lines {
+		text := strings.TrimSuffix(line, "\n")
+		if text == "" {
+			continue
+		}
+
+		var style lipgloss.Style
+		switch {
+		case strings.HasPrefix(text, "--- "), strings.HasPrefix(text, "+++ "):
+			style = headerStyle
+		case strings.HasPrefix(text, "@@"):
+			style = hunkStyle
+		case text[0] == byte(Delete):
+			style = deleteStyle
+		case text[0] == byte(Insert):
+			style = insertStyle
+		default:
+			continue
+		}
+		lines[i] = style.Render(text) + line[len(text):]
+	}
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,81 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "equal",
+			a:    "one\ntwo\n",
+			b:    "one\ntwo\n",
+			want: "",
+		},
+		{
+			name: "changed line",
+			a:    "---\nglobs: \"*.go\"\n---\n# Style\n",
+			b:    "---\nglobs: \"**/*.go\"\n---\n# Style\n",
+			want: "--- a\n+++ b\n@@ -1,4 +1,4 @@\n ---\n-globs: \"*.go\"\n+globs: \"**/*.go\"\n ---\n # Style\n",
+		},
+		{
+			name: "added to empty",
+			a:    "",
+			b:    "one\n",
+			want: "--- a\n+++ b\n@@ -0,0 +1 @@\n+one\n",
+		},
+		{
+			name: "missing newline",
+			a:    "one\n",
+			b:    "one",
+			want: "--- a\n+++ b\n@@ -1 +1 @@\n-one\n+one\n\\ No newline at end of file\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unified("a", "b", tt.a, tt.b); got != tt.want {
+				t.Errorf("Unified() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnifiedSplitsDistantChanges(t *testing.T) {
+	lines := make([]string, 20)
+	for i := range lines {
+		lines[i] = string(rune('a'+i)) + "\n"
+	}
+	a := strings.Join(lines, "")
+	changed := append([]string{}, lines...)
+	changed[1] = "B\n"
+	changed[18] = "S\n"
+	b := strings.Join(changed, "")
+
+	got := Unified("a", "b", a, b)
+	want := "--- a\n+++ b\n" +
+		"@@ -1,5 +1,5 @@\n a\n-b\n+B\n c\n d\n e\n" +
+		"@@ -16,5 +16,5 @@\n p\n q\n r\n-s\n+S\n t\n"
+	if got != want {
+		t.Errorf("Unified() =\n%s\nwant\n%s", got, want)
+	}
+
+	// Changes within twice the context share a hunk
+	changed[18] = lines[18]
+	changed[8] = "I\n"
+	if hunks := Hunks(Lines(a, strings.Join(changed, "")), DefaultContext); len(hunks) != 1 {
+		t.Errorf("Got %d hunks, want 1", len(hunks))
+	}
+}
+
+func TestColorizeWithoutColorsKeepsDiff(t *testing.T) {
+	unified := Unified("a", "b", "\tone\n", "\ttwo\n")
+	if got := Colorize(unified); got != unified {
+		t.Errorf("Colorize() = %q, want %q", got, unified)
+	}
+}
@@ -0,0 +1,223 @@
+package linker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/circleci/llm-agent-rules/internal/rules"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultEditor is the name of the adapter used when no editor is chosen
+const DefaultEditor = "cursor"
+
+// EditorAdapter describes how rules are installed for a particular editor or agent
+type EditorAdapter interface {
+	// Name is the identifier used on the command line and in configuration
+	Name() string
+	// DisplayName is the human readable name shown in the TUI
+	DisplayName() string
+	// TargetDir is the directory rules are installed into, relative to the target project
+	TargetDir() string
+	// FileName returns the base name, without extension, a rule is installed as
+	FileName(rule *models.Rule) string
+	// FileExtension returns the extension of installed rule files, including the dot
+	FileExtension() string
+	// TransformFrontmatter returns the frontmatter block, without --- delimiters,
+	// written at the top of an installed rule. An empty string means the rule
+	// is installed without frontmatter.
+	TransformFrontmatter(rule *models.Rule) (string, error)
+	// AllowsSymlinks reports whether rules can be symlinked to their source
+	// instead of being written as transformed files
+	AllowsSymlinks() bool
+}
+
+// SectionAdapter is implemented by adapters that install rules as blocks in
+// a marker-delimited section of one shared file, such as CLAUDE.md, rather
+// than as one file per rule. Content outside the section is left untouched.
+type SectionAdapter interface {
+	EditorAdapter
+	// ManagedFile is the shared file, relative to the target project
+	ManagedFile() string
+	// RenderBlock returns the content of the rule's block in the section
+	RenderBlock(rule *models.Rule) (string, error)
+}
+
+// IndexAdapter is implemented by adapters whose per-rule files must also be
+// referenced from a managed section of an index file
+type IndexAdapter interface {
+	EditorAdapter
+	// IndexFile is the file holding the references, relative to the target project
+	IndexFile() string
+	// IndexEntry returns the reference to an installed rule file, given its
+	// path relative to the target project
+	IndexEntry(relPath string) string
+}
+
+// BodyRenderer is implemented by adapters that change the rule body when it
+// is installed, for example to describe when a rule applies
+type BodyRenderer interface {
+	RenderBody(rule *models.Rule) string
+}
+
+// Activator is implemented by adapters to describe how their editor applies
+// an installed rule to a file. Adapters without it follow Cursor's rule types.
+type Activator interface {
+	// Activate returns how the rule applies to a slash-separated file path
+	// relative to the target project, with the glob that matched it
+	Activate(rule *models.Rule, file string) (rules.Activation, string)
+}
+
+// Activate returns how the adapter's editor applies an installed rule to file
+func Activate(adapter EditorAdapter, rule *models.Rule, file string) (rules.Activation, string) {
+	if activator, ok := adapter.(Activator); ok {
+		return activator.Activate(rule, file)
+	}
+	return rules.Activate(rule, file)
+}
+
+// adapters holds the registered editor adapters in display order
+var adapters = []EditorAdapter{
+	cursorAdapter{},
+	windsurfAdapter{},
+	claudeAdapter{},
+	claudeImportsAdapter{},
+	agentsAdapter{},
+	copilotAdapter{},
+	copilotInstructionsAdapter{},
+	clineAdapter{},
+	rooAdapter{},
+}
+
+// Adapters returns every registered editor adapter in display order
+func Adapters() []EditorAdapter {
+	return append([]EditorAdapter(nil), adapters...)
+}
+
+// RegisterAdapter adds an editor adapter to the registry, replacing any
+// adapter already registered under the same name
+func RegisterAdapter(adapter EditorAdapter) {
+	for i, existing := range adapters {
+		if existing.Name() == adapter.Name() {
+			adapters[i] = adapter
+			return
+		}
+	}
+	adapters = append(adapters, adapter)
+}
+
+// LookupAdapter finds an adapter by name. The lookup is case-insensitive and
+// also accepts the display name and the legacy editor folder form (".cursor").
+func LookupAdapter(editor string) (EditorAdapter, error) {
+	key := strings.ToLower(strings.TrimSpace(editor))
+	key = strings.TrimSuffix(key, " (default)")
+	key = strings.TrimPrefix(key, ".")
+
+	for _, adapter := range adapters {
+		if key == adapter.Name() || key == strings.ToLower(adapter.DisplayName()) {
+			return adapter, nil
+		}
+	}
+
+	names := make([]string, 0, len(adapters))
+	for _, adapter := range adapters {
+		names = append(names, adapter.Name())
+	}
+	return nil, fmt.Errorf("unknown editor %q (expected one of: %s)", editor, strings.Join(names, ", "))
+}
+
+// RenderRule returns the content of a rule as installed by the adapter
+func RenderRule(adapter EditorAdapter, rule *models.Rule) ([]byte, error) {
+	frontmatter, err := adapter.TransformFrontmatter(rule)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform frontmatter for %s: %w", RuleID(rule), err)
+	}
+
+	body := ruleBody(rule)
+	if renderer, ok := adapter.(BodyRenderer); ok {
+		body = renderer.RenderBody(rule)
+	}
+
+	if frontmatter == "" {
+		return []byte(strings.TrimLeft(body, "\n")), nil
+	}
+
+	return []byte("---\n" + frontmatter + "---\n" + body), nil
+}
+
+// ruleBody returns the rule content without its frontmatter
+func ruleBody(rule *models.Rule) string {
+	if rule.Body == "" && !rule.HasFrontmatter() {
+		return rule.Content
+	}
+	return rule.Body
+}
+
+// scopeNote describes when a rule applies, for tools that load every
+// instruction unconditionally. Rules that always apply need no note.
+func scopeNote(rule *models.Rule) string {
+	switch {
+	case rule.AlwaysApply:
+		return ""
+	case len(rule.Globs) > 0:
+		return "> Applies to files matching: `" + strings.Join(rule.Globs, "`, `") + "`"
+	case rule.Description != "":
+		return "> Apply when relevant: " + strings.Join(strings.Fields(rule.Description), " ")
+	default:
+		return ""
+	}
+}
+
+// FlattenedName returns the rule name prefixed by its topic, with path
+// separators converted to underscores ("a/b" and "c" become "a_b_c")
+func FlattenedName(rule *models.Rule) string {
+	if rule.Topic == "" {
+		return rule.Name
+	}
+	return strings.ReplaceAll(rule.Topic, "/", "_") + "_" + rule.Name
+}
+
+// frontmatterField is a single key written to a transformed frontmatter block
+type frontmatterField struct {
+	Key   string
+	Value string
+	// List is written as a YAML block list instead of Value when set
+	List []string
+	// Bare writes the value as-is instead of YAML-quoting it, for formats
+	// such as comma-separated globs that tools expect unquoted
+	Bare bool
+}
+
+// formatFrontmatter renders fields as a frontmatter block, skipping empty values
+func formatFrontmatter(fields ...frontmatterField) string {
+	var b strings.Builder
+	for _, field := range fields {
+		if len(field.List) > 0 {
+			b.WriteString(field.Key + ":\n")
+			for _, item := range field.List {
+				b.WriteString("  - " + yamlScalar(item) + "\n")
+			}
+			continue
+		}
+		if field.Value == "" {
+			continue
+		}
+		value := field.Value
+		if !field.Bare {
+			value = yamlScalar(value)
+		}
+		b.WriteString(field.Key + ": " + value + "\n")
+	}
+	return b.String()
+}
+
+// yamlScalar quotes a single-line string only when YAML requires it
+func yamlScalar(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	out, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Sprintf("%q", s)
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
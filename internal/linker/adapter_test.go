@@ -0,0 +1,169 @@
+package linker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/circleci/llm-agent-rules/internal/rules"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+func TestRenderRuleFormats(t *testing.T) {
+	globbed, err := models.ParseRule("rules/go/testing.mdc", "---\ndescription: Go tests\nglobs: *_test.go, **/testdata/**\n---\n# Go testing\n")
+	if err != nil {
+		t.Fatalf("ParseRule failed: %v", err)
+	}
+	always, err := models.ParseRule("rules/style.mdc", "---\ndescription: Style\nalwaysApply: true\n---\n# Style\n")
+	if err != nil {
+		t.Fatalf("ParseRule failed: %v", err)
+	}
+	requested, err := models.ParseRule("rules/review.mdc", "---\ndescription: Reviewing pull requests\n---\n# Review\n")
+	if err != nil {
+		t.Fatalf("ParseRule failed: %v", err)
+	}
+
+	testCases := []struct {
+		name   string
+		editor string
+		rule   *models.Rule
+		want   string
+	}{
+		{
+			name:   "Copilot globs become applyTo",
+			editor: "copilot",
+			rule:   globbed,
+			want:   "---\ndescription: Go tests\napplyTo: '*_test.go,**/testdata/**'\n---\n# Go testing\n",
+		},
+		{
+			name:   "Copilot alwaysApply applies to every file",
+			editor: "copilot",
+			rule:   always,
+			want:   "---\ndescription: Style\napplyTo: '**'\n---\n# Style\n",
+		},
+		{
+			name:   "Cline globs become paths",
+			editor: "cline",
+			rule:   globbed,
+			want:   "---\npaths:\n  - '*_test.go'\n  - '**/testdata/**'\n---\n# Go testing\n",
+		},
+		{
+			name:   "Cline description-only rule gets a scope note",
+			editor: "cline",
+			rule:   requested,
+			want:   "> Apply when relevant: Reviewing pull requests\n\n# Review\n",
+		},
+		{
+			name:   "Roo drops frontmatter and annotates globs",
+			editor: "roo",
+			rule:   globbed,
+			want:   "> Applies to files matching: `*_test.go`, `**/testdata/**`\n\n# Go testing\n",
+		},
+		{
+			name:   "Roo alwaysApply rule is written as-is",
+			editor: "roo",
+			rule:   always,
+			want:   "# Style\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			adapter, err := LookupAdapter(tc.editor)
+			if err != nil {
+				t.Fatalf("LookupAdapter failed: %v", err)
+			}
+
+			got, err := RenderRule(adapter, tc.rule)
+			if err != nil {
+				t.Fatalf("RenderRule failed: %v", err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("RenderRule =\n%q\nwant\n%q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestActivate(t *testing.T) {
+	always := &models.Rule{Name: "base", AlwaysApply: true}
+	scoped := &models.Rule{Name: "react", Description: "React", Globs: []string{"**/*.tsx"}}
+	described := &models.Rule{Name: "review", Description: "Code review"}
+	bare := &models.Rule{Name: "notes"}
+
+	testCases := []struct {
+		editor string
+		want   []rules.Activation
+	}{
+		{editor: "cursor", want: []rules.Activation{rules.ActivationAlways, rules.ActivationNoMatch, rules.ActivationAgentRequested, rules.ActivationManual}},
+		{editor: "windsurf", want: []rules.Activation{rules.ActivationAlways, rules.ActivationNoMatch, rules.ActivationAgentRequested, rules.ActivationManual}},
+		{editor: "claude", want: []rules.Activation{rules.ActivationAlways, rules.ActivationAlways, rules.ActivationAlways, rules.ActivationAlways}},
+		{editor: "claude-imports", want: []rules.Activation{rules.ActivationAlways, rules.ActivationAlways, rules.ActivationAlways, rules.ActivationAlways}},
+		{editor: "agents", want: []rules.Activation{rules.ActivationAlways, rules.ActivationAlways, rules.ActivationAlways, rules.ActivationAlways}},
+		{editor: "copilot", want: []rules.Activation{rules.ActivationAlways, rules.ActivationNoMatch, rules.ActivationManual, rules.ActivationManual}},
+		{editor: "copilot-instructions", want: []rules.Activation{rules.ActivationAlways, rules.ActivationAlways, rules.ActivationAlways, rules.ActivationAlways}},
+		{editor: "cline", want: []rules.Activation{rules.ActivationAlways, rules.ActivationNoMatch, rules.ActivationAlways, rules.ActivationAlways}},
+		{editor: "roo", want: []rules.Activation{rules.ActivationAlways, rules.ActivationAlways, rules.ActivationAlways, rules.ActivationAlways}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.editor, func(t *testing.T) {
+			adapter, err := LookupAdapter(tc.editor)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, rule := range []*models.Rule{always, scoped, described, bare} {
+				if got, _ := Activate(adapter, rule, "src/a.go"); got != tc.want[i] {
+					t.Errorf("Activate(%s, src/a.go) = %s, want %s", rule.Name, got, tc.want[i])
+				}
+			}
+			if got, glob := Activate(adapter, scoped, "web/app.tsx"); got == rules.ActivationNoMatch || (got == rules.ActivationGlob && glob != "**/*.tsx") {
+				t.Errorf("Activate(react, web/app.tsx) = %s, %q; want the rule applied", got, glob)
+			}
+		})
+	}
+}
+
+func TestLinkRuleTargetPaths(t *testing.T) {
+	testCases := []struct {
+		editor string
+		path   string
+	}{
+		{editor: "copilot", path: ".github/instructions/go_testing.instructions.md"},
+		{editor: "copilot-instructions", path: ".github/copilot-instructions.md"},
+		{editor: "agents", path: "AGENTS.md"},
+		{editor: "cline", path: ".clinerules/go_testing.md"},
+		{editor: "roo", path: ".roo/rules/go_testing.md"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.editor, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			rule := newTestRule(t, tmpDir, "go", "testing", "---\ndescription: Go tests\nglobs: *_test.go\n---\n# Go testing\n")
+
+			l := NewLinker(tmpDir)
+			if err := l.LinkRule(rule, tc.editor); err != nil {
+				t.Fatalf("LinkRule failed: %v", err)
+			}
+
+			content, err := os.ReadFile(filepath.Join(tmpDir, filepath.FromSlash(tc.path)))
+			if err != nil {
+				t.Fatalf("Expected %s to be written: %v", tc.path, err)
+			}
+			if !strings.Contains(string(content), "# Go testing") {
+				t.Errorf("%s does not contain the rule body:\n%s", tc.path, content)
+			}
+			if !l.IsRuleLinked(rule, tc.editor) {
+				t.Error("Expected rule to be reported as linked")
+			}
+
+			if err := l.UnlinkRule("go/testing", tc.editor); err != nil {
+				t.Fatalf("UnlinkRule failed: %v", err)
+			}
+			if l.IsRuleLinked(rule, tc.editor) {
+				t.Error("Expected rule to be unlinked")
+			}
+		})
+	}
+}
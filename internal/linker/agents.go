@@ -0,0 +1,33 @@
+package linker
+
+import (
+	"github.com/circleci/llm-agent-rules/internal/rules"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// agentsAdapter installs rules inline into a managed section of AGENTS.md,
+// the shared instructions file read by many coding agents
+type agentsAdapter struct{}
+
+func (agentsAdapter) Name() string                      { return "agents" }
+func (agentsAdapter) DisplayName() string               { return "AGENTS.md" }
+func (agentsAdapter) TargetDir() string                 { return "" }
+func (agentsAdapter) FileName(rule *models.Rule) string { return FlattenedName(rule) }
+func (agentsAdapter) FileExtension() string             { return ".md" }
+func (agentsAdapter) AllowsSymlinks() bool              { return false }
+func (agentsAdapter) ManagedFile() string               { return "AGENTS.md" }
+
+// TransformFrontmatter drops the frontmatter, which AGENTS.md does not support
+func (agentsAdapter) TransformFrontmatter(rule *models.Rule) (string, error) {
+	return "", nil
+}
+
+// Activate applies every rule, as agents read the whole file
+func (agentsAdapter) Activate(rule *models.Rule, file string) (rules.Activation, string) {
+	return rules.ActivationAlways, ""
+}
+
+// RenderBlock renders the rule under a heading with its scope annotation
+func (agentsAdapter) RenderBlock(rule *models.Rule) (string, error) {
+	return sectionBlock(rule)
+}
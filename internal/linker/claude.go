@@ -0,0 +1,94 @@
+package linker
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/circleci/llm-agent-rules/internal/rules"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// claudeFile is the memory file Claude Code loads for a project
+const claudeFile = "CLAUDE.md"
+
+// claudeAdapter installs rules inline into a managed section of CLAUDE.md.
+// Claude Code loads CLAUDE.md in full, so rules that are scoped by globs or
+// description are annotated with when they apply.
+type claudeAdapter struct{}
+
+func (claudeAdapter) Name() string                      { return "claude" }
+func (claudeAdapter) DisplayName() string               { return "Claude Code (CLAUDE.md)" }
+func (claudeAdapter) TargetDir() string                 { return "" }
+func (claudeAdapter) FileName(rule *models.Rule) string { return FlattenedName(rule) }
+func (claudeAdapter) FileExtension() string             { return ".md" }
+func (claudeAdapter) AllowsSymlinks() bool              { return false }
+func (claudeAdapter) ManagedFile() string               { return claudeFile }
+
+// TransformFrontmatter drops the frontmatter, which Claude Code does not read
+func (claudeAdapter) TransformFrontmatter(rule *models.Rule) (string, error) {
+	return "", nil
+}
+
+// Activate applies every rule, as Claude Code loads CLAUDE.md in full
+func (claudeAdapter) Activate(rule *models.Rule, file string) (rules.Activation, string) {
+	return rules.ActivationAlways, ""
+}
+
+// RenderBlock renders the rule under a heading with its scope annotation
+func (claudeAdapter) RenderBlock(rule *models.Rule) (string, error) {
+	return sectionBlock(rule)
+}
+
+// claudeImportsAdapter installs rules as individual files under .claude/rules
+// and references them from a managed section of CLAUDE.md with @ imports
+type claudeImportsAdapter struct{}
+
+func (claudeImportsAdapter) Name() string                      { return "claude-imports" }
+func (claudeImportsAdapter) DisplayName() string               { return "Claude Code (.claude/rules imports)" }
+func (claudeImportsAdapter) TargetDir() string                 { return ".claude/rules" }
+func (claudeImportsAdapter) FileName(rule *models.Rule) string { return FlattenedName(rule) }
+func (claudeImportsAdapter) FileExtension() string             { return ".md" }
+func (claudeImportsAdapter) AllowsSymlinks() bool              { return false }
+func (claudeImportsAdapter) IndexFile() string                 { return claudeFile }
+
+// TransformFrontmatter drops the frontmatter, which Claude Code does not read
+func (claudeImportsAdapter) TransformFrontmatter(rule *models.Rule) (string, error) {
+	return "", nil
+}
+
+// Activate applies every rule, as the imports are loaded with CLAUDE.md
+func (claudeImportsAdapter) Activate(rule *models.Rule, file string) (rules.Activation, string) {
+	return rules.ActivationAlways, ""
+}
+
+// RenderBody prefixes the body with the rule's scope annotation
+func (claudeImportsAdapter) RenderBody(rule *models.Rule) string {
+	return annotatedBody(rule) + "\n"
+}
+
+// IndexEntry returns the @ import for an installed rule file
+func (claudeImportsAdapter) IndexEntry(relPath string) string {
+	return "@" + path.Clean(relPath)
+}
+
+// sectionBlock renders a rule for a shared instructions file: a heading
+// with the rule ID followed by the annotated body. A body containing the
+// section markers is rejected, as it would end the block or section early
+// and corrupt the file on the next read.
+func sectionBlock(rule *models.Rule) (string, error) {
+	body := annotatedBody(rule)
+	if strings.Contains(body, markerPrefix) {
+		return "", fmt.Errorf("rule %s contains the reserved marker %q", RuleID(rule), markerPrefix)
+	}
+	return "## " + RuleID(rule) + "\n\n" + body, nil
+}
+
+// annotatedBody returns the trimmed rule body, preceded by its scope note when it has one
+func annotatedBody(rule *models.Rule) string {
+	body := strings.TrimSpace(ruleBody(rule))
+	if note := scopeNote(rule); note != "" {
+		return note + "\n\n" + body
+	}
+	return body
+}
@@ -0,0 +1,147 @@
+package linker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+func newTestRule(t *testing.T, dir, topic, name, content string) *models.Rule {
+	t.Helper()
+	path := filepath.Join(dir, "repo", "rules", topic, name+".mdc")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create rule directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write rule: %v", err)
+	}
+	rule, err := models.NewRule(path)
+	if err != nil {
+		t.Fatalf("NewRule failed: %v", err)
+	}
+	rule.Topic = topic
+	return rule
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestClaudeManagedSection(t *testing.T) {
+	tmpDir := t.TempDir()
+	always := newTestRule(t, tmpDir, "", "style", "---\ndescription: Style\nalwaysApply: true\n---\n# Style\nBe concise.\n")
+	scoped := newTestRule(t, tmpDir, "go", "testing", "---\ndescription: Go tests\nglobs: *_test.go\n---\n# Go testing\nUse table tests.\n")
+
+	claudePath := filepath.Join(tmpDir, "CLAUDE.md")
+	userContent := "# My project\n\nHand-written notes.\n"
+	if err := os.WriteFile(claudePath, []byte(userContent), 0644); err != nil {
+		t.Fatalf("Failed to write CLAUDE.md: %v", err)
+	}
+
+	l := NewLinker(tmpDir)
+	if err := l.LinkRules([]*models.Rule{always, scoped}, "claude"); err != nil {
+		t.Fatalf("LinkRules failed: %v", err)
+	}
+
+	content := readFile(t, claudePath)
+	if !strings.HasPrefix(content, userContent) {
+		t.Errorf("User content was not preserved:\n%s", content)
+	}
+	for _, want := range []string{
+		"<!-- rule-tool:rule go/testing -->",
+		"> Applies to files matching: `*_test.go`",
+		"Use table tests.",
+		"## style\n\n# Style\nBe concise.",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("CLAUDE.md does not contain %q:\n%s", want, content)
+		}
+	}
+
+	// Re-running must produce identical output
+	if err := l.LinkRules([]*models.Rule{always, scoped}, "claude"); err != nil {
+		t.Fatalf("LinkRules failed on re-run: %v", err)
+	}
+	if rerun := readFile(t, claudePath); rerun != content {
+		t.Errorf("Re-running changed CLAUDE.md:\n%s\nwant\n%s", rerun, content)
+	}
+
+	if !l.IsRuleLinked(scoped, "claude") {
+		t.Error("Expected scoped rule to be linked")
+	}
+
+	if err := l.UnlinkRule("go/testing", "claude"); err != nil {
+		t.Fatalf("UnlinkRule failed: %v", err)
+	}
+	if l.IsRuleLinked(scoped, "claude") || !l.IsRuleLinked(always, "claude") {
+		t.Error("Expected only the scoped rule to be removed")
+	}
+
+	// Removing the last rule removes the section but keeps the user's content
+	if err := l.UnlinkRule("style", "claude"); err != nil {
+		t.Fatalf("UnlinkRule failed: %v", err)
+	}
+	if content := readFile(t, claudePath); content != userContent {
+		t.Errorf("Expected only user content to remain, got:\n%s", content)
+	}
+}
+
+func TestSectionRejectsMarkersInRules(t *testing.T) {
+	tmpDir := t.TempDir()
+	style := newTestRule(t, tmpDir, "", "style", "# Style\nBe concise.\n")
+	spoof := newTestRule(t, tmpDir, "", "spoof", "# Spoof\n<!-- rule-tool:end -->\nOutside the section.\n")
+
+	l := NewLinker(tmpDir)
+	if err := l.LinkRules([]*models.Rule{style}, "claude"); err != nil {
+		t.Fatalf("LinkRules failed: %v", err)
+	}
+	claudePath := filepath.Join(tmpDir, "CLAUDE.md")
+	before := readFile(t, claudePath)
+
+	for _, editor := range []string{"claude", "agents", "copilot-instructions"} {
+		if err := l.LinkRules([]*models.Rule{spoof}, editor); err == nil || !strings.Contains(err.Error(), "reserved marker") {
+			t.Errorf("LinkRules(%s) error = %v, want the marker rejected", editor, err)
+		}
+	}
+	if after := readFile(t, claudePath); after != before {
+		t.Errorf("CLAUDE.md changed after a rejected rule:\n%s", after)
+	}
+	if !l.IsRuleLinked(style, "claude") {
+		t.Error("Expected the existing rule to stay linked")
+	}
+}
+
+func TestClaudeImports(t *testing.T) {
+	tmpDir := t.TempDir()
+	rule := newTestRule(t, tmpDir, "go", "testing", "---\ndescription: Go tests\nglobs: *_test.go\n---\n# Go testing\n")
+
+	l := NewLinker(tmpDir)
+	if err := l.LinkRule(rule, "claude-imports"); err != nil {
+		t.Fatalf("LinkRule failed: %v", err)
+	}
+
+	_, ruleFile, _ := parseStamp([]byte(readFile(t, filepath.Join(tmpDir, ".claude", "rules", "go_testing.md"))))
+	if want := "> Applies to files matching: `*_test.go`\n\n# Go testing\n"; string(ruleFile) != want {
+		t.Errorf("Rule file =\n%q\nwant\n%q", ruleFile, want)
+	}
+
+	claudePath := filepath.Join(tmpDir, "CLAUDE.md")
+	if content := readFile(t, claudePath); !strings.Contains(content, "@.claude/rules/go_testing.md") {
+		t.Errorf("CLAUDE.md does not import the rule file:\n%s", content)
+	}
+
+	if err := l.UnlinkRule("go/testing", "claude-imports"); err != nil {
+		t.Fatalf("UnlinkRule failed: %v", err)
+	}
+	if _, err := os.Stat(claudePath); !os.IsNotExist(err) {
+		t.Errorf("Expected CLAUDE.md created by the tool to be removed once empty, got err=%v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package linker
+
+import (
+	"github.com/circleci/llm-agent-rules/internal/rules"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// clineAdapter installs rules as Markdown files in .clinerules. Cline scopes
+// rules to files with a paths list in the frontmatter; rules without globs
+// are always active, so description-only rules carry a note on when to apply.
+type clineAdapter struct{}
+
+func (clineAdapter) Name() string                      { return "cline" }
+func (clineAdapter) DisplayName() string               { return "Cline" }
+func (clineAdapter) TargetDir() string                 { return ".clinerules" }
+func (clineAdapter) FileName(rule *models.Rule) string { return FlattenedName(rule) }
+func (clineAdapter) FileExtension() string             { return ".md" }
+func (clineAdapter) AllowsSymlinks() bool              { return false }
+
+// TransformFrontmatter maps globs to Cline's paths list
+func (clineAdapter) TransformFrontmatter(rule *models.Rule) (string, error) {
+	if rule.AlwaysApply {
+		return "", nil
+	}
+	return formatFrontmatter(frontmatterField{Key: "paths", List: rule.Globs}), nil
+}
+
+// Activate scopes rules with globs to the files they match; every other rule
+// has no paths and is always active
+func (clineAdapter) Activate(rule *models.Rule, file string) (rules.Activation, string) {
+	if rule.AlwaysApply || len(rule.Globs) == 0 {
+		return rules.ActivationAlways, ""
+	}
+	return rules.Activate(rule, file)
+}
+
+// RenderBody adds a scope note to rules that only have a description
+func (clineAdapter) RenderBody(rule *models.Rule) string {
+	if rule.AlwaysApply || len(rule.Globs) > 0 {
+		return ruleBody(rule)
+	}
+	return annotatedBody(rule) + "\n"
+}
@@ -0,0 +1,217 @@
+package linker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// CollisionStrategy controls what happens when two rules flatten to the same file name
+type CollisionStrategy string
+
+const (
+	// CollisionFail refuses to link rules whose file names collide
+	CollisionFail CollisionStrategy = "fail"
+	// CollisionSuffix keeps the plain name for the first rule (by topic/name order)
+	// and links the others under a name with a stable hash suffix
+	CollisionSuffix CollisionStrategy = "suffix"
+)
+
+// CollisionStrategies lists every supported collision strategy
+var CollisionStrategies = []CollisionStrategy{CollisionFail, CollisionSuffix}
+
+// ParseCollisionStrategy converts a flag value into a CollisionStrategy
+func ParseCollisionStrategy(value string) (CollisionStrategy, error) {
+	for _, strategy := range CollisionStrategies {
+		if string(strategy) == value {
+			return strategy, nil
+		}
+	}
+	return "", fmt.Errorf("unknown collision strategy %q (expected fail or suffix)", value)
+}
+
+// Collision describes rules that would be linked to the same file
+type Collision struct {
+	FileName string
+	// Rules holds the IDs (topic/name) of the conflicting source rules
+	Rules []string
+	// Existing is the source an already installed link points to, if the
+	// collision is with a file in the target rather than within the batch
+	Existing string
+}
+
+// CollisionError is returned when rules cannot be linked without overwriting each other
+type CollisionError struct {
+	Collisions []Collision
+}
+
+// Error implements the error interface
+func (e *CollisionError) Error() string {
+	parts := make([]string, 0, len(e.Collisions))
+	for _, c := range e.Collisions {
+		sources := strings.Join(c.Rules, ", ")
+		if c.Existing != "" {
+			sources += " and existing link to " + c.Existing
+		}
+		parts = append(parts, fmt.Sprintf("%s is claimed by %s", c.FileName, sources))
+	}
+	return "rule file name collision: " + strings.Join(parts, "; ")
+}
+
+// RuleID returns the topic/name identifier for a rule
+func RuleID(rule *models.Rule) string {
+	if rule.Topic != "" {
+		return rule.Topic + "/" + rule.Name
+	}
+	return rule.Name
+}
+
+// DisambiguatedFileName returns the collision-free file name for a rule,
+// which appends a short hash of the rule ID to its flattened name
+func DisambiguatedFileName(adapter EditorAdapter, rule *models.Rule) string {
+	return disambiguate(adapter.FileName(rule)+adapter.FileExtension(), RuleID(rule))
+}
+
+func disambiguate(fileName, id string) string {
+	sum := sha256.Sum256([]byte(id))
+	ext := filepath.Ext(fileName)
+	return strings.TrimSuffix(fileName, ext) + "_" + hex.EncodeToString(sum[:])[:8] + ext
+}
+
+// PlanTargets computes the target file name of every rule before anything is
+// linked. Rules that flatten to the same name, or whose name is already
+// taken by a link to or copy of a different source, are either reported as a
+// *CollisionError or disambiguated, depending on the collision strategy.
+// The returned map is keyed by rule path.
+func (l *Linker) PlanTargets(rules []*models.Rule, editor string) (map[string]string, error) {
+	adapter, err := LookupAdapter(editor)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rules in a managed section are keyed by rule ID, which cannot collide
+	if _, ok := adapter.(SectionAdapter); ok {
+		plan := make(map[string]string, len(rules))
+		for _, rule := range rules {
+			plan[rule.Path] = RuleID(rule)
+		}
+		return plan, nil
+	}
+
+	byName := make(map[string][]*models.Rule)
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		if seen[rule.Path] {
+			continue
+		}
+		seen[rule.Path] = true
+		name := adapter.FileName(rule) + adapter.FileExtension()
+		byName[name] = append(byName[name], rule)
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	plan := make(map[string]string, len(seen))
+	collisions := make([]Collision, 0)
+
+	for _, name := range names {
+		group := byName[name]
+		sort.Slice(group, func(i, j int) bool { return RuleID(group[i]) < RuleID(group[j]) })
+
+		// A link already in the target claims the name, either for one of the
+		// rules in the group (which then keeps it) or for another source
+		existing, owner := l.existingLinkOwner(filepath.Join(l.RulesDir(adapter), name), group)
+		if owner > 0 {
+			group[0], group[owner] = group[owner], group[0]
+		}
+
+		if (len(group) > 1 || existing != "") && l.CollisionStrategy != CollisionSuffix {
+			ids := make([]string, 0, len(group))
+			for _, rule := range group {
+				ids = append(ids, RuleID(rule))
+			}
+			collisions = append(collisions, Collision{FileName: name, Rules: ids, Existing: existing})
+			continue
+		}
+
+		for i, rule := range group {
+			if i == 0 && existing == "" {
+				plan[rule.Path] = name
+			} else {
+				plan[rule.Path] = DisambiguatedFileName(adapter, rule)
+			}
+		}
+	}
+
+	if len(collisions) > 0 {
+		return nil, &CollisionError{Collisions: collisions}
+	}
+
+	return plan, nil
+}
+
+// existingLinkOwner inspects an existing symlink or stamped copy at
+// targetPath. It returns the index of the rule in rules it was installed
+// from, or its source and -1 when it belongs to something else. Both are
+// empty when there is no symlink or stamped copy.
+func (l *Linker) existingLinkOwner(targetPath string, rules []*models.Rule) (string, int) {
+	if source, ok := linkSource(targetPath); ok {
+		for i, rule := range rules {
+			if l.linksTo(targetPath, source, rule) {
+				return "", i
+			}
+		}
+		return source, -1
+	}
+
+	if stamp, ok := ReadStamp(targetPath); ok {
+		for i, rule := range rules {
+			if stamp.Source == l.SourceRef(rule) {
+				return "", i
+			}
+		}
+		return stamp.Source, -1
+	}
+
+	return "", -1
+}
+
+// linksTo reports whether the symlink at targetPath (pointing at source) is a link to rule
+func (l *Linker) linksTo(targetPath, source string, rule *models.Rule) bool {
+	if source == rule.Path {
+		return true
+	}
+
+	resolved := source
+	if !filepath.IsAbs(resolved) {
+		resolved = filepath.Join(filepath.Dir(targetPath), resolved)
+	}
+	rulePath, err := filepath.Abs(rule.Path)
+	if err != nil {
+		return false
+	}
+	return filepath.Clean(resolved) == filepath.Clean(rulePath)
+}
+
+// linkSource returns the raw target of the symlink at path, if path is a symlink
+func linkSource(path string) (string, bool) {
+	info, err := os.Lstat(path)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return "", false
+	}
+	source, err := os.Readlink(path)
+	if err != nil {
+		return "", false
+	}
+	return source, true
+}
@@ -0,0 +1,99 @@
+package linker
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/circleci/llm-agent-rules/internal/diff"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// ErrNotInstalled is returned when comparing a rule that is not installed for the editor
+var ErrNotInstalled = errors.New("rule is not installed")
+
+// Comparison holds an installed rule next to what installing its current
+// source would write
+type Comparison struct {
+	// Destination is where the rule is installed, relative to the target project
+	Destination string
+	// Source is the source rule, as recorded in copy stamps
+	Source string
+	// Mode is how the rule was installed
+	Mode LinkMode
+	// Installed is the installed content without the copy stamp, or empty
+	// when the installed file was deleted
+	Installed string
+	// Upstream is the current source rule as the editor gets it
+	Upstream string
+}
+
+// Changed reports whether the installed rule differs from its source
+func (c *Comparison) Changed() bool {
+	return c.Installed != c.Upstream
+}
+
+// Unified returns the unified diff from the installed rule to its source,
+// or an empty string when they are the same
+func (c *Comparison) Unified() string {
+	return diff.Unified(c.Destination+" (installed)", c.Source+" (upstream)", c.Installed, c.Upstream)
+}
+
+// Compare reads the rule installed for editor, as recorded in the lockfile,
+// and renders its current source the same way. A symlink always shows its
+// source, so it only differs when it no longer resolves.
+func (l *Linker) Compare(rule *models.Rule, editor string) (*Comparison, error) {
+	adapter, err := LookupAdapter(editor)
+	if err != nil {
+		return nil, err
+	}
+	lock, err := l.Lockfile()
+	if err != nil {
+		return nil, err
+	}
+	entry, ok := lock.Get(adapter.Name(), RuleID(rule))
+	if !ok {
+		return nil, fmt.Errorf("%s for %s: %w", RuleID(rule), adapter.DisplayName(), ErrNotInstalled)
+	}
+	comparison := &Comparison{Destination: entry.Destination, Source: l.SourceRef(rule), Mode: LinkMode(entry.Mode)}
+	source := rule
+	if rule, _, err = l.Rendered(source, adapter.Name()); err != nil {
+		return nil, fmt.Errorf("failed to render %s: %w", RuleID(source), err)
+	}
+
+	if section, ok := adapter.(SectionAdapter); ok {
+		block, found, err := l.InstalledBlock(section, entry.ID)
+		if err != nil {
+			return nil, err
+		}
+		upstream, err := section.RenderBlock(rule)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			comparison.Installed = block + "\n"
+		}
+		comparison.Upstream = strings.TrimSpace(upstream) + "\n"
+		return comparison, nil
+	}
+
+	if comparison.Mode == ModeSymlink {
+		comparison.Upstream = rule.Content
+	} else {
+		upstream, err := RenderRule(adapter, rule)
+		if err != nil {
+			return nil, err
+		}
+		comparison.Upstream = string(upstream)
+	}
+
+	installed, err := os.ReadFile(filepath.Join(l.TargetDir, filepath.FromSlash(entry.Destination)))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", entry.Destination, err)
+	}
+	_, stripped, _ := parseStamp(installed)
+	comparison.Installed = string(stripped)
+	return comparison, nil
+}
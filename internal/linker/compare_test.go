@@ -0,0 +1,62 @@
+package linker
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+func TestCompare(t *testing.T) {
+	tmpDir := t.TempDir()
+	rule := newTestRule(t, tmpDir, "", "style", "# style\n")
+	linked := newTestRule(t, tmpDir, "", "linked", "# linked\n")
+
+	l := NewLinker(tmpDir)
+	if _, err := l.Compare(rule, "cursor"); !errors.Is(err, ErrNotInstalled) {
+		t.Errorf("Compare of a rule that is not installed returned %v, want ErrNotInstalled", err)
+	}
+
+	if err := l.LinkRules([]*models.Rule{linked}, "cursor"); err != nil {
+		t.Fatalf("LinkRules failed: %v", err)
+	}
+	l.SetMode(ModeCopy)
+	if err := l.LinkRules([]*models.Rule{rule}, "cursor"); err != nil {
+		t.Fatalf("LinkRules failed: %v", err)
+	}
+
+	comparison, err := l.Compare(linked, "cursor")
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	if comparison.Mode != ModeSymlink || comparison.Changed() {
+		t.Errorf("Symlinked rule comparison = %+v, want an unchanged symlink", comparison)
+	}
+
+	// Edit the installed copy
+	copyPath := filepath.Join(tmpDir, ".cursor", "rules", "style.mdc")
+	content, err := os.ReadFile(copyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(copyPath, append(content, "Local edit\n"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	comparison, err = l.Compare(rule, "cursor")
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	if !comparison.Changed() || comparison.Destination != ".cursor/rules/style.mdc" {
+		t.Errorf("Comparison = %+v, want the edited copy", comparison)
+	}
+	if strings.Contains(comparison.Installed, "rule-tool:copy") {
+		t.Errorf("Installed content includes the copy stamp:\n%s", comparison.Installed)
+	}
+	if comparison.Installed != rule.Content+"Local edit\n" || comparison.Upstream != rule.Content {
+		t.Errorf("Installed = %q, Upstream = %q", comparison.Installed, comparison.Upstream)
+	}
+}
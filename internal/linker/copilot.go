@@ -0,0 +1,78 @@
+package linker
+
+import (
+	"strings"
+
+	"github.com/circleci/llm-agent-rules/internal/rules"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// copilotApplyToAll is the applyTo pattern matching every file
+const copilotApplyToAll = "**"
+
+// copilotAdapter installs rules as GitHub Copilot path-specific instruction
+// files in .github/instructions, scoped with applyTo frontmatter
+type copilotAdapter struct{}
+
+func (copilotAdapter) Name() string                      { return "copilot" }
+func (copilotAdapter) DisplayName() string               { return "GitHub Copilot (.github/instructions)" }
+func (copilotAdapter) TargetDir() string                 { return ".github/instructions" }
+func (copilotAdapter) FileName(rule *models.Rule) string { return FlattenedName(rule) }
+func (copilotAdapter) FileExtension() string             { return ".instructions.md" }
+func (copilotAdapter) AllowsSymlinks() bool              { return false }
+
+// TransformFrontmatter maps alwaysApply to applyTo "**" and globs to a
+// comma-separated applyTo. Rules with neither have no applyTo and are only
+// used when attached manually.
+func (copilotAdapter) TransformFrontmatter(rule *models.Rule) (string, error) {
+	applyTo := ""
+	switch {
+	case rule.AlwaysApply:
+		applyTo = copilotApplyToAll
+	case len(rule.Globs) > 0:
+		applyTo = strings.Join(rule.Globs, ",")
+	}
+
+	return formatFrontmatter(
+		frontmatterField{Key: "description", Value: rule.Description},
+		frontmatterField{Key: "applyTo", Value: applyTo},
+	), nil
+}
+
+// Activate follows applyTo: rules without one are only used when attached
+// manually, whatever their description
+func (copilotAdapter) Activate(rule *models.Rule, file string) (rules.Activation, string) {
+	if !rule.AlwaysApply && len(rule.Globs) == 0 {
+		return rules.ActivationManual, ""
+	}
+	return rules.Activate(rule, file)
+}
+
+// copilotInstructionsAdapter installs rules inline into a managed section of
+// .github/copilot-instructions.md, which Copilot applies to every request
+type copilotInstructionsAdapter struct{}
+
+func (copilotInstructionsAdapter) Name() string { return "copilot-instructions" }
+func (copilotInstructionsAdapter) DisplayName() string {
+	return "GitHub Copilot (copilot-instructions.md)"
+}
+func (copilotInstructionsAdapter) TargetDir() string                 { return "" }
+func (copilotInstructionsAdapter) FileName(rule *models.Rule) string { return FlattenedName(rule) }
+func (copilotInstructionsAdapter) FileExtension() string             { return ".md" }
+func (copilotInstructionsAdapter) AllowsSymlinks() bool              { return false }
+func (copilotInstructionsAdapter) ManagedFile() string               { return ".github/copilot-instructions.md" }
+
+// TransformFrontmatter drops the frontmatter, which copilot-instructions.md does not support
+func (copilotInstructionsAdapter) TransformFrontmatter(rule *models.Rule) (string, error) {
+	return "", nil
+}
+
+// Activate applies every rule, as Copilot reads the whole file
+func (copilotInstructionsAdapter) Activate(rule *models.Rule, file string) (rules.Activation, string) {
+	return rules.ActivationAlways, ""
+}
+
+// RenderBlock renders the rule under a heading with its scope annotation
+func (copilotInstructionsAdapter) RenderBlock(rule *models.Rule) (string, error) {
+	return sectionBlock(rule)
+}
@@ -0,0 +1,201 @@
+package linker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// LinkMode controls how rules are installed for editors that accept the source format
+type LinkMode string
+
+const (
+	// ModeSymlink links rules with relative symlinks where the editor allows it
+	ModeSymlink LinkMode = "symlink"
+	// ModeCopy always writes real files stamped with their source and content hash
+	ModeCopy LinkMode = "copy"
+)
+
+// ParseLinkMode converts a flag value into a LinkMode
+func ParseLinkMode(value string) (LinkMode, error) {
+	switch LinkMode(value) {
+	case ModeSymlink, ModeCopy:
+		return LinkMode(value), nil
+	}
+	return "", fmt.Errorf("unknown link mode %q (expected symlink or copy)", value)
+}
+
+// CopyState describes how an installed copy relates to its source rule
+type CopyState string
+
+const (
+	// CopyUnchanged means neither the copy nor the source changed since install
+	CopyUnchanged CopyState = "unchanged"
+	// CopyUpstreamChanged means the source rule changed since the copy was written
+	CopyUpstreamChanged CopyState = "upstream-changed"
+	// CopyLocallyEdited means the copy was edited in the target project
+	CopyLocallyEdited CopyState = "locally-edited"
+	// CopyConflict means both the copy and the source changed
+	CopyConflict CopyState = "conflict"
+)
+
+// ErrLocallyEdited is returned when installing over a copy that was edited
+// in the target project, unless the linker is forced
+var ErrLocallyEdited = errors.New("installed copy has local edits")
+
+// Stamp records where an installed copy came from and the hash of the
+// content that was written, excluding the stamp itself
+type Stamp struct {
+	Source string
+	Hash   string
+}
+
+// stampRe matches the stamp comment written into copies
+var stampRe = regexp.MustCompile(`(?m)^<!-- rule-tool:copy source=(\S+) sha256=([0-9a-f]{64}) -->\n`)
+
+// String renders the stamp as the comment line written into copies
+func (s Stamp) String() string {
+	return fmt.Sprintf("<!-- rule-tool:copy source=%s sha256=%s -->\n", s.Source, s.Hash)
+}
+
+// ContentHash returns the hex-encoded SHA-256 of content
+func ContentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// stampContent inserts the stamp after the frontmatter, or at the top of
+// content without frontmatter, so editors still find the frontmatter first
+func stampContent(content []byte, stamp Stamp) []byte {
+	text := string(content)
+	if strings.HasPrefix(text, "---\n") {
+		if end := strings.Index(text[4:], "\n---\n"); end >= 0 {
+			split := 4 + end + len("\n---\n")
+			return []byte(text[:split] + stamp.String() + text[split:])
+		}
+	}
+	return []byte(stamp.String() + text)
+}
+
+// parseStamp extracts the stamp from installed content and returns the
+// content with the stamp removed
+func parseStamp(content []byte) (Stamp, []byte, bool) {
+	loc := stampRe.FindSubmatchIndex(content)
+	if loc == nil {
+		return Stamp{}, content, false
+	}
+
+	stamp := Stamp{
+		Source: string(content[loc[2]:loc[3]]),
+		Hash:   string(content[loc[4]:loc[5]]),
+	}
+	stripped := append(append([]byte{}, content[:loc[0]]...), content[loc[1]:]...)
+	return stamp, stripped, true
+}
+
+// ReadStamp reads the stamp from an installed copy
+func ReadStamp(path string) (Stamp, bool) {
+	info, err := os.Lstat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return Stamp{}, false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Stamp{}, false
+	}
+	stamp, _, ok := parseStamp(content)
+	return stamp, ok
+}
+
+// SourceRef returns the source reference recorded for a rule: its path
+// relative to the root of its source when one is set, so stamps are portable
+// between machines, or its path as loaded otherwise
+func (l *Linker) SourceRef(rule *models.Rule) string {
+	if root := l.ruleSource(rule).Root; root != "" {
+		if rel, err := filepath.Rel(root, rule.Path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(rule.Path)
+}
+
+// renderCopy renders a rule for the adapter and stamps it, returning the
+// stamped content and the hash recorded in the stamp
+func (l *Linker) renderCopy(adapter EditorAdapter, rule *models.Rule) ([]byte, string, error) {
+	content, err := RenderRule(adapter, rule)
+	if err != nil {
+		return nil, "", err
+	}
+	stamp := Stamp{Source: l.SourceRef(rule), Hash: ContentHash(content)}
+	return stampContent(content, stamp), stamp.Hash, nil
+}
+
+// CopyStatus compares an installed copy at targetPath with the current
+// rendering of its source rule
+func (l *Linker) CopyStatus(rule *models.Rule, editor, targetPath string) (CopyState, error) {
+	adapter, err := LookupAdapter(editor)
+	if err != nil {
+		return "", err
+	}
+
+	installed, err := os.ReadFile(targetPath)
+	if err != nil {
+		return "", err
+	}
+	stamp, stripped, ok := parseStamp(installed)
+	if !ok {
+		return "", fmt.Errorf("%s is not a rule-tool copy", targetPath)
+	}
+
+	rendered, _, err := l.Rendered(rule, adapter.Name())
+	if err != nil {
+		return "", err
+	}
+	upstream, err := RenderRule(adapter, rendered)
+	if err != nil {
+		return "", err
+	}
+
+	localEdit := ContentHash(stripped) != stamp.Hash
+	upstreamChange := ContentHash(upstream) != stamp.Hash
+
+	switch {
+	case localEdit && upstreamChange:
+		return CopyConflict, nil
+	case localEdit:
+		return CopyLocallyEdited, nil
+	case upstreamChange:
+		return CopyUpstreamChanged, nil
+	default:
+		return CopyUnchanged, nil
+	}
+}
+
+// checkLocalEdits refuses to overwrite a copy of the rule that was edited in
+// the target, unless the linker is forced
+func (l *Linker) checkLocalEdits(rule *models.Rule, editor, targetPath string) error {
+	if l.Force {
+		return nil
+	}
+
+	stamp, ok := ReadStamp(targetPath)
+	if !ok || stamp.Source != l.SourceRef(rule) {
+		return nil
+	}
+
+	state, err := l.CopyStatus(rule, editor, targetPath)
+	if err != nil {
+		return nil
+	}
+	if state == CopyLocallyEdited || state == CopyConflict {
+		return fmt.Errorf("%s: %w (use --force to overwrite)", targetPath, ErrLocallyEdited)
+	}
+	return nil
+}
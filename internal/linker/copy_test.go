@@ -0,0 +1,167 @@
+package linker
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+func TestLinkRuleCopyMode(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := "---\ndescription: Go tests\nglobs: *_test.go\n---\n# Go testing\n"
+	rule := newTestRule(t, tmpDir, "go", "testing", content)
+
+	l := NewLinker(tmpDir)
+	l.SetMode(ModeCopy)
+	l.SetSourceRoot(filepath.Join(tmpDir, "repo"))
+	if err := l.LinkRule(rule, "cursor"); err != nil {
+		t.Fatalf("LinkRule failed: %v", err)
+	}
+
+	targetPath := filepath.Join(tmpDir, ".cursor", "rules", "go_testing.mdc")
+	info, err := os.Lstat(targetPath)
+	if err != nil {
+		t.Fatalf("Rule was not copied: %v", err)
+	}
+	if !info.Mode().IsRegular() {
+		t.Fatal("Expected a regular file in copy mode, got a symlink")
+	}
+
+	// The stamp goes after the frontmatter so editors still read it first
+	written := readFile(t, targetPath)
+	want := "---\ndescription: Go tests\nglobs: *_test.go\n---\n" +
+		"<!-- rule-tool:copy source=rules/go/testing.mdc sha256=" + ContentHash([]byte(content)) + " -->\n" +
+		"# Go testing\n"
+	if written != want {
+		t.Errorf("Copied rule =\n%s\nwant\n%s", written, want)
+	}
+
+	if !l.IsRuleLinked(rule, "cursor") {
+		t.Error("Expected copied rule to be reported as linked")
+	}
+	if state, err := l.CopyStatus(rule, "cursor", targetPath); err != nil || state != CopyUnchanged {
+		t.Errorf("CopyStatus = %s, %v; want %s", state, err, CopyUnchanged)
+	}
+}
+
+func TestCopyStatus(t *testing.T) {
+	testCases := []struct {
+		name       string
+		editLocal  bool
+		editSource bool
+		want       CopyState
+	}{
+		{name: "unchanged", want: CopyUnchanged},
+		{name: "upstream changed", editSource: true, want: CopyUpstreamChanged},
+		{name: "locally edited", editLocal: true, want: CopyLocallyEdited},
+		{name: "both changed", editLocal: true, editSource: true, want: CopyConflict},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			rule := newTestRule(t, tmpDir, "", "style", "# Style\n")
+
+			l := NewLinker(tmpDir)
+			l.SetMode(ModeCopy)
+			if err := l.LinkRule(rule, "cursor"); err != nil {
+				t.Fatalf("LinkRule failed: %v", err)
+			}
+			targetPath := filepath.Join(tmpDir, ".cursor", "rules", "style.mdc")
+
+			if tc.editLocal {
+				edited := readFile(t, targetPath) + "Local note.\n"
+				if err := os.WriteFile(targetPath, []byte(edited), 0644); err != nil {
+					t.Fatalf("Failed to edit copy: %v", err)
+				}
+			}
+			if tc.editSource {
+				rule = newTestRule(t, tmpDir, "", "style", "# Style\nNew upstream line.\n")
+			}
+
+			state, err := l.CopyStatus(rule, "cursor", targetPath)
+			if err != nil {
+				t.Fatalf("CopyStatus failed: %v", err)
+			}
+			if state != tc.want {
+				t.Errorf("CopyStatus = %s, want %s", state, tc.want)
+			}
+		})
+	}
+}
+
+func TestLinkRuleKeepsLocalEdits(t *testing.T) {
+	tmpDir := t.TempDir()
+	rule := newTestRule(t, tmpDir, "", "style", "# Style\n")
+
+	l := NewLinker(tmpDir)
+	l.SetMode(ModeCopy)
+	if err := l.LinkRule(rule, "cursor"); err != nil {
+		t.Fatalf("LinkRule failed: %v", err)
+	}
+
+	targetPath := filepath.Join(tmpDir, ".cursor", "rules", "style.mdc")
+	edited := readFile(t, targetPath) + "Local note.\n"
+	if err := os.WriteFile(targetPath, []byte(edited), 0644); err != nil {
+		t.Fatalf("Failed to edit copy: %v", err)
+	}
+
+	err := l.LinkRule(rule, "cursor")
+	if !errors.Is(err, ErrLocallyEdited) {
+		t.Fatalf("Expected ErrLocallyEdited, got %v", err)
+	}
+	if readFile(t, targetPath) != edited {
+		t.Error("Expected the edited copy to be left alone")
+	}
+
+	l.SetForce(true)
+	if err := l.LinkRule(rule, "cursor"); err != nil {
+		t.Fatalf("Forced LinkRule failed: %v", err)
+	}
+	if strings.Contains(readFile(t, targetPath), "Local note.") {
+		t.Error("Expected the forced link to overwrite the local edit")
+	}
+}
+
+func TestPlanTargetsDetectsForeignCopy(t *testing.T) {
+	tmpDir := t.TempDir()
+	first := newTestRule(t, tmpDir, "a", "b_c", "# First\n")
+	second := newTestRule(t, tmpDir, "a_b", "c", "# Second\n")
+
+	l := NewLinker(tmpDir)
+	l.SetMode(ModeCopy)
+	if err := l.LinkRule(first, "cursor"); err != nil {
+		t.Fatalf("LinkRule failed: %v", err)
+	}
+
+	// The copy's stamp identifies its owner, so the second rule cannot take its name
+	var collisionErr *CollisionError
+	if err := l.LinkRule(second, "cursor"); !errors.As(err, &collisionErr) {
+		t.Fatalf("Expected a CollisionError, got %v", err)
+	}
+	if l.IsRuleLinked(second, "cursor") {
+		t.Error("Expected the second rule not to be reported as linked")
+	}
+
+	// Re-linking the owner keeps the plain name
+	plan, err := l.PlanTargets([]*models.Rule{first}, "cursor")
+	if err != nil {
+		t.Fatalf("PlanTargets failed: %v", err)
+	}
+	if plan[first.Path] != "a_b_c.mdc" {
+		t.Errorf("Planned %s, want a_b_c.mdc", plan[first.Path])
+	}
+}
+
+func TestParseLinkMode(t *testing.T) {
+	if mode, err := ParseLinkMode("copy"); err != nil || mode != ModeCopy {
+		t.Errorf("ParseLinkMode(copy) = %s, %v", mode, err)
+	}
+	if _, err := ParseLinkMode("hardlink"); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+}
@@ -0,0 +1,27 @@
+package linker
+
+import (
+	"github.com/circleci/llm-agent-rules/internal/rules"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// cursorAdapter installs rules into .cursor/rules as-is. Rules are written
+// in Cursor's .mdc format already, so they are symlinked to the source.
+type cursorAdapter struct{}
+
+func (cursorAdapter) Name() string                      { return "cursor" }
+func (cursorAdapter) DisplayName() string               { return "Cursor" }
+func (cursorAdapter) TargetDir() string                 { return ".cursor/rules" }
+func (cursorAdapter) FileName(rule *models.Rule) string { return FlattenedName(rule) }
+func (cursorAdapter) FileExtension() string             { return ".mdc" }
+func (cursorAdapter) AllowsSymlinks() bool              { return true }
+
+// TransformFrontmatter keeps the original frontmatter untouched
+func (cursorAdapter) TransformFrontmatter(rule *models.Rule) (string, error) {
+	return rule.Frontmatter, nil
+}
+
+// Activate follows Cursor's rule types
+func (cursorAdapter) Activate(rule *models.Rule, file string) (rules.Activation, string) {
+	return rules.Activate(rule, file)
+}
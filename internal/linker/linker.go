@@ -6,22 +6,44 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/circleci/llm-agent-rules/internal/lockfile"
 	"github.com/circleci/llm-agent-rules/pkg/models"
 )
 
-// Linker handles creating symlinks between rules repository and target project
+// Linker handles installing rules from the rules repository into a target
+// project. How and where a rule is installed is decided by the EditorAdapter
+// registered for the chosen editor.
 type Linker struct {
-	TargetDir string
-	DryRun    bool
-	Verbose   bool
+	TargetDir         string
+	DryRun            bool
+	Verbose           bool
+	CollisionStrategy CollisionStrategy
+	Mode              LinkMode
+	// SourceRoot is the rules repository root, used to record portable
+	// source paths in the stamps of copied rules
+	SourceRoot string
+	// Sources are the rules repositories rules may come from, by ID. A rule
+	// from a known source is recorded relative to that source's root.
+	Sources map[string]models.Source
+	// Force overwrites copies that were edited in the target project
+	Force bool
+	// Vars are the values of rule template variables supplied for the
+	// target project, by variable name
+	Vars map[string]string
+
+	lock *lockfile.Lockfile
+	// tx is the transaction changes to the target project are made in
+	tx *Transaction
 }
 
 // NewLinker creates a new linker for the specified target directory
 func NewLinker(targetDir string) *Linker {
 	return &Linker{
-		TargetDir: targetDir,
-		DryRun:    false,
-		Verbose:   false,
+		TargetDir:         targetDir,
+		DryRun:            false,
+		Verbose:           false,
+		CollisionStrategy: CollisionFail,
+		Mode:              ModeSymlink,
 	}
 }
 
@@ -35,9 +57,62 @@ func (l *Linker) SetVerbose(verbose bool) {
 	l.Verbose = verbose
 }
 
+// TargetFileName returns the flat file name a rule is linked as. Rules in
+// subfolders have their topic path separators converted to underscores,
+// so topic "a/b" and name "c" become "a_b_c.mdc".
+func TargetFileName(rule *models.Rule) string {
+	return FlattenedName(rule) + filepath.Ext(rule.Path)
+}
+
+// SetCollisionStrategy sets how file name collisions between rules are handled
+func (l *Linker) SetCollisionStrategy(strategy CollisionStrategy) {
+	l.CollisionStrategy = strategy
+}
+
+// SetMode sets whether rules are symlinked or copied
+func (l *Linker) SetMode(mode LinkMode) {
+	l.Mode = mode
+}
+
+// SetSourceRoot sets the rules repository root recorded in copy stamps
+func (l *Linker) SetSourceRoot(root string) {
+	l.SourceRoot = root
+}
+
+// SetSources sets the rules repositories rules are installed from
+func (l *Linker) SetSources(sources []models.Source) {
+	l.Sources = make(map[string]models.Source, len(sources))
+	for _, source := range sources {
+		l.Sources[source.ID] = source
+	}
+}
+
+// ruleSource returns the rules repository a rule was loaded from, falling
+// back to SourceRoot for rules from an unknown source
+func (l *Linker) ruleSource(rule *models.Rule) models.Source {
+	if source, ok := l.Sources[rule.Source]; ok {
+		return source
+	}
+	return models.Source{Root: l.SourceRoot}
+}
+
+// SetForce enables or disables overwriting locally edited copies
+func (l *Linker) SetForce(force bool) {
+	l.Force = force
+}
+
+// RulesDir returns the absolute directory an editor's rules are installed into
+func (l *Linker) RulesDir(adapter EditorAdapter) string {
+	return filepath.Join(l.TargetDir, filepath.FromSlash(adapter.TargetDir()))
+}
+
 // EnsureTargetDirectory ensures the specified editor's rules directory exists in the target project
-func (l *Linker) EnsureTargetDirectory(editorFolder string) error {
-	rulesDir := filepath.Join(l.TargetDir, editorFolder, "rules")
+func (l *Linker) EnsureTargetDirectory(editor string) error {
+	adapter, err := LookupAdapter(editor)
+	if err != nil {
+		return err
+	}
+	rulesDir := l.RulesDir(adapter)
 
 	// Check if directory exists
 	if _, err := os.Stat(rulesDir); os.IsNotExist(err) {
@@ -49,7 +124,7 @@ func (l *Linker) EnsureTargetDirectory(editorFolder string) error {
 			return nil
 		}
 
-		err := os.MkdirAll(rulesDir, 0755)
+		err := l.mkdirAll(rulesDir)
 		if err != nil {
 			return fmt.Errorf("failed to create target directory: %w", err)
 		}
@@ -58,48 +133,90 @@ func (l *Linker) EnsureTargetDirectory(editorFolder string) error {
 	return nil
 }
 
-// LinkRule creates a symlink from the rule source to the target directory
-func (l *Linker) LinkRule(rule *models.Rule, editorFolder string) error {
-	// Ensure target directory exists
-	if err := l.EnsureTargetDirectory(editorFolder); err != nil {
+// LinkRule installs a rule into the editor's rules directory
+func (l *Linker) LinkRule(rule *models.Rule, editor string) error {
+	plan, err := l.PlanTargets([]*models.Rule{rule}, editor)
+	if err != nil {
 		return err
 	}
 
-	var targetFileName string
+	return l.LinkRuleAs(rule, editor, plan[rule.Path])
+}
 
-	// If the rule has a topic (is in a subfolder), convert path separators to underscores
-	if rule.Topic != "" {
-		// Convert path separators to underscores
-		topicUnderscored := strings.ReplaceAll(rule.Topic, "/", "_")
-		targetFileName = topicUnderscored + "_" + rule.Name + filepath.Ext(rule.Path)
+// LinkRuleAs installs the rule under the given file name, as computed by
+// PlanTargets. Editors that accept the source format get a symlink unless
+// the linker is in copy mode; everything else gets a stamped file rendered
+// by the editor's adapter. An existing file is replaced atomically, and is
+// restored if installing the rule fails part way.
+func (l *Linker) LinkRuleAs(rule *models.Rule, editor, targetFileName string) error {
+	return l.Transact(func() error {
+		return l.linkRuleAs(rule, editor, targetFileName)
+	})
+}
 
-		if l.Verbose {
-			fmt.Printf("Converting path separators to underscores: %s -> %s\n",
-				rule.Topic+"/"+rule.Name,
-				targetFileName)
-		}
-	} else {
-		// No topic, use the original filename
-		targetFileName = filepath.Base(rule.Path)
+// linkRuleAs installs the rule under the given file name in the active transaction
+func (l *Linker) linkRuleAs(rule *models.Rule, editor, targetFileName string) error {
+	adapter, err := LookupAdapter(editor)
+	if err != nil {
+		return err
+	}
+
+	// Templates are rendered with the project's values, so they are always copied
+	source := rule
+	rule, vars, err := l.Rendered(source, adapter.Name())
+	if err != nil {
+		return fmt.Errorf("failed to render %s: %w", RuleID(source), err)
+	}
+
+	if section, ok := adapter.(SectionAdapter); ok {
+		return l.linkToSection(section, source, rule, vars)
+	}
+
+	// Ensure target directory exists
+	if err := l.EnsureTargetDirectory(editor); err != nil {
+		return err
+	}
+
+	if rule.Topic != "" && l.Verbose {
+		fmt.Printf("Converting path separators to underscores: %s -> %s\n",
+			rule.Topic+"/"+rule.Name,
+			targetFileName)
 	}
 
 	// Set the target path in the specified editor's rules directory
-	targetPath := filepath.Join(l.TargetDir, editorFolder, "rules", targetFileName)
+	targetPath := filepath.Join(l.RulesDir(adapter), targetFileName)
 
-	// Check if the target already exists
-	if _, err := os.Stat(targetPath); err == nil {
-		// Remove existing link or file
-		if l.DryRun {
-			if l.Verbose {
-				fmt.Printf("Would remove existing: %s\n", targetPath)
-			}
-		} else {
-			if err := os.Remove(targetPath); err != nil {
-				return fmt.Errorf("failed to remove existing rule: %w", err)
-			}
+	if err := l.checkLocalEdits(source, editor, targetPath); err != nil {
+		return err
+	}
+
+	// An existing link or file, including a broken symlink, is replaced
+	if _, err := os.Lstat(targetPath); err == nil && l.DryRun && l.Verbose {
+		fmt.Printf("Would replace existing: %s\n", targetPath)
+	}
+
+	mode, installedHash := ModeSymlink, ""
+	if adapter.AllowsSymlinks() && l.Mode != ModeCopy && !source.Templated() {
+		err = l.symlinkRule(rule, targetPath)
+	} else {
+		mode = ModeCopy
+		installedHash, err = l.writeRule(adapter, rule, targetPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	if index, ok := adapter.(IndexAdapter); ok {
+		if err := l.updateIndex(index, RuleID(rule), targetFileName, true); err != nil {
+			return err
 		}
 	}
 
+	return l.recordInstall(adapter, source, adapter.TargetDir()+"/"+targetFileName, mode, installedHash, vars)
+}
+
+// symlinkRule creates a relative symlink at targetPath pointing at the rule source
+func (l *Linker) symlinkRule(rule *models.Rule, targetPath string) error {
 	// Create symlink
 	if l.DryRun {
 		if l.Verbose {
@@ -127,44 +244,110 @@ func (l *Linker) LinkRule(rule *models.Rule, editorFolder string) error {
 		fmt.Printf("Creating symlink: %s -> %s\n", symlinkPath, targetPath)
 	}
 
-	if err := os.Symlink(symlinkPath, targetPath); err != nil {
+	if err := l.symlink(symlinkPath, targetPath); err != nil {
 		return fmt.Errorf("failed to create symlink: %w", err)
 	}
 
 	return nil
 }
 
-// LinkRules creates symlinks for all provided rules
-func (l *Linker) LinkRules(rules []*models.Rule, editorFolder string) error {
-	for _, rule := range rules {
-		if err := l.LinkRule(rule, editorFolder); err != nil {
-			return err
+// writeRule writes the adapter's rendering of a rule to targetPath, stamped
+// with the rule source and content hash, and returns the hash
+func (l *Linker) writeRule(adapter EditorAdapter, rule *models.Rule, targetPath string) (string, error) {
+	content, hash, err := l.renderCopy(adapter, rule)
+	if err != nil {
+		return "", err
+	}
+
+	if l.DryRun {
+		if l.Verbose {
+			fmt.Printf("Would write %s rule: %s -> %s\n", adapter.DisplayName(), rule.Path, targetPath)
 		}
+		return hash, nil
 	}
-	return nil
+
+	if l.Verbose {
+		fmt.Printf("Writing %s rule: %s -> %s\n", adapter.DisplayName(), rule.Path, targetPath)
+	}
+
+	if err := l.writeFile(targetPath, content); err != nil {
+		return "", fmt.Errorf("failed to write rule: %w", err)
+	}
+
+	return hash, nil
 }
 
-// UnlinkRule removes a symlink for a rule
-func (l *Linker) UnlinkRule(ruleName, editorFolder string) error {
-	// Handle rules with topic paths
-	var targetFileName string
+// LinkRules installs all provided rules in one transaction. Target names are
+// planned for the whole batch first, so no rule is linked if any of them
+// would overwrite another, and if linking any rule fails the rules linked
+// before it are rolled back.
+func (l *Linker) LinkRules(rules []*models.Rule, editor string) error {
+	plan, err := l.PlanTargets(rules, editor)
+	if err != nil {
+		return err
+	}
 
-	// If the rule name includes a topic path (contains slashes)
-	if strings.Contains(ruleName, "/") {
-		// Convert slashes to underscores for the filename
-		targetFileName = strings.ReplaceAll(ruleName, "/", "_") + ".mdc"
-	} else {
-		// No path separators, might be a base name or already in underscore format
-		// Strip the extension if it was included
-		baseName := strings.TrimSuffix(ruleName, filepath.Ext(ruleName))
-		targetFileName = baseName + ".mdc"
+	return l.Transact(func() error {
+		for _, rule := range rules {
+			if err := l.linkRuleAs(rule, editor, plan[rule.Path]); err != nil {
+				return fmt.Errorf("failed to link %s: %w", RuleID(rule), err)
+			}
+		}
+		return nil
+	})
+}
+
+// UnlinkRule removes an installed rule. The rule may be given as its
+// topic/name, its plain name, or its flattened file name.
+func (l *Linker) UnlinkRule(ruleName, editor string) error {
+	return l.Transact(func() error {
+		return l.unlinkRule(ruleName, editor)
+	})
+}
+
+// unlinkRule removes an installed rule in the active transaction
+func (l *Linker) unlinkRule(ruleName, editor string) error {
+	adapter, err := LookupAdapter(editor)
+	if err != nil {
+		return err
+	}
+
+	// The lockfile records exactly what was installed and where
+	lock, err := l.Lockfile()
+	if err != nil {
+		return err
+	}
+	if entry, ok := lock.Find(adapter.Name(), strings.TrimSuffix(ruleName, adapter.FileExtension())); ok {
+		return l.unlinkEntry(adapter, entry)
+	}
+
+	// Rules installed before the lockfile existed are found by their name
+	if section, ok := adapter.(SectionAdapter); ok {
+		return l.unlinkFromSection(section, ruleName)
 	}
 
-	// Create the target path in the flat .cursor/rules directory
-	targetPath := filepath.Join(l.TargetDir, editorFolder, "rules", targetFileName)
+	rulesDir := l.RulesDir(adapter)
+	ext := adapter.FileExtension()
+
+	// Strip the extension if it was included and convert topic slashes to underscores
+	ruleID := strings.TrimSuffix(ruleName, filepath.Ext(ruleName))
+	targetFileName := strings.ReplaceAll(ruleID, "/", "_") + ext
+
+	// A rule linked under a disambiguated name owns that name exclusively,
+	// so prefer it over the plain name which may belong to another rule
+	candidates := []string{
+		filepath.Join(rulesDir, disambiguate(targetFileName, ruleID)),
+		filepath.Join(rulesDir, targetFileName),
+		// Fall back to a flat file without path conversion
+		filepath.Join(rulesDir, filepath.Base(ruleID)+ext),
+	}
+
+	for _, targetPath := range candidates {
+		// Lstat so broken symlinks can be removed too
+		if _, err := os.Lstat(targetPath); err != nil {
+			continue
+		}
 
-	// Check if the target exists
-	if _, err := os.Stat(targetPath); err == nil {
 		// Remove existing link or file
 		if l.DryRun {
 			if l.Verbose {
@@ -173,60 +356,59 @@ func (l *Linker) UnlinkRule(ruleName, editorFolder string) error {
 			return nil
 		}
 
-		if err := os.Remove(targetPath); err != nil {
+		if err := l.removeFile(targetPath); err != nil {
 			return fmt.Errorf("failed to remove rule: %w", err)
 		}
 
-		return nil
-	}
-
-	// If we didn't find the file with the converted name,
-	// try checking if it's a flat file without path conversion
-	flatPath := filepath.Join(l.TargetDir, editorFolder, "rules", filepath.Base(strings.TrimSuffix(ruleName, filepath.Ext(ruleName)))+".mdc")
-	if flatPath != targetPath {
-		if _, err := os.Stat(flatPath); err == nil {
-			if l.DryRun {
-				if l.Verbose {
-					fmt.Printf("Would remove: %s\n", flatPath)
-				}
-				return nil
-			}
-
-			if err := os.Remove(flatPath); err != nil {
-				return fmt.Errorf("failed to remove rule: %w", err)
-			}
-
-			return nil
+		if index, ok := adapter.(IndexAdapter); ok {
+			return l.updateIndex(index, ruleID, filepath.Base(targetPath), false)
 		}
+
+		return nil
 	}
 
 	return fmt.Errorf("rule %s is not linked", ruleName)
 }
 
 // IsRuleLinked checks if a rule is already linked in the target directory
-func (l *Linker) IsRuleLinked(rule *models.Rule, editorFolder string) bool {
-	var targetFileName string
-
-	// If the rule has a topic (is in a subfolder), convert path separators to underscores
-	if rule.Topic != "" {
-		// Convert path separators to underscores
-		topicUnderscored := strings.ReplaceAll(rule.Topic, "/", "_")
-		targetFileName = topicUnderscored + "_" + rule.Name + filepath.Ext(rule.Path)
-	} else {
-		// No topic, use the original filename
-		targetFileName = filepath.Base(rule.Path)
+func (l *Linker) IsRuleLinked(rule *models.Rule, editor string) bool {
+	adapter, err := LookupAdapter(editor)
+	if err != nil {
+		return false
+	}
+
+	if section, ok := adapter.(SectionAdapter); ok {
+		f, err := readManagedFile(filepath.Join(l.TargetDir, section.ManagedFile()))
+		return err == nil && f.has(RuleID(rule))
+	}
+
+	// A rule in the lockfile is linked as long as its destination exists
+	if lock, err := l.Lockfile(); err == nil {
+		if entry, ok := lock.Get(adapter.Name(), RuleID(rule)); ok {
+			_, err := os.Stat(filepath.Join(l.TargetDir, filepath.FromSlash(entry.Destination)))
+			return err == nil
+		}
 	}
 
-	// Check the target path in the .cursor/rules directory
-	targetPath := filepath.Join(l.TargetDir, editorFolder, "rules", targetFileName)
+	rulesDir := l.RulesDir(adapter)
+
+	targetPath := filepath.Join(rulesDir, adapter.FileName(rule)+adapter.FileExtension())
 
-	// Check if the target exists
+	// Check if the target exists and is a link to, or a copy of, this rule
 	if _, err := os.Stat(targetPath); err == nil {
+		// Files without a link or stamp are assumed to be this rule
+		if existing, _ := l.existingLinkOwner(targetPath, []*models.Rule{rule}); existing == "" {
+			return true
+		}
+	}
+
+	// The rule may have been linked under a disambiguated name
+	if _, err := os.Stat(filepath.Join(rulesDir, DisambiguatedFileName(adapter, rule))); err == nil {
 		return true
 	}
 
 	// Also check for the old-style flat path (for backward compatibility)
-	flatPath := filepath.Join(l.TargetDir, editorFolder, "rules", filepath.Base(rule.Path))
+	flatPath := filepath.Join(rulesDir, rule.Name+adapter.FileExtension())
 	if flatPath != targetPath {
 		if _, err := os.Stat(flatPath); err == nil {
 			return true
@@ -235,3 +417,92 @@ func (l *Linker) IsRuleLinked(rule *models.Rule, editorFolder string) bool {
 
 	return false
 }
+
+// linkToSection adds or refreshes the rule's block in the adapter's managed
+// file. rule is the source rule as rendered with vars when it is a template.
+func (l *Linker) linkToSection(adapter SectionAdapter, source, rule *models.Rule, vars map[string]string) error {
+	path := filepath.Join(l.TargetDir, adapter.ManagedFile())
+	f, err := readManagedFile(path)
+	if err != nil {
+		return err
+	}
+
+	block, err := adapter.RenderBlock(rule)
+	if err != nil {
+		return fmt.Errorf("failed to render %s for %s: %w", RuleID(rule), adapter.DisplayName(), err)
+	}
+	f.blocks[RuleID(rule)] = strings.TrimSpace(block)
+
+	if l.DryRun {
+		if l.Verbose {
+			fmt.Printf("Would update rule %s in managed section of %s\n", RuleID(rule), path)
+		}
+		return nil
+	}
+
+	if l.Verbose {
+		fmt.Printf("Updating rule %s in managed section of %s\n", RuleID(rule), path)
+	}
+
+	if err := l.saveManaged(f); err != nil {
+		return err
+	}
+	return l.recordInstall(adapter, source, adapter.ManagedFile(), ModeCopy, ContentHash([]byte(f.blocks[RuleID(rule)])), vars)
+}
+
+// unlinkFromSection removes the rule's block from the adapter's managed file
+func (l *Linker) unlinkFromSection(adapter SectionAdapter, ruleName string) error {
+	path := filepath.Join(l.TargetDir, adapter.ManagedFile())
+	f, err := readManagedFile(path)
+	if err != nil {
+		return err
+	}
+
+	id, ok := f.findBlock(strings.TrimSuffix(ruleName, filepath.Ext(ruleName)))
+	if !ok {
+		return fmt.Errorf("rule %s is not linked", ruleName)
+	}
+
+	if l.DryRun {
+		if l.Verbose {
+			fmt.Printf("Would remove rule %s from managed section of %s\n", id, path)
+		}
+		return nil
+	}
+
+	delete(f.blocks, id)
+	return l.saveManaged(f)
+}
+
+// updateIndex adds or removes the reference to an installed rule file in the
+// adapter's index file
+func (l *Linker) updateIndex(adapter IndexAdapter, ruleID, fileName string, add bool) error {
+	path := filepath.Join(l.TargetDir, adapter.IndexFile())
+	f, err := readManagedFile(path)
+	if err != nil {
+		return err
+	}
+
+	id := ruleID
+	if !add {
+		found, ok := f.findBlock(ruleID)
+		if !ok {
+			return nil
+		}
+		id = found
+	}
+
+	if l.DryRun {
+		if l.Verbose {
+			fmt.Printf("Would update reference to %s in %s\n", fileName, path)
+		}
+		return nil
+	}
+
+	if add {
+		f.blocks[id] = adapter.IndexEntry(adapter.TargetDir() + "/" + fileName)
+	} else {
+		delete(f.blocks, id)
+	}
+	return l.saveManaged(f)
+}
@@ -1,8 +1,10 @@
 package linker
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/circleci/llm-agent-rules/pkg/models"
@@ -145,3 +147,139 @@ func TestLinkRuleWithRelativePath(t *testing.T) {
 		t.Errorf("Expected symlink target to be %s, got %s", relativePath, linkTarget)
 	}
 }
+
+func TestPlanTargetsDetectsCollisions(t *testing.T) {
+	tmpDir := t.TempDir()
+	repoDir := filepath.Join(tmpDir, "repo", "rules")
+
+	newRule := func(topic, name string) *models.Rule {
+		path := filepath.Join(repoDir, topic, name+".mdc")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create rule directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("rule"), 0644); err != nil {
+			t.Fatalf("Failed to write rule: %v", err)
+		}
+		return &models.Rule{Name: name, Topic: topic, Path: path}
+	}
+
+	first := newRule("a_b", "c")
+	second := newRule("a", "b_c")
+	root := newRule("", "a_b_c")
+
+	l := NewLinker(tmpDir)
+
+	_, err := l.PlanTargets([]*models.Rule{first, second, root}, ".cursor")
+	var collisionErr *CollisionError
+	if !errors.As(err, &collisionErr) {
+		t.Fatalf("Expected CollisionError, got %v", err)
+	}
+	if len(collisionErr.Collisions) != 1 {
+		t.Fatalf("Expected 1 collision, got %+v", collisionErr.Collisions)
+	}
+	want := []string{"a/b_c", "a_b/c", "a_b_c"}
+	if got := collisionErr.Collisions[0].Rules; !reflect.DeepEqual(got, want) {
+		t.Errorf("Collision rules = %v, want %v", got, want)
+	}
+
+	// Nothing should have been linked by a failed batch
+	if err := l.LinkRules([]*models.Rule{first, second}, ".cursor"); err == nil {
+		t.Fatal("Expected LinkRules to refuse colliding rules")
+	}
+	if _, err := os.Lstat(filepath.Join(tmpDir, ".cursor", "rules", "a_b_c.mdc")); !os.IsNotExist(err) {
+		t.Errorf("Expected no link to be created, got err=%v", err)
+	}
+
+	// Linking one rule, then a colliding one separately, must not overwrite the first
+	if err := l.LinkRule(first, ".cursor"); err != nil {
+		t.Fatalf("LinkRule failed: %v", err)
+	}
+	if err := l.LinkRule(second, ".cursor"); !errors.As(err, &collisionErr) {
+		t.Fatalf("Expected CollisionError with existing link, got %v", err)
+	}
+	if !l.IsRuleLinked(first, ".cursor") || l.IsRuleLinked(second, ".cursor") {
+		t.Error("Expected only the first rule to be linked")
+	}
+
+	// The suffix strategy links the second rule under a stable disambiguated name
+	l.SetCollisionStrategy(CollisionSuffix)
+	if err := l.LinkRule(second, ".cursor"); err != nil {
+		t.Fatalf("LinkRule with suffix strategy failed: %v", err)
+	}
+	if !l.IsRuleLinked(first, ".cursor") || !l.IsRuleLinked(second, ".cursor") {
+		t.Error("Expected both rules to be linked with the suffix strategy")
+	}
+	if _, err := os.Lstat(filepath.Join(tmpDir, ".cursor", "rules", DisambiguatedFileName(cursorAdapter{}, second))); err != nil {
+		t.Errorf("Expected disambiguated link for second rule: %v", err)
+	}
+
+	if err := l.UnlinkRule("a/b_c", ".cursor"); err != nil {
+		t.Fatalf("UnlinkRule failed: %v", err)
+	}
+	if !l.IsRuleLinked(first, ".cursor") || l.IsRuleLinked(second, ".cursor") {
+		t.Error("Expected unlinking the second rule to leave the first in place")
+	}
+}
+
+func TestLinkRuleWindsurfWritesTransformedFile(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	rulePath := filepath.Join(tmpDir, "repo", "rules", "go", "style.mdc")
+	if err := os.MkdirAll(filepath.Dir(rulePath), 0755); err != nil {
+		t.Fatalf("Failed to create rule directory: %v", err)
+	}
+	content := "---\ndescription: \"Go style: formatting\"\nglobs: *.go, cmd/**/*.go\nalwaysApply: false\n---\n# Go style\n"
+	if err := os.WriteFile(rulePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write rule: %v", err)
+	}
+
+	rule, err := models.NewRule(rulePath)
+	if err != nil {
+		t.Fatalf("NewRule failed: %v", err)
+	}
+	rule.Topic = "go"
+
+	l := NewLinker(tmpDir)
+	if err := l.LinkRule(rule, "windsurf"); err != nil {
+		t.Fatalf("LinkRule failed: %v", err)
+	}
+
+	targetPath := filepath.Join(tmpDir, ".windsurf", "rules", "go_style.md")
+	info, err := os.Lstat(targetPath)
+	if err != nil {
+		t.Fatalf("Rule was not written: %v", err)
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		t.Fatal("Expected a regular file for Windsurf, got a symlink")
+	}
+
+	written, err := os.ReadFile(targetPath)
+	if err != nil {
+		t.Fatalf("Failed to read written rule: %v", err)
+	}
+	stamp, unstamped, ok := parseStamp(written)
+	if !ok {
+		t.Fatalf("Written rule has no copy stamp:\n%s", written)
+	}
+	want := "---\ntrigger: glob\nglobs: *.go, cmd/**/*.go\ndescription: 'Go style: formatting'\n---\n# Go style\n"
+	if string(unstamped) != want {
+		t.Errorf("Written rule =\n%s\nwant\n%s", unstamped, want)
+	}
+	if stamp.Hash != ContentHash([]byte(want)) {
+		t.Errorf("Stamp hash = %s, want hash of written content", stamp.Hash)
+	}
+
+	if !l.IsRuleLinked(rule, "windsurf") {
+		t.Error("Expected rule to be reported as linked for Windsurf")
+	}
+	if l.IsRuleLinked(rule, "cursor") {
+		t.Error("Expected rule not to be reported as linked for Cursor")
+	}
+
+	if err := l.UnlinkRule("go/style", "windsurf"); err != nil {
+		t.Fatalf("UnlinkRule failed: %v", err)
+	}
+	if _, err := os.Lstat(targetPath); !os.IsNotExist(err) {
+		t.Errorf("Expected rule to be removed, got err=%v", err)
+	}
+}
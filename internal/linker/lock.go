@@ -0,0 +1,268 @@
+package linker
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/circleci/llm-agent-rules/internal/lockfile"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// Lockfile returns the target project's lockfile, loading it on first use
+func (l *Linker) Lockfile() (*lockfile.Lockfile, error) {
+	if l.lock == nil {
+		lock, err := lockfile.Load(l.TargetDir)
+		if err != nil {
+			return nil, err
+		}
+		l.lock = lock
+	}
+	return l.lock, nil
+}
+
+// SourceRepo returns a rule's repository as recorded in the lockfile: its
+// git URL, or its path relative to the target project when possible
+func (l *Linker) SourceRepo(rule *models.Rule) string {
+	source := l.ruleSource(rule)
+	if source.URL != "" {
+		return source.URL
+	}
+	if source.Root == "" {
+		return ""
+	}
+	if rel, err := filepath.Rel(l.TargetDir, source.Root); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(source.Root)
+}
+
+// recordInstall adds the installed rule to the lockfile. installedHash is the
+// hash of the content written, and is empty for symlinks; vars are the values
+// a template was rendered with.
+func (l *Linker) recordInstall(adapter EditorAdapter, rule *models.Rule, destination string, mode LinkMode, installedHash string, vars map[string]string) error {
+	if l.DryRun {
+		return nil
+	}
+
+	lock, err := l.Lockfile()
+	if err != nil {
+		return err
+	}
+
+	lock.Put(lockfile.Entry{
+		ID:            RuleID(rule),
+		Source:        l.SourceRepo(rule),
+		Commit:        l.ruleSource(rule).Commit,
+		Path:          l.SourceRef(rule),
+		SourceHash:    ContentHash([]byte(rule.Content)),
+		Mode:          string(mode),
+		Format:        adapter.Name(),
+		Destination:   destination,
+		InstalledHash: installedHash,
+		Vars:          vars,
+	})
+	return l.saveLock(lock)
+}
+
+// forgetInstall removes a rule from the lockfile
+func (l *Linker) forgetInstall(format, id string) error {
+	if l.DryRun {
+		return nil
+	}
+
+	lock, err := l.Lockfile()
+	if err != nil {
+		return err
+	}
+
+	if lock.Remove(format, id) {
+		return l.saveLock(lock)
+	}
+	return nil
+}
+
+// RemoveUnrecorded removes a file that is not recorded in the lockfile, such
+// as a broken symlink or a copy of a deleted rule, given as its destination
+// relative to the target project
+func (l *Linker) RemoveUnrecorded(destination string) error {
+	targetPath := filepath.Join(l.TargetDir, filepath.FromSlash(destination))
+	if l.DryRun {
+		if l.Verbose {
+			fmt.Printf("Would remove: %s\n", targetPath)
+		}
+		return nil
+	}
+
+	return l.Transact(func() error {
+		if err := l.removeFile(targetPath); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", destination, err)
+		}
+		return nil
+	})
+}
+
+// saveLock writes the lockfile in the active transaction
+func (l *Linker) saveLock(lock *lockfile.Lockfile) error {
+	if err := l.track(lockfile.Path(l.TargetDir)); err != nil {
+		return err
+	}
+	return lock.Save()
+}
+
+// unlinkEntry removes the installed rule recorded by a lockfile entry
+func (l *Linker) unlinkEntry(adapter EditorAdapter, entry lockfile.Entry) error {
+	if section, ok := adapter.(SectionAdapter); ok {
+		f, err := readManagedFile(filepath.Join(l.TargetDir, section.ManagedFile()))
+		if err != nil {
+			return err
+		}
+		// The block may already have been removed by hand
+		if f.has(entry.ID) {
+			if err := l.unlinkFromSection(section, entry.ID); err != nil {
+				return err
+			}
+		}
+		return l.forgetInstall(entry.Format, entry.ID)
+	}
+
+	targetPath := filepath.Join(l.TargetDir, filepath.FromSlash(entry.Destination))
+
+	// Lstat so broken symlinks can be removed too
+	if _, err := os.Lstat(targetPath); err == nil {
+		if l.DryRun {
+			if l.Verbose {
+				fmt.Printf("Would remove: %s\n", targetPath)
+			}
+			return nil
+		}
+
+		if err := l.removeFile(targetPath); err != nil {
+			return fmt.Errorf("failed to remove rule: %w", err)
+		}
+	}
+
+	if index, ok := adapter.(IndexAdapter); ok {
+		if err := l.updateIndex(index, entry.ID, filepath.Base(targetPath), false); err != nil {
+			return err
+		}
+	}
+
+	return l.forgetInstall(entry.Format, entry.ID)
+}
+
+// EntryError is the failure to update one installed rule
+type EntryError struct {
+	Entry lockfile.Entry
+	Err   error
+}
+
+// Error implements error
+func (e *EntryError) Error() string {
+	return fmt.Sprintf("failed to update %s: %v", e.Entry.ID, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *EntryError) Unwrap() error {
+	return e.Err
+}
+
+// Update reinstalls every rule in the lockfile whose source or template
+// values changed or whose installed file is missing, using the mode it was
+// installed with. It returns the entries that were reinstalled. If any of
+// them cannot be, none are, and the error joins an *EntryError for each.
+func (l *Linker) Update(rules []*models.Rule) ([]lockfile.Entry, error) {
+	lock, err := l.Lockfile()
+	if err != nil {
+		return nil, err
+	}
+
+	updated := make([]lockfile.Entry, 0)
+	err = l.Transact(func() error {
+		errs := make([]error, 0)
+		for _, entry := range lock.Entries("") {
+			rule, ok := l.EntryRule(entry, rules)
+			if !ok {
+				continue
+			}
+
+			_, missing := os.Lstat(filepath.Join(l.TargetDir, filepath.FromSlash(entry.Destination)))
+			if ContentHash([]byte(rule.Content)) == entry.SourceHash && missing == nil && !l.VarsChanged(rule, entry) {
+				continue
+			}
+
+			mode := l.Mode
+			l.Mode = LinkMode(entry.Mode)
+			err := l.LinkRuleAs(rule, entry.Format, filepath.Base(filepath.FromSlash(entry.Destination)))
+			l.Mode = mode
+			if err != nil {
+				// Keep going to report every entry that cannot be updated
+				errs = append(errs, &EntryError{Entry: entry, Err: err})
+				continue
+			}
+			updated = append(updated, entry)
+		}
+		return errors.Join(errs...)
+	})
+	if err != nil {
+		// Everything updated so far was rolled back
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Prune removes installed rules whose source rule no longer exists, such as
+// rules that were deleted or renamed in the rules repository. It returns the
+// entries that were removed. If removing any of them fails, none are.
+func (l *Linker) Prune(rules []*models.Rule) ([]lockfile.Entry, error) {
+	lock, err := l.Lockfile()
+	if err != nil {
+		return nil, err
+	}
+
+	pruned := make([]lockfile.Entry, 0)
+	err = l.Transact(func() error {
+		for _, entry := range lock.Entries("") {
+			if _, ok := l.EntryRule(entry, rules); ok {
+				continue
+			}
+
+			adapter, err := LookupAdapter(entry.Format)
+			if err != nil {
+				return err
+			}
+			if err := l.unlinkEntry(adapter, entry); err != nil {
+				return fmt.Errorf("failed to prune %s: %w", entry.ID, err)
+			}
+			pruned = append(pruned, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		// Everything pruned so far was rolled back
+		return nil, err
+	}
+	return pruned, nil
+}
+
+// EntryRule returns the rule a lockfile entry was installed from. When
+// several sources have a rule with the entry's ID, the one from the source
+// recorded in the entry is returned, so a rule linked from an overridden
+// source stays pinned to it; otherwise the first match is returned, which
+// is the one taking precedence in rules.Manager.All.
+func (l *Linker) EntryRule(entry lockfile.Entry, rules []*models.Rule) (*models.Rule, bool) {
+	var found *models.Rule
+	for _, rule := range rules {
+		if RuleID(rule) != entry.ID {
+			continue
+		}
+		if entry.Source != "" && l.SourceRepo(rule) == entry.Source {
+			return rule, true
+		}
+		if found == nil {
+			found = rule
+		}
+	}
+	return found, found != nil
+}
@@ -0,0 +1,254 @@
+package linker
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/circleci/llm-agent-rules/internal/lockfile"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+func TestLinkRuleRecordsLockfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := "---\ndescription: Go tests\nglobs: *_test.go\n---\n# Go testing\n"
+	rule := newTestRule(t, tmpDir, "go", "testing", content)
+
+	l := NewLinker(filepath.Join(tmpDir, "project"))
+	if err := os.MkdirAll(l.TargetDir, 0755); err != nil {
+		t.Fatalf("Failed to create target: %v", err)
+	}
+	l.SetSourceRoot(filepath.Join(tmpDir, "repo"))
+	if err := l.LinkRule(rule, "windsurf"); err != nil {
+		t.Fatalf("LinkRule failed: %v", err)
+	}
+
+	lock, err := lockfile.Load(l.TargetDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	entry, ok := lock.Get("windsurf", "go/testing")
+	if !ok {
+		t.Fatalf("Expected a lockfile entry, got %+v", lock.Rules)
+	}
+
+	want := lockfile.Entry{
+		ID:            "go/testing",
+		Source:        "../repo",
+		Path:          "rules/go/testing.mdc",
+		SourceHash:    ContentHash([]byte(content)),
+		Mode:          "copy",
+		Format:        "windsurf",
+		Destination:   ".windsurf/rules/go_testing.md",
+		InstalledHash: entry.InstalledHash,
+	}
+	if !reflect.DeepEqual(entry, want) {
+		t.Errorf("Entry = %+v\nwant %+v", entry, want)
+	}
+	if stamp, _ := ReadStamp(filepath.Join(l.TargetDir, ".windsurf", "rules", "go_testing.md")); stamp.Hash != entry.InstalledHash {
+		t.Errorf("InstalledHash %s does not match the copy stamp %s", entry.InstalledHash, stamp.Hash)
+	}
+}
+
+func TestUnlinkRuleUsesLockfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	rule := newTestRule(t, tmpDir, "go", "testing", "# Go testing\n")
+
+	l := NewLinker(tmpDir)
+	if err := l.LinkRule(rule, "copilot"); err != nil {
+		t.Fatalf("LinkRule failed: %v", err)
+	}
+
+	// The destination is read from the lockfile rather than guessed from the
+	// editor's extension, so a plain name is enough
+	if err := l.UnlinkRule("testing", "copilot"); err != nil {
+		t.Fatalf("UnlinkRule failed: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(tmpDir, ".github", "instructions", "go_testing.instructions.md")); !os.IsNotExist(err) {
+		t.Error("Expected the installed rule to be removed")
+	}
+	if _, err := os.Stat(lockfile.Path(tmpDir)); !os.IsNotExist(err) {
+		t.Error("Expected the lockfile to be removed with its last entry")
+	}
+}
+
+func TestUpdateAndPrune(t *testing.T) {
+	tmpDir := t.TempDir()
+	kept := newTestRule(t, tmpDir, "go", "testing", "# Go testing\n")
+	removed := newTestRule(t, tmpDir, "go", "legacy", "# Legacy\n")
+
+	l := NewLinker(tmpDir)
+	l.SetMode(ModeCopy)
+	if err := l.LinkRules([]*models.Rule{kept, removed}, "cursor"); err != nil {
+		t.Fatalf("LinkRules failed: %v", err)
+	}
+
+	// Nothing changed yet
+	updated, err := l.Update([]*models.Rule{kept, removed})
+	if err != nil || len(updated) != 0 {
+		t.Fatalf("Update = %v, %v; want nothing updated", updated, err)
+	}
+
+	kept = newTestRule(t, tmpDir, "go", "testing", "# Go testing\nUse table tests.\n")
+	l.SetMode(ModeSymlink)
+	updated, err = l.Update([]*models.Rule{kept})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if len(updated) != 1 || updated[0].ID != "go/testing" {
+		t.Fatalf("Update = %+v, want go/testing", updated)
+	}
+
+	// The rule is reinstalled with the mode recorded in the lockfile
+	targetPath := filepath.Join(tmpDir, ".cursor", "rules", "go_testing.mdc")
+	if state, err := l.CopyStatus(kept, "cursor", targetPath); err != nil || state != CopyUnchanged {
+		t.Errorf("CopyStatus = %s, %v; want %s", state, err, CopyUnchanged)
+	}
+
+	pruned, err := l.Prune([]*models.Rule{kept})
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if len(pruned) != 1 || pruned[0].ID != "go/legacy" {
+		t.Fatalf("Prune = %+v, want go/legacy", pruned)
+	}
+	if _, err := os.Lstat(filepath.Join(tmpDir, ".cursor", "rules", "go_legacy.mdc")); !os.IsNotExist(err) {
+		t.Error("Expected the orphaned rule to be removed")
+	}
+	if !l.IsRuleLinked(kept, "cursor") {
+		t.Error("Expected the remaining rule to stay linked")
+	}
+}
+
+func TestLinkRuleRecordsGitSource(t *testing.T) {
+	tmpDir := t.TempDir()
+	rule := newTestRule(t, tmpDir, "go", "testing", "# Go testing\n")
+
+	l := NewLinker(tmpDir)
+	rule.Source = "org"
+	l.SetSources([]models.Source{{
+		ID:     "org",
+		Root:   filepath.Join(tmpDir, "repo"),
+		URL:    "https://github.com/org/rules.git?ref=v1",
+		Commit: "0123456789abcdef0123456789abcdef01234567",
+	}})
+	if err := l.LinkRule(rule, "cursor"); err != nil {
+		t.Fatalf("LinkRule failed: %v", err)
+	}
+
+	lock, err := lockfile.Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	entry, _ := lock.Get("cursor", "go/testing")
+	if entry.Source != "https://github.com/org/rules.git?ref=v1" || entry.Commit != "0123456789abcdef0123456789abcdef01234567" {
+		t.Errorf("Entry source = %q at %q, want the git URL and commit", entry.Source, entry.Commit)
+	}
+	if entry.Path != "rules/go/testing.mdc" {
+		t.Errorf("Path = %q, want the path within the repository", entry.Path)
+	}
+}
+
+func TestLinkTemplateRecordsVars(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := "---\ndescription: Conventions for {{ project }}\nvars:\n  project:\n  owner: platform\n---\n# {{ project }}\nAsk {{owner}} about {{ other }}.\n"
+	rule := newTestRule(t, tmpDir, "go", "conventions", content)
+	target := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(target, 0755); err != nil {
+		t.Fatalf("Failed to create target: %v", err)
+	}
+
+	l := NewLinker(target)
+	if err := l.LinkRule(rule, "cursor"); err == nil {
+		t.Fatal("Expected linking without a value for project to fail")
+	}
+
+	l.SetVars(map[string]string{"project": "billing"})
+	if err := l.LinkRule(rule, "cursor"); err != nil {
+		t.Fatalf("LinkRule failed: %v", err)
+	}
+	installedPath := filepath.Join(target, ".cursor", "rules", "go_conventions.mdc")
+	if info, err := os.Lstat(installedPath); err != nil || !info.Mode().IsRegular() {
+		t.Fatalf("Expected a template to be copied, got %v", err)
+	}
+	installed := readFile(t, installedPath)
+	for _, want := range []string{"description: Conventions for billing", "# billing\n", "Ask platform about {{ other }}."} {
+		if !strings.Contains(installed, want) {
+			t.Errorf("Installed copy does not contain %q:\n%s", want, installed)
+		}
+	}
+
+	lock, err := lockfile.Load(target)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	entry, _ := lock.Get("cursor", "go/conventions")
+	if want := map[string]string{"project": "billing", "owner": "platform"}; !reflect.DeepEqual(entry.Vars, want) || entry.Mode != "copy" {
+		t.Errorf("Entry = %+v, want a copy with vars %v", entry, want)
+	}
+	if entry.SourceHash != ContentHash([]byte(content)) {
+		t.Error("Expected the source hash of the template, not of the rendered rule")
+	}
+
+	// Values are kept from the lockfile, so a later run needs no --set
+	l = NewLinker(target)
+	if updated, err := l.Update([]*models.Rule{rule}); err != nil || len(updated) != 0 {
+		t.Fatalf("Update = %v, %v, want nothing to update", updated, err)
+	}
+
+	l.SetVars(map[string]string{"project": "payments"})
+	if !l.VarsChanged(rule, entry) {
+		t.Error("Expected new values to change the template")
+	}
+	if updated, err := l.Update([]*models.Rule{rule}); err != nil || len(updated) != 1 {
+		t.Fatalf("Update = %v, %v, want the template reinstalled", updated, err)
+	}
+	if installed := readFile(t, installedPath); !strings.Contains(installed, "# payments\n") {
+		t.Errorf("Expected the copy rendered with the new value:\n%s", installed)
+	}
+}
+
+func TestUpdateRollsBackOnFailure(t *testing.T) {
+	tmpDir := t.TempDir()
+	first := newTestRule(t, tmpDir, "", "first", "# First\n")
+	second := newTestRule(t, tmpDir, "", "second", "# Second\n")
+
+	target := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(target, 0755); err != nil {
+		t.Fatal(err)
+	}
+	l := NewLinker(target)
+	l.SetMode(ModeCopy)
+	if err := l.LinkRules([]*models.Rule{first, second}, "cursor"); err != nil {
+		t.Fatalf("LinkRules failed: %v", err)
+	}
+
+	// Both sources change, and the second copy was edited in the project
+	firstPath := filepath.Join(target, ".cursor", "rules", "first.mdc")
+	secondPath := filepath.Join(target, ".cursor", "rules", "second.mdc")
+	if err := os.WriteFile(secondPath, []byte(readFile(t, secondPath)+"Mine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	first.Content, second.Content = "# First v2\n", "# Second v2\n"
+	before := readFile(t, firstPath)
+	lockBefore := readFile(t, lockfile.Path(target))
+
+	l = NewLinker(target)
+	updated, err := l.Update([]*models.Rule{first, second})
+	var entryErr *EntryError
+	if !errors.As(err, &entryErr) || entryErr.Entry.ID != "second" || !errors.Is(err, ErrLocallyEdited) {
+		t.Fatalf("Update error = %v, want the locally edited second rule", err)
+	}
+	if len(updated) != 0 {
+		t.Errorf("Updated = %v, want nothing after a failure", updated)
+	}
+	if after := readFile(t, firstPath); after != before {
+		t.Errorf("First rule was updated despite the failure:\n%s", after)
+	}
+	if after := readFile(t, lockfile.Path(target)); after != lockBefore {
+		t.Errorf("Lockfile changed despite the failure:\n%s", after)
+	}
+}
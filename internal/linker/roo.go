@@ -0,0 +1,33 @@
+package linker
+
+import (
+	"github.com/circleci/llm-agent-rules/internal/rules"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// rooAdapter installs rules as Markdown files in .roo/rules. Roo Code loads
+// every file in that directory and ignores frontmatter, so scoped rules are
+// annotated with when they apply.
+type rooAdapter struct{}
+
+func (rooAdapter) Name() string                      { return "roo" }
+func (rooAdapter) DisplayName() string               { return "Roo Code" }
+func (rooAdapter) TargetDir() string                 { return ".roo/rules" }
+func (rooAdapter) FileName(rule *models.Rule) string { return FlattenedName(rule) }
+func (rooAdapter) FileExtension() string             { return ".md" }
+func (rooAdapter) AllowsSymlinks() bool              { return false }
+
+// TransformFrontmatter drops the frontmatter, which Roo Code does not read
+func (rooAdapter) TransformFrontmatter(rule *models.Rule) (string, error) {
+	return "", nil
+}
+
+// Activate applies every rule, as Roo Code loads the whole directory
+func (rooAdapter) Activate(rule *models.Rule, file string) (rules.Activation, string) {
+	return rules.ActivationAlways, ""
+}
+
+// RenderBody prefixes the body with the rule's scope annotation
+func (rooAdapter) RenderBody(rule *models.Rule) string {
+	return annotatedBody(rule) + "\n"
+}
@@ -0,0 +1,201 @@
+package linker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Markers delimiting the section of a shared file managed by rule-tool.
+// Everything outside the section is left exactly as the user wrote it.
+const (
+	sectionBegin    = "<!-- rule-tool:begin -->"
+	sectionEnd      = "<!-- rule-tool:end -->"
+	sectionNotice   = "<!-- Managed by rule-tool. Changes inside this section are overwritten. -->"
+	blockBeginFmt   = "<!-- rule-tool:rule %s -->"
+	blockEndFmt     = "<!-- rule-tool:end-rule %s -->"
+	blockBeginStart = "<!-- rule-tool:rule "
+	markerClose     = " -->"
+	markerPrefix    = "<!-- rule-tool:"
+)
+
+// managedFile is a file containing a rule-tool managed section made up of one
+// block per rule, keyed by rule ID
+type managedFile struct {
+	path   string
+	before string
+	after  string
+	blocks map[string]string
+	exists bool
+}
+
+// readManagedFile loads a file and parses its managed section, if any
+func readManagedFile(path string) (*managedFile, error) {
+	f := &managedFile{path: path, blocks: make(map[string]string)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	f.exists = true
+	content := string(data)
+
+	start := strings.Index(content, sectionBegin)
+	if start < 0 {
+		f.before = content
+		return f, nil
+	}
+	end := strings.Index(content[start:], sectionEnd)
+	if end < 0 {
+		return nil, fmt.Errorf("%s has a rule-tool section without a closing %s marker", path, sectionEnd)
+	}
+	end += start
+
+	f.before = content[:start]
+	f.after = strings.TrimPrefix(content[end+len(sectionEnd):], "\n")
+
+	section := content[start+len(sectionBegin) : end]
+	for {
+		open := strings.Index(section, blockBeginStart)
+		if open < 0 {
+			break
+		}
+		openEnd := strings.Index(section[open:], markerClose)
+		if openEnd < 0 {
+			return nil, fmt.Errorf("%s has a malformed rule-tool rule marker", path)
+		}
+		id := section[open+len(blockBeginStart) : open+openEnd]
+		section = section[open+openEnd+len(markerClose):]
+
+		closeMarker := fmt.Sprintf(blockEndFmt, id)
+		closeAt := strings.Index(section, closeMarker)
+		if closeAt < 0 {
+			return nil, fmt.Errorf("%s is missing the end marker for rule %s", path, id)
+		}
+		f.blocks[id] = strings.Trim(section[:closeAt], "\n")
+		section = section[closeAt+len(closeMarker):]
+	}
+
+	return f, nil
+}
+
+// has reports whether the section contains a block for id
+func (f *managedFile) has(id string) bool {
+	_, ok := f.blocks[id]
+	return ok
+}
+
+// findBlock resolves a rule reference to a block ID. The reference may be
+// the full topic/name ID, a plain rule name, or a flattened name.
+func (f *managedFile) findBlock(ruleName string) (string, bool) {
+	if f.has(ruleName) {
+		return ruleName, true
+	}
+
+	for _, id := range f.ids() {
+		if id[strings.LastIndex(id, "/")+1:] == ruleName || strings.ReplaceAll(id, "/", "_") == ruleName {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// ids returns the block IDs in sorted order
+func (f *managedFile) ids() []string {
+	ids := make([]string, 0, len(f.blocks))
+	for id := range f.blocks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// render returns the file content with the managed section regenerated.
+// Blocks are written in ID order so re-running produces identical output.
+func (f *managedFile) render() string {
+	if len(f.blocks) == 0 {
+		return joinSections(f.before, f.after)
+	}
+
+	var b strings.Builder
+	b.WriteString(sectionBegin + "\n")
+	b.WriteString(sectionNotice + "\n")
+	for _, id := range f.ids() {
+		b.WriteString("\n" + fmt.Sprintf(blockBeginFmt, id) + "\n")
+		b.WriteString(f.blocks[id] + "\n")
+		b.WriteString(fmt.Sprintf(blockEndFmt, id) + "\n")
+	}
+	b.WriteString("\n" + sectionEnd + "\n")
+
+	before := f.before
+	if before != "" && !strings.HasSuffix(before, "\n\n") {
+		before = strings.TrimRight(before, "\n") + "\n\n"
+	}
+	return before + b.String() + f.after
+}
+
+// joinSections rejoins the user content around a removed section
+func joinSections(before, after string) string {
+	if after == "" {
+		return strings.TrimRight(before, "\n") + trailingNewline(before)
+	}
+	return before + after
+}
+
+func trailingNewline(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return ""
+	}
+	return "\n"
+}
+
+// save writes the file, removing it when nothing but the section was there
+// and the section is now empty
+func (f *managedFile) save() error {
+	content := f.render()
+	if strings.TrimSpace(content) == "" {
+		if !f.exists {
+			return nil
+		}
+		if err := os.Remove(f.path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", f.path, err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", f.path, err)
+	}
+
+	if err := writeFileAtomic(f.path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", f.path, err)
+	}
+	return nil
+}
+
+// saveManaged writes a managed file in the active transaction
+func (l *Linker) saveManaged(f *managedFile) error {
+	if err := l.mkdirAll(filepath.Dir(f.path)); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", f.path, err)
+	}
+	if err := l.track(f.path); err != nil {
+		return err
+	}
+	return f.save()
+}
+
+// InstalledBlock returns the content of a rule's block in the adapter's
+// managed file, and whether the block exists
+func (l *Linker) InstalledBlock(adapter SectionAdapter, id string) (string, bool, error) {
+	f, err := readManagedFile(filepath.Join(l.TargetDir, adapter.ManagedFile()))
+	if err != nil {
+		return "", false, err
+	}
+	block, ok := f.blocks[id]
+	return block, ok, nil
+}
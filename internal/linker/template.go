@@ -0,0 +1,47 @@
+package linker
+
+import (
+	"maps"
+
+	"github.com/circleci/llm-agent-rules/internal/lockfile"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// SetVars sets the values of the template variables supplied for the target project
+func (l *Linker) SetVars(vars map[string]string) {
+	l.Vars = vars
+}
+
+// templateValues returns the values a template is rendered with for a
+// format: the values recorded in the lockfile when it was installed, so
+// values given once with --set are kept, overridden by the linker's values
+func (l *Linker) templateValues(rule *models.Rule, format string) map[string]string {
+	values := make(map[string]string)
+	if lock, err := l.Lockfile(); err == nil {
+		if entry, ok := lock.Get(format, RuleID(rule)); ok {
+			maps.Copy(values, entry.Vars)
+		}
+	}
+	maps.Copy(values, l.Vars)
+	return values
+}
+
+// Rendered returns the rule as it is installed for a format, with its
+// template variables rendered, and the values they were rendered with.
+// Rules that are not templates are returned as they are, without values.
+func (l *Linker) Rendered(rule *models.Rule, format string) (*models.Rule, map[string]string, error) {
+	if !rule.Templated() {
+		return rule, nil, nil
+	}
+	return rule.Render(l.templateValues(rule, format))
+}
+
+// VarsChanged reports whether a template installed as entry would now be
+// rendered with different values, or can no longer be rendered
+func (l *Linker) VarsChanged(rule *models.Rule, entry lockfile.Entry) bool {
+	if !rule.Templated() {
+		return false
+	}
+	_, vars, err := l.Rendered(rule, entry.Format)
+	return err != nil || !maps.Equal(vars, entry.Vars)
+}
@@ -0,0 +1,369 @@
+package linker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// JournalDir is the directory in the target project holding the journal and
+// backups of the transaction in progress. It only exists while rule-tool is
+// changing the target project, or after a run was interrupted.
+const JournalDir = ".rule-tool.txn"
+
+// journalFile is the journal within JournalDir
+const journalFile = "journal.json"
+
+// journalEntry records how to undo the change to one path
+type journalEntry struct {
+	// Path is the changed path relative to the target project
+	Path string `json:"path"`
+	// Dir reports that Path is a directory the transaction created
+	Dir bool `json:"dir,omitempty"`
+	// Existed reports whether a file was at Path before the change
+	Existed bool `json:"existed,omitempty"`
+	// Link is the target of the symlink that was at Path
+	Link string `json:"link,omitempty"`
+	// Backup is the copy of the file that was at Path, within JournalDir
+	Backup string `json:"backup,omitempty"`
+	// Perm is the permission bits of the file that was at Path
+	Perm os.FileMode `json:"perm,omitempty"`
+}
+
+// journal is the record of every path a transaction changed, in order
+type journal struct {
+	Entries []journalEntry `json:"entries"`
+}
+
+// Transaction tracks the files a batch of link and unlink operations
+// changes in a target project. Before a path is first changed, its previous
+// state is backed up and recorded in a journal on disk, so the batch can be
+// rolled back when a step fails, or on the next run when it was interrupted.
+type Transaction struct {
+	targetDir string
+	journal   journal
+	tracked   map[string]bool
+}
+
+// beginTransaction starts a transaction in targetDir. It fails when the
+// journal of another transaction exists, which is either still running or
+// was interrupted and needs to be recovered first.
+func beginTransaction(targetDir string) (*Transaction, error) {
+	dir := filepath.Join(targetDir, JournalDir)
+	if err := os.Mkdir(dir, 0755); err != nil {
+		if os.IsExist(err) {
+			return nil, fmt.Errorf("%s exists: another rule-tool run is changing %s, or one was interrupted and needs to be recovered", dir, targetDir)
+		}
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	tx := &Transaction{targetDir: targetDir, journal: journal{Entries: make([]journalEntry, 0)}, tracked: make(map[string]bool)}
+	if err := tx.save(); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return tx, nil
+}
+
+// dir returns the transaction's journal directory
+func (tx *Transaction) dir() string {
+	return filepath.Join(tx.targetDir, JournalDir)
+}
+
+// save writes the journal, replacing the previous one atomically
+func (tx *Transaction) save() error {
+	data, err := json.MarshalIndent(tx.journal, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode journal: %w", err)
+	}
+	return writeFileAtomic(filepath.Join(tx.dir(), journalFile), data, 0644)
+}
+
+// track backs up path and records it in the journal, unless it already was
+func (tx *Transaction) track(path string) error {
+	rel, err := filepath.Rel(tx.targetDir, path)
+	if err != nil {
+		return fmt.Errorf("failed to track %s: %w", path, err)
+	}
+	if tx.tracked[rel] {
+		return nil
+	}
+
+	entry := journalEntry{Path: filepath.ToSlash(rel)}
+	info, err := os.Lstat(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	case info.Mode()&os.ModeSymlink != 0:
+		entry.Existed = true
+		if entry.Link, err = os.Readlink(path); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+	case info.Mode().IsRegular():
+		entry.Existed = true
+		entry.Perm = info.Mode().Perm()
+		entry.Backup = strconv.Itoa(len(tx.journal.Entries))
+		if err := copyFile(path, filepath.Join(tx.dir(), entry.Backup)); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("cannot replace %s: not a file or symlink", path)
+	}
+
+	tx.journal.Entries = append(tx.journal.Entries, entry)
+	if err := tx.save(); err != nil {
+		return err
+	}
+	tx.tracked[rel] = true
+	return nil
+}
+
+// mkdirAll creates dir and any missing parents, recording the ones it
+// created so rolling back removes them again
+func (tx *Transaction) mkdirAll(dir string) error {
+	missing := make([]string, 0)
+	for path := dir; ; path = filepath.Dir(path) {
+		if _, err := os.Lstat(path); err == nil || path == filepath.Dir(path) {
+			break
+		}
+		missing = append(missing, path)
+	}
+
+	for i := len(missing) - 1; i >= 0; i-- {
+		rel, err := filepath.Rel(tx.targetDir, missing[i])
+		if err != nil {
+			return fmt.Errorf("failed to track %s: %w", missing[i], err)
+		}
+		tx.journal.Entries = append(tx.journal.Entries, journalEntry{Path: filepath.ToSlash(rel), Dir: true})
+		if err := tx.save(); err != nil {
+			return err
+		}
+		if err := os.Mkdir(missing[i], 0755); err != nil && !os.IsExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// commit keeps the changes. Removing the journal is the point the
+// transaction is committed; the backups are removed after.
+func (tx *Transaction) commit() error {
+	if err := os.Remove(filepath.Join(tx.dir(), journalFile)); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	if err := os.RemoveAll(tx.dir()); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", tx.dir(), err)
+	}
+	return nil
+}
+
+// rollback restores every tracked path to its state before the
+// transaction, in reverse order, and removes the journal
+func (tx *Transaction) rollback() error {
+	errs := make([]error, 0)
+	for i := len(tx.journal.Entries) - 1; i >= 0; i-- {
+		if err := tx.undo(tx.journal.Entries[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		// Keep the journal and backups so the rollback can be retried
+		return errors.Join(errs...)
+	}
+	if err := os.RemoveAll(tx.dir()); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", tx.dir(), err)
+	}
+	return nil
+}
+
+// undo restores one path
+func (tx *Transaction) undo(entry journalEntry) error {
+	path := filepath.Join(tx.targetDir, filepath.FromSlash(entry.Path))
+	switch {
+	case entry.Dir:
+		// Only empty directories are removed; anything else in them was not ours
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) && !isNotEmpty(path) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	case !entry.Existed:
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	case entry.Link != "":
+		if err := symlinkAtomic(entry.Link, path); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", path, err)
+		}
+	default:
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", path, err)
+		}
+		if err := copyFile(filepath.Join(tx.dir(), entry.Backup), path); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", path, err)
+		}
+		if err := os.Chmod(path, entry.Perm); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// Transact runs fn as a transaction: if fn fails, every change it made to
+// the target project is rolled back before its error is returned. Calls
+// nested in fn join the outer transaction. Dry runs change nothing, so they
+// run without one.
+func (l *Linker) Transact(fn func() error) error {
+	if l.tx != nil || l.DryRun {
+		return fn()
+	}
+
+	tx, err := beginTransaction(l.TargetDir)
+	if err != nil {
+		return err
+	}
+	l.tx = tx
+	err = fn()
+	l.tx = nil
+
+	if err != nil {
+		// The lockfile on disk is restored, so reload it on next use
+		l.lock = nil
+		if rollbackErr := tx.rollback(); rollbackErr != nil {
+			return errors.Join(err, fmt.Errorf("failed to roll back: %w", rollbackErr))
+		}
+		return err
+	}
+	return tx.commit()
+}
+
+// Recover rolls back the changes of a transaction that was interrupted in
+// the target project, such as by a crash or Ctrl-C, and returns the number
+// of paths it restored. Without such a transaction it does nothing.
+func (l *Linker) Recover() (int, error) {
+	dir := filepath.Join(l.TargetDir, JournalDir)
+	data, err := os.ReadFile(filepath.Join(dir, journalFile))
+	if os.IsNotExist(err) {
+		if _, statErr := os.Stat(dir); statErr == nil {
+			// Interrupted before the journal was written, or after the commit
+			return 0, os.RemoveAll(dir)
+		}
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	tx := &Transaction{targetDir: l.TargetDir}
+	if err := json.Unmarshal(data, &tx.journal); err != nil {
+		return 0, fmt.Errorf("failed to parse journal %s: %w", filepath.Join(dir, journalFile), err)
+	}
+	if err := tx.rollback(); err != nil {
+		return 0, fmt.Errorf("failed to recover interrupted run: %w", err)
+	}
+	l.lock = nil
+	return len(tx.journal.Entries), nil
+}
+
+// track records path in the active transaction before it is changed
+func (l *Linker) track(path string) error {
+	if l.tx == nil {
+		return nil
+	}
+	return l.tx.track(path)
+}
+
+// removeFile removes a file or symlink in the target project
+func (l *Linker) removeFile(path string) error {
+	if err := l.track(path); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// writeFile replaces a file in the target project atomically
+func (l *Linker) writeFile(path string, data []byte) error {
+	if err := l.track(path); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0644)
+}
+
+// symlink replaces path in the target project with a symlink to oldname atomically
+func (l *Linker) symlink(oldname, path string) error {
+	if err := l.track(path); err != nil {
+		return err
+	}
+	return symlinkAtomic(oldname, path)
+}
+
+// mkdirAll creates a directory in the target project with any missing parents
+func (l *Linker) mkdirAll(dir string) error {
+	if l.tx == nil {
+		return os.MkdirAll(dir, 0755)
+	}
+	return l.tx.mkdirAll(dir)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it over path, so path holds either the old or the new content
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// symlinkAtomic creates a symlink to oldname under a temporary name next to
+// path and renames it over path, so an existing file is never removed first
+func symlinkAtomic(oldname, path string) error {
+	for i := 0; ; i++ {
+		tmp := filepath.Join(filepath.Dir(path), fmt.Sprintf(".%s.tmp-%d-%d", filepath.Base(path), os.Getpid(), i))
+		err := os.Symlink(oldname, tmp)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+		return nil
+	}
+}
+
+// copyFile copies the content of a regular file
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(dst, data, 0644)
+}
+
+// isNotEmpty reports whether path is a directory with entries in it
+func isNotEmpty(path string) bool {
+	entries, err := os.ReadDir(path)
+	return err == nil && len(entries) > 0
+}
@@ -0,0 +1,133 @@
+package linker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/circleci/llm-agent-rules/internal/lockfile"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+func TestLinkRulesRollsBackOnFailure(t *testing.T) {
+	tmpDir := t.TempDir()
+	first := newTestRule(t, tmpDir, "", "first", "# first\n")
+	second := newTestRule(t, tmpDir, "", "second", "# second\n")
+
+	// A file the user wrote is in the way of the first rule
+	rulesDir := filepath.Join(tmpDir, ".cursor", "rules")
+	if err := os.MkdirAll(rulesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	existing := filepath.Join(rulesDir, "first.mdc")
+	if err := os.WriteFile(existing, []byte("mine"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// The second rule cannot replace a non-empty directory
+	blocker := filepath.Join(rulesDir, "second.mdc")
+	if err := os.MkdirAll(filepath.Join(blocker, "keep"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	l := NewLinker(tmpDir)
+	l.SetForce(true)
+	if err := l.LinkRules([]*models.Rule{first, second}, "cursor"); err == nil {
+		t.Fatal("LinkRules succeeded, want an error for the second rule")
+	}
+
+	data, err := os.ReadFile(existing)
+	if err != nil || string(data) != "mine" {
+		t.Errorf("Existing file = %q (%v), want it restored", data, err)
+	}
+	if info, err := os.Lstat(existing); err != nil || info.Mode()&os.ModeSymlink != 0 || info.Mode().Perm() != 0600 {
+		t.Errorf("Existing file was not restored as a regular file with its permissions: %v %v", info, err)
+	}
+	if _, err := os.Stat(lockfile.Path(tmpDir)); !os.IsNotExist(err) {
+		t.Errorf("Lockfile exists after rollback: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, JournalDir)); !os.IsNotExist(err) {
+		t.Errorf("Journal exists after rollback: %v", err)
+	}
+}
+
+func TestTransactionRollbackRemovesCreatedPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	rule := newTestRule(t, tmpDir, "", "rule", "# rule\n")
+
+	l := NewLinker(tmpDir)
+	err := l.Transact(func() error {
+		if err := l.LinkRule(rule, "cursor"); err != nil {
+			return err
+		}
+		return os.ErrInvalid
+	})
+	if err != os.ErrInvalid {
+		t.Fatalf("Transact returned %v, want the error of fn", err)
+	}
+
+	if _, err := os.Lstat(filepath.Join(tmpDir, ".cursor")); !os.IsNotExist(err) {
+		t.Errorf("Created directory exists after rollback: %v", err)
+	}
+
+	// A symlink replaced by a copy is restored
+	if err := l.LinkRule(rule, "cursor"); err != nil {
+		t.Fatalf("LinkRule failed: %v", err)
+	}
+	linkPath := filepath.Join(tmpDir, ".cursor", "rules", "rule.mdc")
+	before, err := os.Readlink(linkPath)
+	if err != nil {
+		t.Fatalf("Rule was not linked: %v", err)
+	}
+	l.SetMode(ModeCopy)
+	err = l.Transact(func() error {
+		if err := l.LinkRule(rule, "cursor"); err != nil {
+			return err
+		}
+		return os.ErrInvalid
+	})
+	if err != os.ErrInvalid {
+		t.Fatalf("Transact returned %v, want the error of fn", err)
+	}
+	if target, err := os.Readlink(linkPath); err != nil || target != before {
+		t.Errorf("Symlink points to %q (%v), want %q restored", target, err, before)
+	}
+}
+
+func TestRecoverInterruptedTransaction(t *testing.T) {
+	tmpDir := t.TempDir()
+	rule := newTestRule(t, tmpDir, "", "rule", "# rule\n")
+
+	// Simulate a run that stopped after linking but before committing
+	l := NewLinker(tmpDir)
+	tx, err := beginTransaction(tmpDir)
+	if err != nil {
+		t.Fatalf("beginTransaction failed: %v", err)
+	}
+	l.tx = tx
+	if err := l.LinkRule(rule, "cursor"); err != nil {
+		t.Fatalf("LinkRule failed: %v", err)
+	}
+	l.tx = nil
+
+	if _, err := beginTransaction(tmpDir); err == nil {
+		t.Error("beginTransaction succeeded while a journal exists")
+	}
+
+	restored, err := NewLinker(tmpDir).Recover()
+	if err != nil {
+		t.Fatalf("Recover failed: %v", err)
+	}
+	if restored == 0 {
+		t.Error("Recover restored nothing")
+	}
+	for _, path := range []string{".cursor", lockfile.FileName, JournalDir} {
+		if _, err := os.Lstat(filepath.Join(tmpDir, path)); !os.IsNotExist(err) {
+			t.Errorf("%s exists after recovery: %v", path, err)
+		}
+	}
+
+	// Without a journal there is nothing to recover
+	if restored, err := NewLinker(tmpDir).Recover(); err != nil || restored != 0 {
+		t.Errorf("Recover = %d, %v; want 0, nil", restored, err)
+	}
+}
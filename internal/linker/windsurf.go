@@ -0,0 +1,56 @@
+package linker
+
+import (
+	"strings"
+
+	"github.com/circleci/llm-agent-rules/internal/rules"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// Windsurf activation modes, set by the trigger frontmatter key
+const (
+	windsurfAlwaysOn      = "always_on"
+	windsurfGlob          = "glob"
+	windsurfModelDecision = "model_decision"
+	windsurfManual        = "manual"
+)
+
+// windsurfAdapter installs rules into .windsurf/rules as Markdown files with
+// Windsurf's trigger frontmatter. Windsurf does not read .mdc frontmatter, so
+// rules are written as transformed files rather than symlinked.
+type windsurfAdapter struct{}
+
+func (windsurfAdapter) Name() string                      { return "windsurf" }
+func (windsurfAdapter) DisplayName() string               { return "Windsurf" }
+func (windsurfAdapter) TargetDir() string                 { return ".windsurf/rules" }
+func (windsurfAdapter) FileName(rule *models.Rule) string { return FlattenedName(rule) }
+func (windsurfAdapter) FileExtension() string             { return ".md" }
+func (windsurfAdapter) AllowsSymlinks() bool              { return false }
+
+// TransformFrontmatter maps Cursor's alwaysApply, globs and description onto
+// Windsurf's always_on, glob and model_decision triggers. Rules with none of
+// these are only applied when mentioned manually.
+func (windsurfAdapter) TransformFrontmatter(rule *models.Rule) (string, error) {
+	trigger := windsurfManual
+	switch {
+	case rule.AlwaysApply:
+		trigger = windsurfAlwaysOn
+	case len(rule.Globs) > 0:
+		trigger = windsurfGlob
+	case rule.Description != "":
+		trigger = windsurfModelDecision
+	}
+
+	fields := []frontmatterField{{Key: "trigger", Value: trigger, Bare: true}}
+	if trigger == windsurfGlob {
+		fields = append(fields, frontmatterField{Key: "globs", Value: strings.Join(rule.Globs, ", "), Bare: true})
+	}
+	fields = append(fields, frontmatterField{Key: "description", Value: rule.Description})
+
+	return formatFrontmatter(fields...), nil
+}
+
+// Activate follows the triggers, which match Cursor's rule types
+func (windsurfAdapter) Activate(rule *models.Rule, file string) (rules.Activation, string) {
+	return rules.Activate(rule, file)
+}
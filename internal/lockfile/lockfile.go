@@ -0,0 +1,178 @@
+package lockfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FileName is the name of the lockfile written to the root of the target project
+const FileName = ".rule-tool.lock"
+
+// Version is the lockfile format version written by this tool
+const Version = 1
+
+// Entry records one rule installed into the target project for one format
+type Entry struct {
+	// ID is the topic/name identifier of the rule
+	ID string `json:"id"`
+	// Source is the rules repository the rule was installed from: a git URL,
+	// or a path relative to the target project when possible so the lockfile
+	// can be committed
+	Source string `json:"source,omitempty"`
+	// Commit is the commit SHA of a git rules repository at install time
+	Commit string `json:"commit,omitempty"`
+	// Path is the rule file relative to the rules repository
+	Path string `json:"path"`
+	// SourceHash is the SHA-256 of the rule file when it was installed
+	SourceHash string `json:"sourceHash"`
+	// Mode is how the rule was installed: symlink or copy
+	Mode string `json:"mode"`
+	// Format is the name of the editor adapter the rule was installed for
+	Format string `json:"format"`
+	// Destination is the installed file relative to the target project
+	Destination string `json:"destination"`
+	// InstalledHash is the SHA-256 of the content written for copies
+	InstalledHash string `json:"installedHash,omitempty"`
+	// Vars are the values the template variables of the rule were rendered with
+	Vars map[string]string `json:"vars,omitempty"`
+}
+
+// Lockfile is the manifest of every rule installed into a target project
+type Lockfile struct {
+	Version int     `json:"version"`
+	Rules   []Entry `json:"rules"`
+
+	path string
+}
+
+// Path returns the lockfile path for a target project
+func Path(targetDir string) string {
+	return filepath.Join(targetDir, FileName)
+}
+
+// Load reads the lockfile of a target project. A missing lockfile yields an
+// empty one that is created on the first Save.
+func Load(targetDir string) (*Lockfile, error) {
+	lock := &Lockfile{Version: Version, Rules: make([]Entry, 0), path: Path(targetDir)}
+
+	data, err := os.ReadFile(lock.path)
+	if os.IsNotExist(err) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", lock.path, err)
+	}
+
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", lock.path, err)
+	}
+	if lock.Version > Version {
+		return nil, fmt.Errorf("%s has version %d, this rule-tool supports up to %d", lock.path, lock.Version, Version)
+	}
+	if lock.Rules == nil {
+		lock.Rules = make([]Entry, 0)
+	}
+	return lock, nil
+}
+
+// Save writes the lockfile with entries in a stable order so it diffs
+// cleanly. The lockfile is removed once no rules are installed.
+func (l *Lockfile) Save() error {
+	if len(l.Rules) == 0 {
+		if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", l.path, err)
+		}
+		return nil
+	}
+
+	sort.Slice(l.Rules, func(i, j int) bool {
+		if l.Rules[i].Format != l.Rules[j].Format {
+			return l.Rules[i].Format < l.Rules[j].Format
+		}
+		return l.Rules[i].ID < l.Rules[j].ID
+	})
+	l.Version = Version
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode lockfile: %w", err)
+	}
+	// Write a temporary file and rename it, so the lockfile is never left half written
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", l.path, err)
+	}
+	if err := os.Rename(tmp, l.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", l.path, err)
+	}
+	return nil
+}
+
+// Get returns the entry for the rule with the given topic/name ID installed for format
+func (l *Lockfile) Get(format, id string) (Entry, bool) {
+	for _, entry := range l.Rules {
+		if entry.Format == format && entry.ID == id {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Find returns the entry for a rule installed for format. The rule may be
+// given as its topic/name ID, its plain name, its flattened name, or the
+// base name of its destination.
+func (l *Lockfile) Find(format, ruleName string) (Entry, bool) {
+	if entry, ok := l.Get(format, ruleName); ok {
+		return entry, true
+	}
+
+	for _, entry := range l.Entries(format) {
+		base := filepath.Base(filepath.FromSlash(entry.Destination))
+		if entry.ID[strings.LastIndex(entry.ID, "/")+1:] == ruleName ||
+			strings.ReplaceAll(entry.ID, "/", "_") == ruleName ||
+			base == ruleName ||
+			strings.TrimSuffix(base, filepath.Ext(base)) == ruleName {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Entries returns the entries installed for format, or every entry when
+// format is empty
+func (l *Lockfile) Entries(format string) []Entry {
+	entries := make([]Entry, 0, len(l.Rules))
+	for _, entry := range l.Rules {
+		if format == "" || entry.Format == format {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Put adds an entry, replacing any entry for the same rule and format
+func (l *Lockfile) Put(entry Entry) {
+	for i, existing := range l.Rules {
+		if existing.Format == entry.Format && existing.ID == entry.ID {
+			l.Rules[i] = entry
+			return
+		}
+	}
+	l.Rules = append(l.Rules, entry)
+}
+
+// Remove deletes the entry for a rule and format, reporting whether it existed
+func (l *Lockfile) Remove(format, id string) bool {
+	for i, existing := range l.Rules {
+		if existing.Format == format && existing.ID == id {
+			l.Rules = append(l.Rules[:i], l.Rules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package lockfile
+
+import (
+	"os"
+	"testing"
+)
+
+func TestLoadMissingLockfile(t *testing.T) {
+	lock, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(lock.Rules) != 0 {
+		t.Errorf("Expected no entries, got %d", len(lock.Rules))
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	lock, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	lock.Put(Entry{ID: "go/testing", Format: "cursor", Mode: "symlink", Destination: ".cursor/rules/go_testing.mdc"})
+	lock.Put(Entry{ID: "style", Format: "claude", Mode: "copy", Destination: "CLAUDE.md"})
+	lock.Put(Entry{ID: "go/testing", Format: "cursor", Mode: "copy", Destination: ".cursor/rules/go_testing.mdc"})
+	if err := lock.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	reloaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(reloaded.Rules) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(reloaded.Rules))
+	}
+	// Entries are sorted by format, then ID
+	if reloaded.Rules[0].Format != "claude" {
+		t.Errorf("Expected claude entry first, got %s", reloaded.Rules[0].Format)
+	}
+	if entry, ok := reloaded.Get("cursor", "go/testing"); !ok || entry.Mode != "copy" {
+		t.Errorf("Expected Put to replace the cursor entry, got %+v", entry)
+	}
+
+	// Removing the last entries removes the file
+	reloaded.Remove("cursor", "go/testing")
+	reloaded.Remove("claude", "style")
+	if err := reloaded.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := os.Stat(Path(dir)); !os.IsNotExist(err) {
+		t.Error("Expected an empty lockfile to be removed")
+	}
+}
+
+func TestFind(t *testing.T) {
+	lock := &Lockfile{}
+	lock.Put(Entry{ID: "go/testing", Format: "cursor", Destination: ".cursor/rules/go_testing.mdc"})
+
+	for _, name := range []string{"go/testing", "testing", "go_testing", "go_testing.mdc"} {
+		if _, ok := lock.Find("cursor", name); !ok {
+			t.Errorf("Find(%q) did not match", name)
+		}
+	}
+	if _, ok := lock.Find("windsurf", "go/testing"); ok {
+		t.Error("Expected Find to only match entries for the given format")
+	}
+}
+
+func TestLoadRejectsNewerVersion(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(Path(dir), []byte(`{"version": 99, "rules": []}`), 0644); err != nil {
+		t.Fatalf("Failed to write lockfile: %v", err)
+	}
+	if _, err := Load(dir); err == nil {
+		t.Error("Expected an error for a newer lockfile version")
+	}
+}
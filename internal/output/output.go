@@ -0,0 +1,197 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// Output formats supported by Writer
+const (
+	FormatText   = "text"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+// Formats lists every supported output format
+var Formats = []string{FormatText, FormatJSON, FormatNDJSON}
+
+// Writer emits the records a command produces. In json format the records
+// are collected into a single document written by Close; in ndjson format
+// each record is written as one line as soon as it is produced. In text
+// format records are dropped and commands print their usual output.
+type Writer struct {
+	w       io.Writer
+	format  string
+	key     string
+	records []interface{}
+}
+
+// NewWriter creates a writer for format. The json document holds the records
+// in an array under key, or the only record itself when key is empty.
+func NewWriter(w io.Writer, format, key string) (*Writer, error) {
+	switch format {
+	case "":
+		format = FormatText
+	case FormatText, FormatJSON, FormatNDJSON:
+	default:
+		return nil, fmt.Errorf("unknown output format %q (expected one of: %s)", format, strings.Join(Formats, ", "))
+	}
+	return &Writer{w: w, format: format, key: key, records: make([]interface{}, 0)}, nil
+}
+
+// Structured reports whether records are written instead of text
+func (o *Writer) Structured() bool {
+	return o.format != FormatText
+}
+
+// Record writes or collects one record
+func (o *Writer) Record(record interface{}) error {
+	switch o.format {
+	case FormatNDJSON:
+		return json.NewEncoder(o.w).Encode(record)
+	case FormatJSON:
+		o.records = append(o.records, record)
+	}
+	return nil
+}
+
+// Close writes the json document
+func (o *Writer) Close() error {
+	if o.format != FormatJSON {
+		return nil
+	}
+
+	var document interface{} = map[string]interface{}{o.key: o.records}
+	if o.key == "" {
+		document = nil
+		if len(o.records) > 0 {
+			document = o.records[0]
+		}
+	}
+	encoder := json.NewEncoder(o.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+// Rule is the structured record of a rule
+type Rule struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Topic       string   `json:"topic,omitempty"`
+	Source      string   `json:"source,omitempty"`
+	Description string   `json:"description"`
+	Globs       []string `json:"globs"`
+	AlwaysApply bool     `json:"alwaysApply"`
+	Tags        []string `json:"tags"`
+	Requires    []string `json:"requires,omitempty"`
+	Conflicts   []string `json:"conflicts,omitempty"`
+	Path        string   `json:"path"`
+	// Installed reports whether the rule is installed for Format
+	Installed  bool     `json:"installed"`
+	Format     string   `json:"format,omitempty"`
+	Overrides  []string `json:"overrides,omitempty"`
+	ShadowedBy string   `json:"shadowedBy,omitempty"`
+	// Vars are the template variables, only included when showing a single rule
+	Vars []string `json:"vars,omitempty"`
+	// Content is the rule body, only included when showing a single rule
+	Content string `json:"content,omitempty"`
+}
+
+// NewRule creates the record of a rule installed, or not, for format
+func NewRule(rule *models.Rule, id, format string, installed bool) Rule {
+	record := Rule{
+		ID:          id,
+		Name:        rule.Name,
+		Topic:       rule.Topic,
+		Source:      rule.Source,
+		Description: rule.Description,
+		Globs:       rule.Globs,
+		AlwaysApply: rule.AlwaysApply,
+		Tags:        rule.Tags,
+		Requires:    rule.Requires,
+		Conflicts:   rule.Conflicts,
+		Path:        rule.Path,
+		Installed:   installed,
+		Format:      format,
+		Overrides:   rule.Overrides,
+		ShadowedBy:  rule.ShadowedBy,
+	}
+	if record.Globs == nil {
+		record.Globs = []string{}
+	}
+	if record.Tags == nil {
+		record.Tags = []string{}
+	}
+	return record
+}
+
+// Result is the structured record of one operation on an installed rule
+type Result struct {
+	Operation   string `json:"operation"`
+	Rule        string `json:"rule"`
+	Format      string `json:"format,omitempty"`
+	Destination string `json:"destination,omitempty"`
+	DryRun      bool   `json:"dryRun,omitempty"`
+	OK          bool   `json:"ok"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Failed returns the record of a failed operation
+func Failed(operation, rule, format string, err error) Result {
+	return Result{Operation: operation, Rule: rule, Format: format, Error: err.Error()}
+}
+
+// Setting is the structured record of a configuration setting or file
+type Setting struct {
+	Key    string   `json:"key,omitempty"`
+	Values []string `json:"values,omitempty"`
+	Origin string   `json:"origin,omitempty"`
+	Path   string   `json:"path,omitempty"`
+}
+
+// Diff is the structured record of how an installed rule differs from its source
+type Diff struct {
+	Rule        string `json:"rule"`
+	Format      string `json:"format"`
+	Destination string `json:"destination,omitempty"`
+	Mode        string `json:"mode,omitempty"`
+	Changed     bool   `json:"changed"`
+	// Diff is the unified diff from the installed rule to its source
+	Diff  string `json:"diff,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Recommendation is the structured record of a rule recommended for the
+// files of the target project
+type Recommendation struct {
+	Rule string `json:"rule"`
+	// Files is the number of project files the rule applies to
+	Files int `json:"files"`
+	// Globs are the rule's globs that matched
+	Globs []string `json:"globs"`
+	// Examples are some of the matching files
+	Examples  []string `json:"examples"`
+	Installed bool     `json:"installed"`
+	Format    string   `json:"format"`
+	// Linked reports whether --link installed the rule
+	Linked bool `json:"linked,omitempty"`
+}
+
+// Explanation is the structured record of how an installed rule applies to a file
+type Explanation struct {
+	Rule        string `json:"rule"`
+	File        string `json:"file"`
+	Format      string `json:"format"`
+	Description string `json:"description,omitempty"`
+	// Activation is always, glob, agent-requested, manual or no-match
+	Activation string `json:"activation"`
+	// Glob is the rule glob that matched the file
+	Glob    string `json:"glob,omitempty"`
+	Applies bool   `json:"applies"`
+	// Why describes the activation, as in the WHY column of the table
+	Why string `json:"why"`
+}
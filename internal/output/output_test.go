@@ -0,0 +1,112 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+func TestWriterFormats(t *testing.T) {
+	results := []Result{
+		{Operation: "link", Rule: "go/testing", Format: "cursor", Destination: ".cursor/rules/go_testing.mdc", OK: true},
+		Failed("link", "missing", "cursor", errors.New("rule not found")),
+	}
+
+	testCases := []struct {
+		format string
+		want   string
+	}{
+		{format: FormatText, want: ""},
+		{format: FormatNDJSON, want: `{"operation":"link","rule":"go/testing","format":"cursor","destination":".cursor/rules/go_testing.mdc","ok":true}` + "\n" +
+			`{"operation":"link","rule":"missing","format":"cursor","ok":false,"error":"rule not found"}` + "\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			out, err := NewWriter(&buf, tc.format, "results")
+			if err != nil {
+				t.Fatalf("NewWriter failed: %v", err)
+			}
+			for _, result := range results {
+				if err := out.Record(result); err != nil {
+					t.Fatalf("Record failed: %v", err)
+				}
+			}
+			if err := out.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+			if buf.String() != tc.want {
+				t.Errorf("Output =\n%s\nwant\n%s", buf.String(), tc.want)
+			}
+		})
+	}
+
+	if _, err := NewWriter(&bytes.Buffer{}, "xml", "results"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestWriterJSONDocument(t *testing.T) {
+	var buf bytes.Buffer
+	out, _ := NewWriter(&buf, FormatJSON, "results")
+	if !out.Structured() {
+		t.Error("JSON output is not structured")
+	}
+	out.Record(Result{Operation: "unlink", Rule: "style", OK: true})
+	if buf.Len() != 0 {
+		t.Errorf("Record wrote before Close: %s", buf.String())
+	}
+	if err := out.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	var document struct {
+		Results []Result `json:"results"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &document); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	if len(document.Results) != 1 || document.Results[0].Rule != "style" {
+		t.Errorf("Decoded %+v", document)
+	}
+
+	// Without records, the array is empty rather than null
+	buf.Reset()
+	out, _ = NewWriter(&buf, FormatJSON, "results")
+	out.Close()
+	if !strings.Contains(buf.String(), `"results": []`) {
+		t.Errorf("Empty document = %s", buf.String())
+	}
+
+	// Without a key, the only record is the document
+	buf.Reset()
+	out, _ = NewWriter(&buf, FormatJSON, "")
+	out.Record(Setting{Path: "/tmp/config.yaml"})
+	out.Close()
+	if !strings.HasPrefix(buf.String(), "{\n  \"path\"") {
+		t.Errorf("Single record document = %s", buf.String())
+	}
+}
+
+func TestNewRule(t *testing.T) {
+	rule, err := models.ParseRule("rules/go/testing.mdc", "---\ndescription: Go tests\nglobs: \"*.go\"\n---\nbody\n")
+	if err != nil {
+		t.Fatalf("ParseRule failed: %v", err)
+	}
+	rule.Topic = "go"
+
+	record := NewRule(rule, "go/testing", "cursor", true)
+	data, err := json.Marshal(record)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want := `{"id":"go/testing","name":"testing","topic":"go","description":"Go tests","globs":["*.go"],"alwaysApply":false,"tags":[],"path":"rules/go/testing.mdc","installed":true,"format":"cursor"}`
+	if string(data) != want {
+		t.Errorf("Record =\n%s\nwant\n%s", data, want)
+	}
+}
@@ -0,0 +1,54 @@
+package plan
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// symbols mark each action in the printed plan, terraform style
+var symbols = map[Action]string{
+	ActionLink:    "+",
+	ActionRefresh: "~",
+	ActionRemove:  "-",
+	ActionSkip:    "!",
+}
+
+// Write prints the plan grouped by format, followed by a summary line
+func Write(w io.Writer, p *Plan) error {
+	if len(p.Changes) == 0 {
+		_, err := fmt.Fprintln(w, "No changes. Installed rules match the declared rule set.")
+		return err
+	}
+
+	fmt.Fprintln(w, "rule-tool will perform the following actions:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	format := ""
+	for _, change := range p.Changes {
+		if change.Format != format {
+			format = change.Format
+			fmt.Fprintf(tw, "\n  # %s\n", format)
+		}
+		line := fmt.Sprintf("  %s %s\t%s", symbols[change.Action], change.Rule, change.Destination)
+		if change.Reason != "" {
+			line += "\t(" + change.Reason + ")"
+		}
+		fmt.Fprintln(tw, line)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintln(w, "\n"+Summary(p))
+	return err
+}
+
+// Summary returns the one-line count of planned changes
+func Summary(p *Plan) string {
+	summary := fmt.Sprintf("Plan: %d to link, %d to refresh, %d to remove.",
+		p.Count(ActionLink), p.Count(ActionRefresh), p.Count(ActionRemove))
+	if skipped := p.Count(ActionSkip); skipped > 0 {
+		summary += fmt.Sprintf(" %d skipped with local edits.", skipped)
+	}
+	return summary
+}
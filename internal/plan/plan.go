@@ -0,0 +1,221 @@
+package plan
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/circleci/llm-agent-rules/internal/linker"
+	"github.com/circleci/llm-agent-rules/internal/status"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// Action is what a sync does to one rule
+type Action string
+
+const (
+	// ActionLink installs a declared rule that is not installed
+	ActionLink Action = "link"
+	// ActionRefresh reinstalls a declared rule that is stale
+	ActionRefresh Action = "refresh"
+	// ActionRemove uninstalls a rule that is no longer declared
+	ActionRemove Action = "remove"
+	// ActionSkip leaves a stale rule alone because it has local edits
+	ActionSkip Action = "skip"
+)
+
+// Change is a single planned action
+type Change struct {
+	Action      Action `json:"action"`
+	Rule        string `json:"rule"`
+	Format      string `json:"format"`
+	Destination string `json:"destination,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+	// Applied reports whether Apply made the change
+	Applied bool `json:"applied"`
+	// Error is why Apply failed to make the change
+	Error string `json:"error,omitempty"`
+
+	rule     *models.Rule
+	fileName string
+}
+
+// Plan is the set of changes that reconcile a target project with its declared rules
+type Plan struct {
+	Changes []Change `json:"changes"`
+	mode    linker.LinkMode
+}
+
+// Count returns the number of changes with the given action
+func (p *Plan) Count(action Action) int {
+	count := 0
+	for _, change := range p.Changes {
+		if change.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+// Empty reports whether the plan changes nothing
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == p.Count(ActionSkip)
+}
+
+// Compute plans how to make the rules installed for each format match the
+// desired rules. all is every rule in the repository, including shadowed
+// ones, used to tell rules that were removed upstream from rules that are
+// merely no longer declared.
+func Compute(l *linker.Linker, all, desired []*models.Rule, formats []string, mode linker.LinkMode) (*Plan, error) {
+	lock, err := l.Lockfile()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Plan{Changes: make([]Change, 0), mode: mode}
+	for _, format := range formats {
+		adapter, err := linker.LookupAdapter(format)
+		if err != nil {
+			return nil, err
+		}
+
+		report, err := status.New(l, all).Check(adapter.Name())
+		if err != nil {
+			return nil, err
+		}
+		states := report.RuleStates(adapter.Name())
+
+		wanted := make(map[string]bool, len(desired))
+		missing := make([]*models.Rule, 0)
+		for _, rule := range desired {
+			id := linker.RuleID(rule)
+			wanted[id] = true
+
+			entry, ok := lock.Get(adapter.Name(), id)
+			if !ok {
+				missing = append(missing, rule)
+				continue
+			}
+
+			change := Change{Rule: id, Format: adapter.Name(), Destination: entry.Destination, rule: rule, fileName: filepath.Base(entry.Destination)}
+			switch state := states[id]; {
+			case state == status.StateLocallyModified && !l.Force:
+				change.Action = ActionSkip
+				change.Reason = "locally modified, use --force to overwrite"
+			case state != status.StateUpToDate:
+				change.Action = ActionRefresh
+				change.Reason = string(state)
+			case entry.Mode != string(installMode(adapter, rule, mode)):
+				change.Action = ActionRefresh
+				change.Reason = "switch to " + string(installMode(adapter, rule, mode))
+			case entry.Source != "" && entry.Source != l.SourceRepo(rule):
+				change.Action = ActionRefresh
+				change.Reason = "switch to " + l.SourceRepo(rule)
+			default:
+				continue
+			}
+			p.Changes = append(p.Changes, change)
+		}
+
+		// New rules get their file names planned together, so they cannot
+		// collide with each other or with rules already installed
+		targets, err := l.PlanTargets(missing, adapter.Name())
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range missing {
+			destination := adapter.TargetDir() + "/" + targets[rule.Path]
+			if section, ok := adapter.(linker.SectionAdapter); ok {
+				destination = section.ManagedFile()
+			}
+			p.Changes = append(p.Changes, Change{
+				Action:      ActionLink,
+				Rule:        linker.RuleID(rule),
+				Format:      adapter.Name(),
+				Destination: destination,
+				rule:        rule,
+				fileName:    targets[rule.Path],
+			})
+		}
+
+		for _, entry := range lock.Entries(adapter.Name()) {
+			if wanted[entry.ID] {
+				continue
+			}
+			reason := "no longer declared"
+			if states[entry.ID] == status.StateOrphaned {
+				reason = "source rule removed"
+			}
+			p.Changes = append(p.Changes, Change{
+				Action:      ActionRemove,
+				Rule:        entry.ID,
+				Format:      adapter.Name(),
+				Destination: entry.Destination,
+				Reason:      reason,
+			})
+		}
+	}
+
+	sort.SliceStable(p.Changes, func(i, j int) bool {
+		if p.Changes[i].Format != p.Changes[j].Format {
+			return p.Changes[i].Format < p.Changes[j].Format
+		}
+		return p.Changes[i].Rule < p.Changes[j].Rule
+	})
+	return p, nil
+}
+
+// Retain drops the changes keep rejects, so a sync can be limited to some rules
+func (p *Plan) Retain(keep func(change Change) bool) {
+	kept := p.Changes[:0]
+	for _, change := range p.Changes {
+		if keep(change) {
+			kept = append(kept, change)
+		}
+	}
+	p.Changes = kept
+}
+
+// installMode returns the mode a rule ends up installed with for an adapter,
+// since editors that cannot use symlinks and templates always get copies
+func installMode(adapter linker.EditorAdapter, rule *models.Rule, mode linker.LinkMode) linker.LinkMode {
+	if _, ok := adapter.(linker.SectionAdapter); ok || !adapter.AllowsSymlinks() || rule.Templated() {
+		return linker.ModeCopy
+	}
+	return mode
+}
+
+// Apply carries out the plan in one transaction. It stops at the first
+// change that fails, which records the error, and rolls back the ones
+// applied before it, so either every change is applied or none is.
+func (p *Plan) Apply(l *linker.Linker) error {
+	previous := l.Mode
+	l.SetMode(p.mode)
+	defer l.SetMode(previous)
+
+	err := l.Transact(func() error {
+		for i, change := range p.Changes {
+			var err error
+			switch change.Action {
+			case ActionLink, ActionRefresh:
+				err = l.LinkRuleAs(change.rule, change.Format, change.fileName)
+			case ActionRemove:
+				err = l.UnlinkRule(change.Rule, change.Format)
+			default:
+				continue
+			}
+			if err != nil {
+				p.Changes[i].Error = err.Error()
+				return fmt.Errorf("failed to %s %s for %s: %w", change.Action, change.Rule, change.Format, err)
+			}
+			p.Changes[i].Applied = true
+		}
+		return nil
+	})
+	if err != nil {
+		for i := range p.Changes {
+			p.Changes[i].Applied = false
+		}
+	}
+	return err
+}
@@ -0,0 +1,239 @@
+package plan
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/circleci/llm-agent-rules/internal/linker"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+func writeRule(t *testing.T, dir, topic, name, content string) *models.Rule {
+	t.Helper()
+	path := filepath.Join(dir, "repo", "rules", topic, name+".mdc")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create rule directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write rule: %v", err)
+	}
+	rule, err := models.NewRule(path)
+	if err != nil {
+		t.Fatalf("NewRule failed: %v", err)
+	}
+	rule.Topic = topic
+	return rule
+}
+
+func actions(p *Plan) map[string]Action {
+	got := make(map[string]Action)
+	for _, change := range p.Changes {
+		got[change.Format+" "+change.Rule] = change.Action
+	}
+	return got
+}
+
+func TestComputeAndApply(t *testing.T) {
+	tmpDir := t.TempDir()
+	kept := writeRule(t, tmpDir, "go", "style", "# Style\n")
+	stale := writeRule(t, tmpDir, "go", "testing", "# Testing\n")
+	dropped := writeRule(t, tmpDir, "process", "review", "# Review\n")
+	added := writeRule(t, tmpDir, "go", "errors", "# Errors\n")
+	all := []*models.Rule{kept, stale, dropped, added}
+
+	l := linker.NewLinker(tmpDir)
+	l.SetMode(linker.ModeCopy)
+	if err := l.LinkRules([]*models.Rule{kept, stale, dropped}, "cursor"); err != nil {
+		t.Fatalf("LinkRules failed: %v", err)
+	}
+	stale = writeRule(t, tmpDir, "go", "testing", "# Testing\nChanged.\n")
+	all[1] = stale
+
+	desired := []*models.Rule{kept, stale, added}
+	p, err := Compute(l, all, desired, []string{"cursor"}, linker.ModeCopy)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+
+	want := map[string]Action{
+		"cursor go/testing":     ActionRefresh,
+		"cursor process/review": ActionRemove,
+		"cursor go/errors":      ActionLink,
+	}
+	got := actions(p)
+	if len(got) != len(want) {
+		t.Errorf("Changes = %+v, want %v", p.Changes, want)
+	}
+	for rule, action := range want {
+		if got[rule] != action {
+			t.Errorf("%s: action %q, want %q", rule, got[rule], action)
+		}
+	}
+
+	if err := p.Apply(l); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	// Applying the plan converges, so planning again finds nothing to do
+	again, err := Compute(l, all, desired, []string{"cursor"}, linker.ModeCopy)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	if !again.Empty() {
+		t.Errorf("Expected an empty plan after apply, got %+v", again.Changes)
+	}
+	if _, err := os.Lstat(filepath.Join(tmpDir, ".cursor", "rules", "process_review.mdc")); !os.IsNotExist(err) {
+		t.Error("Expected the undeclared rule to be removed")
+	}
+}
+
+func TestComputeSkipsLocalEdits(t *testing.T) {
+	tmpDir := t.TempDir()
+	rule := writeRule(t, tmpDir, "", "style", "# Style\n")
+
+	l := linker.NewLinker(tmpDir)
+	l.SetMode(linker.ModeCopy)
+	if err := l.LinkRule(rule, "cursor"); err != nil {
+		t.Fatalf("LinkRule failed: %v", err)
+	}
+	targetPath := filepath.Join(tmpDir, ".cursor", "rules", "style.mdc")
+	content, _ := os.ReadFile(targetPath)
+	if err := os.WriteFile(targetPath, append(content, "Local.\n"...), 0644); err != nil {
+		t.Fatalf("Failed to edit copy: %v", err)
+	}
+
+	p, err := Compute(l, []*models.Rule{rule}, []*models.Rule{rule}, []string{"cursor"}, linker.ModeCopy)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	if got := actions(p)["cursor style"]; got != ActionSkip {
+		t.Errorf("Action = %q, want %q", got, ActionSkip)
+	}
+	if !p.Empty() {
+		t.Error("Expected a plan with only skipped changes to be empty")
+	}
+
+	l.SetForce(true)
+	p, err = Compute(l, []*models.Rule{rule}, []*models.Rule{rule}, []string{"cursor"}, linker.ModeCopy)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	if got := actions(p)["cursor style"]; got != ActionRefresh {
+		t.Errorf("Forced action = %q, want %q", got, ActionRefresh)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	p := &Plan{Changes: []Change{
+		{Action: ActionLink, Rule: "go/errors", Format: "cursor", Destination: ".cursor/rules/go_errors.mdc"},
+		{Action: ActionRemove, Rule: "process/review", Format: "cursor", Destination: ".cursor/rules/process_review.mdc", Reason: "no longer declared"},
+	}}
+
+	var out bytes.Buffer
+	if err := Write(&out, p); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	for _, want := range []string{
+		"# cursor",
+		"+ go/errors",
+		"- process/review",
+		"(no longer declared)",
+		"Plan: 1 to link, 0 to refresh, 1 to remove.",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Plan output does not contain %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestComputePinnedSource(t *testing.T) {
+	tmpDir := t.TempDir()
+	team := writeRule(t, tmpDir, "go", "style", "# Team style\n")
+	team.Source, team.Overrides = "team", []string{"org"}
+	org := writeRule(t, filepath.Join(tmpDir, "org"), "go", "style", "# Org style\n")
+	org.Source, org.ShadowedBy = "org", "team"
+	all := []*models.Rule{team, org}
+
+	l := linker.NewLinker(tmpDir)
+	l.SetSources([]models.Source{
+		{ID: "org", Root: filepath.Join(tmpDir, "org", "repo")},
+		{ID: "team", Root: filepath.Join(tmpDir, "repo")},
+	})
+	apply := func(desired *models.Rule) *Plan {
+		t.Helper()
+		p, err := Compute(l, all, []*models.Rule{desired}, []string{"cursor"}, linker.ModeCopy)
+		if err != nil {
+			t.Fatalf("Compute failed: %v", err)
+		}
+		if err := p.Apply(l); err != nil {
+			t.Fatalf("Apply failed: %v", err)
+		}
+		return p
+	}
+
+	apply(org)
+	// The pinned rule is compared with its own source, not the override
+	if p := apply(org); !p.Empty() {
+		t.Errorf("Expected a pinned rule to stay installed, got %+v", p.Changes)
+	}
+
+	// Declaring the overriding rule instead switches the source
+	p := apply(team)
+	if got := actions(p)["cursor go/style"]; got != ActionRefresh {
+		t.Errorf("Action = %q, want %q", got, ActionRefresh)
+	}
+	content, _ := os.ReadFile(filepath.Join(tmpDir, ".cursor", "rules", "go_style.mdc"))
+	if !strings.Contains(string(content), "# Team style") {
+		t.Errorf("Expected the team rule to be installed, got %q", content)
+	}
+}
+
+func TestApplyRollsBackOnFailure(t *testing.T) {
+	tmpDir := t.TempDir()
+	// The removal sorts before the link that fails
+	dropped := writeRule(t, tmpDir, "go", "alpha", "# Alpha\n")
+	added := writeRule(t, tmpDir, "go", "errors", "# Errors\n")
+
+	l := linker.NewLinker(tmpDir)
+	if err := l.LinkRules([]*models.Rule{dropped}, "cursor"); err != nil {
+		t.Fatalf("LinkRules failed: %v", err)
+	}
+	p, err := Compute(l, []*models.Rule{dropped, added}, []*models.Rule{added}, []string{"cursor"}, linker.ModeSymlink)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+
+	// A directory where the added rule goes makes linking it fail
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".cursor", "rules", "go_errors.mdc", "keep"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Apply(l); err == nil {
+		t.Fatal("Apply succeeded, want an error")
+	}
+
+	for _, change := range p.Changes {
+		if change.Applied {
+			t.Errorf("%s %s is applied after the rollback", change.Action, change.Rule)
+		}
+	}
+	if !l.IsRuleLinked(dropped, "cursor") {
+		t.Error("Removed rule was not restored by the rollback")
+	}
+}
+
+func TestRetain(t *testing.T) {
+	p := &Plan{Changes: []Change{
+		{Action: ActionLink, Rule: "go/style"},
+		{Action: ActionRemove, Rule: "js/jest"},
+		{Action: ActionRefresh, Rule: "go/testing"},
+	}}
+	p.Retain(func(change Change) bool {
+		return strings.HasPrefix(change.Rule, "go/")
+	})
+	if len(p.Changes) != 2 || p.Changes[0].Rule != "go/style" || p.Changes[1].Rule != "go/testing" {
+		t.Errorf("Retain kept %+v", p.Changes)
+	}
+}
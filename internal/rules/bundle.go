@@ -0,0 +1,152 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// BundlePrefix marks a selector that matches the rules of a bundle, as in "bundle:go-service"
+const BundlePrefix = "bundle:"
+
+// loadBundles loads the bundles of every source, in name order
+func (m *Manager) loadBundles() error {
+	index := make(map[string]int)
+	for i, source := range m.Sources {
+		if i >= len(m.bundleDirs) || m.bundleDirs[i] == "" {
+			continue
+		}
+		paths, err := bundleFiles(m.bundleDirs[i])
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			bundle, err := models.NewBundle(path)
+			var parseErr *models.ParseError
+			if errors.As(err, &parseErr) {
+				m.Invalid = append(m.Invalid, err)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			bundle.Source = source.ID
+
+			if at, ok := index[bundle.Name]; ok {
+				m.Bundles[at] = bundle
+				continue
+			}
+			index[bundle.Name] = len(m.Bundles)
+			m.Bundles = append(m.Bundles, bundle)
+		}
+	}
+	return nil
+}
+
+// WalkBundleFiles calls fn for every bundle file of the repository whose
+// rules WalkRuleFiles walks
+func (m *Manager) WalkBundleFiles(fn func(path string) error) error {
+	if len(m.bundleDirs) == 0 || m.bundleDirs[len(m.bundleDirs)-1] == "" {
+		return nil
+	}
+	paths, err := bundleFiles(m.bundleDirs[len(m.bundleDirs)-1])
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err := fn(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bundleFiles returns the bundle files in dir, sorted, or none when dir does not exist
+func bundleFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != models.BundleExt && ext != ".yml") {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// GetBundle returns a bundle by name, or nil
+func (m *Manager) GetBundle(name string) *models.Bundle {
+	for _, bundle := range m.Bundles {
+		if bundle.Name == name {
+			return bundle
+		}
+	}
+	return nil
+}
+
+// ResolveBundle returns the rules of a bundle and the bundles it includes,
+// in load order
+func (m *Manager) ResolveBundle(name string) ([]*models.Rule, error) {
+	selectors, err := m.bundleSelectors(name, nil)
+	if err != nil {
+		return nil, err
+	}
+	selected, err := m.Select(selectors)
+	if err != nil {
+		return nil, fmt.Errorf("bundle %s: %w", name, err)
+	}
+	return selected, nil
+}
+
+// bundleSelectors returns the rule selectors of a bundle and, recursively,
+// of the bundles it includes. included is the chain of bundles that led to
+// this one, to report cycles.
+func (m *Manager) bundleSelectors(name string, included []string) ([]string, error) {
+	for i, outer := range included {
+		if outer == name {
+			return nil, fmt.Errorf("bundle %s includes itself: %s", name, strings.Join(append(included[i:], name), " -> "))
+		}
+	}
+	bundle := m.GetBundle(name)
+	if bundle == nil {
+		if len(included) > 0 {
+			return nil, fmt.Errorf("bundle %s includes unknown bundle %s", included[len(included)-1], name)
+		}
+		return nil, fmt.Errorf("bundle not found: %s", name)
+	}
+
+	// Bundles selected in the rules list are included like any other
+	selectors := make([]string, 0, len(bundle.Rules))
+	includes := append([]string{}, bundle.Include...)
+	for _, selector := range bundle.Rules {
+		if include, ok := strings.CutPrefix(strings.TrimSpace(selector), BundlePrefix); ok {
+			includes = append(includes, include)
+			continue
+		}
+		selectors = append(selectors, selector)
+	}
+
+	chain := append(included[:len(included):len(included)], name)
+	for _, include := range includes {
+		more, err := m.bundleSelectors(strings.TrimSpace(include), chain)
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, more...)
+	}
+	return selectors, nil
+}
@@ -0,0 +1,86 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeBundle writes a bundle file under a repository's bundles directory
+func writeBundle(t *testing.T, root, name, content string) {
+	t.Helper()
+	path := filepath.Join(root, "bundles", name+".yaml")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create bundles directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write bundle: %v", err)
+	}
+}
+
+func TestResolveBundle(t *testing.T) {
+	root := t.TempDir()
+	writeRule(t, root, "go/style.mdc", "# Style\n")
+	writeRule(t, root, "go/testing.mdc", "# Testing\n")
+	writeRule(t, root, "review.mdc", "# Review\n")
+	writeRule(t, root, "secrets.mdc", "---\ntags: [security]\n---\n# Secrets\n")
+	writeBundle(t, root, "base", "description: Every project\nrules:\n  - review\n  - tag:security\n")
+	writeBundle(t, root, "go-service", "description: Go services\nrules: [\"go/**\"]\ninclude: [base]\n")
+	writeBundle(t, root, "loop-a", "include: [loop-b]\n")
+	writeBundle(t, root, "loop-b", "rules: [\"bundle:loop-a\"]\n")
+	writeBundle(t, root, "dangling", "include: [missing]\n")
+
+	m := NewManager(filepath.Join(root, "rules"))
+	if err := m.LoadRules(); err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+	if len(m.Bundles) != 5 || m.GetBundle("go-service").Description != "Go services" {
+		t.Fatalf("Loaded bundles %+v", m.Bundles)
+	}
+
+	resolved, err := m.ResolveBundle("go-service")
+	if err != nil {
+		t.Fatalf("ResolveBundle failed: %v", err)
+	}
+	got := make([]string, 0)
+	for _, rule := range resolved {
+		got = append(got, ruleID(rule))
+	}
+	if want := []string{"go/style", "go/testing", "review", "secrets"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ResolveBundle = %v, want %v", got, want)
+	}
+
+	if selected, err := m.Select([]string{"bundle:base"}); err != nil || len(selected) != 2 {
+		t.Errorf("Select(bundle:base) = %v, %v", selected, err)
+	}
+
+	for name, want := range map[string]string{
+		"loop-a":   "bundle loop-a includes itself: loop-a -> loop-b -> loop-a",
+		"dangling": "bundle dangling includes unknown bundle missing",
+		"missing":  "bundle not found: missing",
+	} {
+		if _, err := m.ResolveBundle(name); err == nil || err.Error() != want {
+			t.Errorf("ResolveBundle(%s) error = %v, want %q", name, err, want)
+		}
+	}
+}
+
+func TestLoadRulesSkipsInvalidBundles(t *testing.T) {
+	root := t.TempDir()
+	writeRule(t, root, "review.mdc", "# Review\n")
+	writeBundle(t, root, "good", "description: Good\nrules:\n  - review\n")
+	writeBundle(t, root, "typo", "description: Typo\nrule:\n  - review\n")
+
+	m := NewManager(filepath.Join(root, "rules"))
+	if err := m.LoadRules(); err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+	if len(m.Bundles) != 1 || m.Bundles[0].Name != "good" {
+		t.Errorf("Bundles = %v, want only the valid bundle", m.Bundles)
+	}
+	if len(m.Invalid) != 1 || !strings.Contains(m.Invalid[0].Error(), "typo.yaml:2") {
+		t.Errorf("Invalid = %v, want the unknown key reported with its line", m.Invalid)
+	}
+}
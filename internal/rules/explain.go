@@ -0,0 +1,44 @@
+package rules
+
+import "github.com/circleci/llm-agent-rules/pkg/models"
+
+// Activation is how a rule comes to be applied to a file, following Cursor's rule types
+type Activation string
+
+const (
+	// ActivationAlways is a rule with alwaysApply, applied to every file
+	ActivationAlways Activation = "always"
+	// ActivationGlob is a rule attached because one of its globs matches the file
+	ActivationGlob Activation = "glob"
+	// ActivationAgentRequested is a rule with only a description, which the
+	// agent applies when it judges the rule relevant
+	ActivationAgentRequested Activation = "agent-requested"
+	// ActivationManual is a rule without globs or description, only applied
+	// when mentioned explicitly
+	ActivationManual Activation = "manual"
+	// ActivationNoMatch is a rule whose globs do not match the file
+	ActivationNoMatch Activation = "no-match"
+)
+
+// Applies reports whether a rule with this activation is in effect for the
+// file, counting the rules the agent may apply by itself
+func (a Activation) Applies() bool {
+	return a == ActivationAlways || a == ActivationGlob || a == ActivationAgentRequested
+}
+
+// Activate returns how a rule applies to a slash-separated file path
+// relative to the project root, with the glob that matched it
+func Activate(rule *models.Rule, file string) (Activation, string) {
+	switch {
+	case rule.AlwaysApply:
+		return ActivationAlways, ""
+	case len(rule.Globs) > 0:
+		if glob, ok := MatchingGlob(rule, file); ok {
+			return ActivationGlob, glob
+		}
+		return ActivationNoMatch, ""
+	case rule.Description != "":
+		return ActivationAgentRequested, ""
+	}
+	return ActivationManual, ""
+}
@@ -0,0 +1,112 @@
+package rules
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// TagFilter narrows rules down by their tags, ignoring case
+type TagFilter struct {
+	// Include lists the tags a rule must all have
+	Include []string
+	// Exclude lists the tags a rule must have none of
+	Exclude []string
+}
+
+// Empty reports whether the filter matches every rule
+func (f TagFilter) Empty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// Matches reports whether a rule has every included tag and no excluded one
+func (f TagFilter) Matches(rule *models.Rule) bool {
+	for _, tag := range f.Include {
+		if !rule.HasTag(tag) {
+			return false
+		}
+	}
+	for _, tag := range f.Exclude {
+		if rule.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// String describes the filter as "tag:a tag:b -tag:c"
+func (f TagFilter) String() string {
+	terms := make([]string, 0, len(f.Include)+len(f.Exclude))
+	for _, tag := range f.Include {
+		terms = append(terms, TagPrefix+tag)
+	}
+	for _, tag := range f.Exclude {
+		terms = append(terms, "-"+TagPrefix+tag)
+	}
+	return strings.Join(terms, " ")
+}
+
+// Filter returns the rules matching the filter, in load order
+func (m *Manager) Filter(f TagFilter) []*models.Rule {
+	return FilterRules(m.Rules, f)
+}
+
+// FilterRules returns the rules matching the filter, keeping their order
+func FilterRules(rules []*models.Rule, f TagFilter) []*models.Rule {
+	matched := make([]*models.Rule, 0, len(rules))
+	for _, rule := range rules {
+		if f.Matches(rule) {
+			matched = append(matched, rule)
+		}
+	}
+	return matched
+}
+
+// Tags returns every tag used by the rules, sorted, with the number of
+// rules using each. Tags differing only in case are counted together under
+// their first spelling.
+func (m *Manager) Tags() ([]string, map[string]int) {
+	counts := make(map[string]int)
+	spelling := make(map[string]string)
+	for _, rule := range m.Rules {
+		seen := make(map[string]bool)
+		for _, tag := range rule.Tags {
+			key := strings.ToLower(tag)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if _, ok := spelling[key]; !ok {
+				spelling[key] = tag
+			}
+			counts[spelling[key]]++
+		}
+	}
+
+	tags := make([]string, 0, len(counts))
+	for tag := range counts {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return strings.ToLower(tags[i]) < strings.ToLower(tags[j])
+	})
+	return tags, counts
+}
+
+// ParseTagTerms splits the "tag:name" and "-tag:name" terms out of a filter
+// query, returning them as a filter along with the rest of the query
+func ParseTagTerms(query string) (TagFilter, string) {
+	var filter TagFilter
+	rest := make([]string, 0)
+	for _, term := range strings.Fields(query) {
+		if tag, ok := strings.CutPrefix(term, "-"+TagPrefix); ok && tag != "" {
+			filter.Exclude = append(filter.Exclude, tag)
+		} else if tag, ok := strings.CutPrefix(term, TagPrefix); ok && tag != "" {
+			filter.Include = append(filter.Include, tag)
+		} else {
+			rest = append(rest, term)
+		}
+	}
+	return filter, strings.Join(rest, " ")
+}
@@ -0,0 +1,64 @@
+package rules
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+func TestFilter(t *testing.T) {
+	m := NewManager("")
+	m.Rules = []*models.Rule{
+		{Name: "style", Topic: "go", Tags: []string{"golang"}},
+		{Name: "testing", Topic: "go", Tags: []string{"golang", "Testing"}},
+		{Name: "jest", Topic: "js", Tags: []string{"testing", "slow"}},
+		{Name: "review"},
+	}
+
+	testCases := []struct {
+		name   string
+		filter TagFilter
+		want   []string
+	}{
+		{name: "Empty filter", filter: TagFilter{}, want: []string{"go/style", "go/testing", "js/jest", "review"}},
+		{name: "Every included tag", filter: TagFilter{Include: []string{"golang", "testing"}}, want: []string{"go/testing"}},
+		{name: "Tags ignore case", filter: TagFilter{Include: []string{"TESTING"}}, want: []string{"go/testing", "js/jest"}},
+		{name: "Excluded tag", filter: TagFilter{Include: []string{"testing"}, Exclude: []string{"slow"}}, want: []string{"go/testing"}},
+		{name: "Only excluded tag", filter: TagFilter{Exclude: []string{"golang"}}, want: []string{"js/jest", "review"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, rule := range m.Filter(tc.filter) {
+				got = append(got, ruleID(rule))
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Filter = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	tags, counts := m.Tags()
+	if want := []string{"golang", "slow", "Testing"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("Tags = %v, want %v", tags, want)
+	}
+	if counts["Testing"] != 2 || counts["golang"] != 2 || counts["slow"] != 1 {
+		t.Errorf("Tag counts = %v", counts)
+	}
+}
+
+func TestParseTagTerms(t *testing.T) {
+	filter, rest := ParseTagTerms("tag:go style -tag:slow tag: tag:testing")
+	want := TagFilter{Include: []string{"go", "testing"}, Exclude: []string{"slow"}}
+	if !reflect.DeepEqual(filter, want) {
+		t.Errorf("ParseTagTerms filter = %+v, want %+v", filter, want)
+	}
+	if rest != "style tag:" {
+		t.Errorf("ParseTagTerms rest = %q, want %q", rest, "style tag:")
+	}
+	if got := filter.String(); got != "tag:go tag:testing -tag:slow" {
+		t.Errorf("String() = %q", got)
+	}
+}
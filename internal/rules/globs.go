@@ -0,0 +1,85 @@
+package rules
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// MatchGlob reports whether a slash-separated file path, relative to the
+// project root, matches a rule glob. Globs use doublestar syntax and are
+// relative to the project root, except that a glob without a slash, such
+// as "*.ts", matches files of that name in any directory.
+func MatchGlob(glob, file string) bool {
+	glob = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(glob), "./"), "/")
+	if glob == "" {
+		return false
+	}
+	if !strings.Contains(glob, "/") {
+		glob = "**/" + glob
+	}
+	ok, _ := doublestar.Match(glob, file)
+	return ok
+}
+
+// MatchingGlob returns the first of a rule's globs that matches file
+func MatchingGlob(rule *models.Rule, file string) (string, bool) {
+	for _, glob := range rule.Globs {
+		if MatchGlob(glob, file) {
+			return glob, true
+		}
+	}
+	return "", false
+}
+
+// Recommendation is a rule whose globs match files of a project
+type Recommendation struct {
+	Rule *models.Rule
+	// Files are the matching files, in the order they were given
+	Files []string
+	// Globs are the rule's globs that matched at least one file
+	Globs []string
+}
+
+// Recommend ranks the rules with globs by how many of files they apply to,
+// most first, leaving out the rules that match none. Rules that always
+// apply, or only on request, are not recommended since their globs say
+// nothing about the project.
+func Recommend(rules []*models.Rule, files []string) []Recommendation {
+	recommendations := make([]Recommendation, 0)
+	for _, rule := range rules {
+		if rule.AlwaysApply || len(rule.Globs) == 0 {
+			continue
+		}
+
+		recommendation := Recommendation{Rule: rule}
+		matched := make(map[string]bool)
+		for _, file := range files {
+			glob, ok := MatchingGlob(rule, file)
+			if !ok {
+				continue
+			}
+			recommendation.Files = append(recommendation.Files, file)
+			matched[glob] = true
+		}
+		if len(recommendation.Files) == 0 {
+			continue
+		}
+		for _, glob := range rule.Globs {
+			if matched[glob] {
+				recommendation.Globs = append(recommendation.Globs, glob)
+			}
+		}
+		recommendations = append(recommendations, recommendation)
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		if len(recommendations[i].Files) != len(recommendations[j].Files) {
+			return len(recommendations[i].Files) > len(recommendations[j].Files)
+		}
+		return ruleID(recommendations[i].Rule) < ruleID(recommendations[j].Rule)
+	})
+	return recommendations
+}
@@ -0,0 +1,83 @@
+package rules
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		glob string
+		file string
+		want bool
+	}{
+		{glob: "*.go", file: "main.go", want: true},
+		{glob: "*.go", file: "internal/scan/scan.go", want: true},
+		{glob: "**/*_test.go", file: "internal/scan/scan_test.go", want: true},
+		{glob: "src/*.ts", file: "src/app.ts", want: true},
+		{glob: "src/*.ts", file: "src/lib/app.ts", want: false},
+		{glob: "src/**/*.ts", file: "src/lib/app.ts", want: true},
+		{glob: "/docs/**", file: "docs/guide.md", want: true},
+		{glob: "./*.{ts,tsx}", file: "web/page.tsx", want: true},
+		{glob: "*.go", file: "main.py", want: false},
+		{glob: "", file: "main.go", want: false},
+	}
+	for _, tt := range tests {
+		if got := MatchGlob(tt.glob, tt.file); got != tt.want {
+			t.Errorf("MatchGlob(%q, %q) = %t, want %t", tt.glob, tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	rules := []*models.Rule{
+		{Name: "style", Topic: "go", Globs: []string{"*.go"}},
+		{Name: "testing", Topic: "go", Globs: []string{"**/*_test.go", "testdata/**"}},
+		{Name: "react", Topic: "js", Globs: []string{"*.tsx"}},
+		{Name: "always", AlwaysApply: true, Globs: []string{"*.go"}},
+		{Name: "review"},
+	}
+	files := []string{"main.go", "scan/scan.go", "scan/scan_test.go", "README.md"}
+
+	got := make(map[string][]string)
+	order := make([]string, 0)
+	for _, recommendation := range Recommend(rules, files) {
+		id := ruleID(recommendation.Rule)
+		order = append(order, id)
+		got[id] = recommendation.Globs
+	}
+	if want := []string{"go/style", "go/testing"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("Recommend order = %v, want %v", order, want)
+	}
+	if want := []string{"**/*_test.go"}; !reflect.DeepEqual(got["go/testing"], want) {
+		t.Errorf("go/testing matched globs %v, want %v", got["go/testing"], want)
+	}
+}
+
+func TestActivate(t *testing.T) {
+	tests := []struct {
+		name string
+		rule *models.Rule
+		want Activation
+		glob string
+	}{
+		{name: "Always", rule: &models.Rule{AlwaysApply: true, Globs: []string{"*.py"}}, want: ActivationAlways},
+		{name: "Matching glob", rule: &models.Rule{Globs: []string{"*.py", "**/*.go"}, Description: "Go"}, want: ActivationGlob, glob: "**/*.go"},
+		{name: "Other globs", rule: &models.Rule{Globs: []string{"*.py"}, Description: "Python"}, want: ActivationNoMatch},
+		{name: "Description only", rule: &models.Rule{Description: "Reviews"}, want: ActivationAgentRequested},
+		{name: "Manual", rule: &models.Rule{}, want: ActivationManual},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, glob := Activate(tt.rule, "cmd/tool/main.go")
+			if got != tt.want || glob != tt.glob {
+				t.Errorf("Activate() = %s, %q; want %s, %q", got, glob, tt.want, tt.glob)
+			}
+			if applies := got == ActivationAlways || got == ActivationGlob || got == ActivationAgentRequested; got.Applies() != applies {
+				t.Errorf("Applies() = %t", got.Applies())
+			}
+		})
+	}
+}
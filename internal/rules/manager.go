@@ -1,6 +1,7 @@
 package rules
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -12,23 +13,127 @@ import (
 type Manager struct {
 	Rules     []*models.Rule
 	RulesPath string
+	// Sources are the rules repositories rules are loaded from, lowest
+	// precedence first
+	Sources []models.Source
+	// Shadowed are rules hidden by a rule with the same topic/name in a later source
+	Shadowed []*models.Rule
+	// Bundles are the named groups of rules defined by the sources. A bundle
+	// replaces one with the same name from an earlier source.
+	Bundles []*models.Bundle
+	// Invalid holds the errors of the files that could not be parsed and
+	// were skipped. Validation reports them in detail.
+	Invalid []error
+
+	dirs       []string
+	bundleDirs []string
 }
 
 // NewManager creates a new rules manager
 func NewManager(rulesPath string) *Manager {
+	// Bundles live next to the rules directory, in the repository root
+	bundleDirs := []string{""}
+	if rulesPath != "" {
+		bundleDirs[0] = filepath.Join(filepath.Dir(rulesPath), "bundles")
+	}
 	return &Manager{
-		Rules:     make([]*models.Rule, 0),
-		RulesPath: rulesPath,
+		Rules:      make([]*models.Rule, 0),
+		RulesPath:  rulesPath,
+		Sources:    []models.Source{{}},
+		dirs:       []string{rulesPath},
+		bundleDirs: bundleDirs,
 	}
 }
 
-// LoadRules loads all rules from the rules directory
+// NewLayeredManager creates a rules manager that loads every source in
+// order, letting later sources override rules of earlier ones
+func NewLayeredManager(sources []models.Source) *Manager {
+	dirs := make([]string, 0, len(sources))
+	bundleDirs := make([]string, 0, len(sources))
+	for _, source := range sources {
+		dirs = append(dirs, source.RulesDir())
+		bundleDirs = append(bundleDirs, source.BundlesDir())
+	}
+
+	m := &Manager{
+		Rules:      make([]*models.Rule, 0),
+		Sources:    sources,
+		dirs:       dirs,
+		bundleDirs: bundleDirs,
+	}
+	if len(dirs) > 0 {
+		m.RulesPath = dirs[len(dirs)-1]
+	}
+	return m
+}
+
+// Layered reports whether rules are loaded from more than one source
+func (m *Manager) Layered() bool {
+	return len(m.Sources) > 1
+}
+
+// LoadRules loads all rules from the rules directory of every source, and
+// the bundles from its bundles directory. A rule replaces one with the same
+// topic/name from an earlier source in place, so the list keeps the order
+// rules were first seen in. Rules and bundles that cannot be parsed are
+// skipped and recorded in Invalid, so one broken file does not hide the others.
 func (m *Manager) LoadRules() error {
 	// Clear existing rules
 	m.Rules = make([]*models.Rule, 0)
+	m.Shadowed = make([]*models.Rule, 0)
+	m.Bundles = make([]*models.Bundle, 0)
+	m.Invalid = make([]error, 0)
+
+	index := make(map[string]int)
+	for i, source := range m.Sources {
+		err := walkRuleFiles(m.dirs[i], func(path, topic string) error {
+			// Create a new rule from the file
+			rule, err := models.NewRule(path)
+			var parseErr *models.ParseError
+			if errors.As(err, &parseErr) {
+				m.Invalid = append(m.Invalid, err)
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			// Set the topic to the folder name
+			rule.Topic = topic
+			rule.Source = source.ID
+
+			id := ruleID(rule)
+			if at, ok := index[id]; ok {
+				shadowed := m.Rules[at]
+				shadowed.ShadowedBy = source.ID
+				rule.Overrides = append(append(rule.Overrides, shadowed.Overrides...), shadowed.Source)
+				m.Shadowed = append(m.Shadowed, shadowed)
+				m.Rules[at] = rule
+				return nil
+			}
+
+			// Add the rule to the list
+			index[id] = len(m.Rules)
+			m.Rules = append(m.Rules, rule)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return m.loadBundles()
+}
 
-	// Walk through the rules directory
-	err := filepath.Walk(m.RulesPath, func(path string, info os.FileInfo, err error) error {
+// WalkRuleFiles calls fn for every rule file in the rules directory along
+// with its topic, the slash-separated folder it lives in relative to the
+// rules directory (empty for rules at the root)
+func (m *Manager) WalkRuleFiles(fn func(path, topic string) error) error {
+	return walkRuleFiles(m.RulesPath, fn)
+}
+
+// walkRuleFiles calls fn for every rule file in rulesPath along with its topic
+func walkRuleFiles(rulesPath string, fn func(path, topic string) error) error {
+	return filepath.Walk(rulesPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -43,34 +148,36 @@ func (m *Manager) LoadRules() error {
 			return nil
 		}
 
-		// Create a new rule from the file
-		rule, err := models.NewRule(path)
-		if err != nil {
-			return err
-		}
-
-		// If the rule is in a subfolder, prepend the folder name to the display name
-		relPath, err := filepath.Rel(m.RulesPath, path)
+		// If the rule is in a subfolder, the folder becomes its topic
+		topic := ""
+		relPath, err := filepath.Rel(rulesPath, path)
 		if err == nil && filepath.Dir(relPath) != "." {
-			// Get the folder structure
-			folder := filepath.Dir(relPath)
 			// Replace backslashes with forward slashes for consistency
-			folder = strings.ReplaceAll(folder, "\\", "/")
-			// Set the topic to the folder name
-			rule.Topic = folder
+			topic = strings.ReplaceAll(filepath.Dir(relPath), "\\", "/")
 		}
 
-		// Add the rule to the list
-		m.Rules = append(m.Rules, rule)
-		return nil
+		return fn(path, topic)
 	})
-
-	return err
 }
 
-// GetRuleByName returns a rule by its name
+// GetRuleByName returns a rule by its name. A name prefixed with a source ID,
+// as in "org:go/testing", picks the rule from that source even when a later
+// source overrides it.
 func (m *Manager) GetRuleByName(name string) *models.Rule {
-	for _, rule := range m.Rules {
+	candidates := m.Rules
+	if id, rest, ok := strings.Cut(name, models.SourceSeparator); ok && m.hasSource(id) {
+		candidates = make([]*models.Rule, 0)
+		for _, loaded := range [][]*models.Rule{m.Rules, m.Shadowed} {
+			for _, rule := range loaded {
+				if rule.Source == id {
+					candidates = append(candidates, rule)
+				}
+			}
+		}
+		name = rest
+	}
+
+	for _, rule := range candidates {
 		// Check both the plain name and the topic/name format
 		if rule.Name == name {
 			return rule
@@ -83,6 +190,32 @@ func (m *Manager) GetRuleByName(name string) *models.Rule {
 	return nil
 }
 
+// hasSource reports whether a source with the given ID is loaded
+func (m *Manager) hasSource(id string) bool {
+	for _, source := range m.Sources {
+		if source.ID == id && id != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// QualifiedName returns a rule's topic/name prefixed with its source ID
+func QualifiedName(rule *models.Rule) string {
+	if rule.Source == "" {
+		return ruleID(rule)
+	}
+	return rule.Source + models.SourceSeparator + ruleID(rule)
+}
+
+// All returns every loaded rule, including shadowed ones after the rules
+// that override them
+func (m *Manager) All() []*models.Rule {
+	all := make([]*models.Rule, 0, len(m.Rules)+len(m.Shadowed))
+	all = append(all, m.Rules...)
+	return append(all, m.Shadowed...)
+}
+
 // GetSelectedRules returns all selected rules
 func (m *Manager) GetSelectedRules() []*models.Rule {
 	selected := make([]*models.Rule, 0)
@@ -0,0 +1,95 @@
+package rules
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// writeRule writes a rule file under a source's rules directory
+func writeRule(t *testing.T, root, name, content string) {
+	t.Helper()
+	path := filepath.Join(root, "rules", filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create rules directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write rule: %v", err)
+	}
+}
+
+func TestLayeredSources(t *testing.T) {
+	tmpDir := t.TempDir()
+	org := models.Source{ID: "org", Root: filepath.Join(tmpDir, "org")}
+	team := models.Source{ID: "team", Root: filepath.Join(tmpDir, "team")}
+	personal := models.Source{ID: "me", Root: filepath.Join(tmpDir, "me")}
+
+	writeRule(t, org.Root, "go/testing.mdc", "# Org testing\n")
+	writeRule(t, org.Root, "review.mdc", "# Org review\n")
+	writeRule(t, team.Root, "go/testing.mdc", "# Team testing\n")
+	writeRule(t, personal.Root, "go/testing.mdc", "# My testing\n")
+	writeRule(t, personal.Root, "notes.mdc", "# Notes\n")
+
+	m := NewLayeredManager([]models.Source{org, team, personal})
+	if err := m.LoadRules(); err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+
+	got := make([]string, 0, len(m.Rules))
+	for _, rule := range m.Rules {
+		got = append(got, QualifiedName(rule))
+	}
+	// Overrides keep the position of the rule they replace
+	want := []string{"me:go/testing", "org:review", "me:notes"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Rules = %v, want %v", got, want)
+	}
+
+	winner := m.GetRuleByName("go/testing")
+	if winner.Content != "# My testing\n" {
+		t.Errorf("Expected the last source to win, got %q", winner.Content)
+	}
+	if !reflect.DeepEqual(winner.Overrides, []string{"org", "team"}) {
+		t.Errorf("Overrides = %v, want [org team]", winner.Overrides)
+	}
+	if len(m.Shadowed) != 2 {
+		t.Fatalf("Expected 2 shadowed rules, got %d", len(m.Shadowed))
+	}
+
+	// A source prefix picks a shadowed rule
+	shadowed := m.GetRuleByName("org:go/testing")
+	if shadowed == nil || shadowed.Content != "# Org testing\n" || shadowed.ShadowedBy != "team" {
+		t.Errorf("GetRuleByName(org:go/testing) = %+v", shadowed)
+	}
+	if rule := m.GetRuleByName("team:testing"); rule == nil || rule.Source != "team" {
+		t.Errorf("GetRuleByName(team:testing) = %+v", rule)
+	}
+	if rule := m.GetRuleByName("team:review"); rule != nil {
+		t.Errorf("Expected no review rule in team, got %+v", rule)
+	}
+	if rule := m.GetRuleByName("unknown:review"); rule != nil {
+		t.Errorf("Expected an unknown source to match nothing, got %+v", rule)
+	}
+}
+
+func TestLoadRulesSkipsInvalidRules(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeRule(t, tmpDir, "good.mdc", "---\ndescription: Good\n---\n# Good\n")
+	writeRule(t, tmpDir, "bad.mdc", "---\ndescription: Use this: always\n---\n# Bad\n")
+
+	m := NewManager(filepath.Join(tmpDir, "rules"))
+	if err := m.LoadRules(); err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+	if len(m.Rules) != 1 || m.Rules[0].Name != "good" {
+		t.Errorf("Rules = %v, want only the valid rule", m.Rules)
+	}
+	var parseErr *models.ParseError
+	if len(m.Invalid) != 1 || !errors.As(m.Invalid[0], &parseErr) || parseErr.Line != 2 {
+		t.Errorf("Invalid = %v, want the parse error of bad.mdc", m.Invalid)
+	}
+}
@@ -0,0 +1,172 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// Requirement is a rule installed because another rule requires it
+type Requirement struct {
+	Rule       *models.Rule
+	RequiredBy *models.Rule
+}
+
+// Conflict is a pair of rules that must not be installed together, where
+// Rule declares the conflict with With
+type Conflict struct {
+	Rule *models.Rule
+	With *models.Rule
+}
+
+// Error implements the error interface
+func (c Conflict) Error() string {
+	return fmt.Sprintf("%s conflicts with %s", ruleID(c.Rule), ruleID(c.With))
+}
+
+// Requirements returns the rules that the selected rules require, directly
+// or through other requirements, and that are not selected themselves. Each
+// is returned once, with the first rule found requiring it, in the order
+// they are reached. Requiring an unknown rule is an error.
+func (m *Manager) Requirements(selected []*models.Rule) ([]Requirement, error) {
+	seen := make(map[*models.Rule]bool, len(selected))
+	for _, rule := range selected {
+		seen[rule] = true
+	}
+
+	requirements := make([]Requirement, 0)
+	queue := append([]*models.Rule{}, selected...)
+	for len(queue) > 0 {
+		rule := queue[0]
+		queue = queue[1:]
+		for _, name := range rule.Requires {
+			required := m.GetRuleByName(name)
+			if required == nil {
+				return nil, fmt.Errorf("%s requires unknown rule %s", ruleID(rule), name)
+			}
+			if seen[required] {
+				continue
+			}
+			seen[required] = true
+			requirements = append(requirements, Requirement{Rule: required, RequiredBy: rule})
+			queue = append(queue, required)
+		}
+	}
+	return requirements, nil
+}
+
+// Conflicts returns the conflicts between the rules being added and each
+// other or the installed rules, whichever rule declares them. Conflicts with
+// unknown rules are ignored.
+func (m *Manager) Conflicts(adding, installed []*models.Rule) []Conflict {
+	added := make(map[*models.Rule]bool, len(adding))
+	present := make(map[*models.Rule]bool, len(adding)+len(installed))
+	for _, rule := range adding {
+		added[rule] = true
+		present[rule] = true
+	}
+	for _, rule := range installed {
+		present[rule] = true
+	}
+
+	conflicts := make([]Conflict, 0)
+	reported := make(map[[2]*models.Rule]bool)
+	for _, rules := range [][]*models.Rule{adding, installed} {
+		for _, rule := range rules {
+			for _, name := range rule.Conflicts {
+				with := m.GetRuleByName(name)
+				if with == nil || with == rule || !present[with] || (!added[rule] && !added[with]) {
+					continue
+				}
+				// Rules may both declare the conflict; report the pair once
+				if reported[[2]*models.Rule{with, rule}] || reported[[2]*models.Rule{rule, with}] {
+					continue
+				}
+				reported[[2]*models.Rule{rule, with}] = true
+				conflicts = append(conflicts, Conflict{Rule: rule, With: with})
+			}
+		}
+	}
+	return conflicts
+}
+
+// RequirementCycles returns the cycles of rules requiring each other, each
+// cycle once, starting from its rule with the lowest topic/name and
+// ending with it again
+func (m *Manager) RequirementCycles() [][]*models.Rule {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[*models.Rule]int, len(m.Rules))
+	found := make(map[string][]*models.Rule)
+
+	var visit func(rule *models.Rule, path []*models.Rule)
+	visit = func(rule *models.Rule, path []*models.Rule) {
+		state[rule] = visiting
+		path = append(path, rule)
+		for _, name := range rule.Requires {
+			required := m.GetRuleByName(name)
+			switch {
+			case required == nil:
+			case state[required] == visiting:
+				for i, onPath := range path {
+					if onPath == required {
+						cycle := rotateCycle(path[i:])
+						found[cycleKey(cycle)] = cycle
+					}
+				}
+			case state[required] == 0:
+				visit(required, path)
+			}
+		}
+		state[rule] = done
+	}
+	for _, rule := range m.Rules {
+		if state[rule] == 0 {
+			visit(rule, nil)
+		}
+	}
+
+	keys := make([]string, 0, len(found))
+	for key := range found {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	cycles := make([][]*models.Rule, 0, len(keys))
+	for _, key := range keys {
+		cycles = append(cycles, found[key])
+	}
+	return cycles
+}
+
+// rotateCycle returns the rules of a cycle starting from the one with the
+// lowest topic/name, with that rule repeated at the end
+func rotateCycle(rules []*models.Rule) []*models.Rule {
+	first := 0
+	for i, rule := range rules {
+		if ruleID(rule) < ruleID(rules[first]) {
+			first = i
+		}
+	}
+	cycle := make([]*models.Rule, 0, len(rules)+1)
+	cycle = append(cycle, rules[first:]...)
+	cycle = append(cycle, rules[:first]...)
+	return append(cycle, rules[first])
+}
+
+// cycleKey identifies a cycle by the topic/names of its rules
+func cycleKey(cycle []*models.Rule) string {
+	return strings.Join(CycleNames(cycle), " -> ")
+}
+
+// CycleNames returns the topic/names of the rules of a cycle
+func CycleNames(cycle []*models.Rule) []string {
+	names := make([]string, 0, len(cycle))
+	for _, rule := range cycle {
+		names = append(names, ruleID(rule))
+	}
+	return names
+}
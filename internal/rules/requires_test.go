@@ -0,0 +1,94 @@
+package rules
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// newDependencyManager returns a manager holding rules with the given
+// requirements and conflicts, keyed by name
+func newDependencyManager(requires, conflicts map[string][]string, names ...string) (*Manager, map[string]*models.Rule) {
+	m := NewManager("")
+	byName := make(map[string]*models.Rule, len(names))
+	for _, name := range names {
+		rule := &models.Rule{Name: name, Requires: requires[name], Conflicts: conflicts[name]}
+		m.Rules = append(m.Rules, rule)
+		byName[name] = rule
+	}
+	return m, byName
+}
+
+func TestRequirements(t *testing.T) {
+	m, rules := newDependencyManager(map[string][]string{
+		"testing": {"logging", "errors"},
+		"logging": {"errors", "style"},
+		"broken":  {"missing"},
+	}, nil, "testing", "logging", "errors", "style", "broken")
+
+	requirements, err := m.Requirements([]*models.Rule{rules["testing"], rules["errors"]})
+	if err != nil {
+		t.Fatalf("Requirements failed: %v", err)
+	}
+	got := make([]string, 0)
+	for _, requirement := range requirements {
+		got = append(got, requirement.Rule.Name+" by "+requirement.RequiredBy.Name)
+	}
+	if want := []string{"logging by testing", "style by logging"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Requirements = %v, want %v", got, want)
+	}
+
+	if _, err := m.Requirements([]*models.Rule{rules["broken"]}); err == nil || err.Error() != "broken requires unknown rule missing" {
+		t.Errorf("Requirements of a dangling reference returned %v", err)
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	m, rules := newDependencyManager(nil, map[string][]string{
+		"conventional": {"gitmoji"},
+		"gitmoji":      {"conventional"},
+		"tabs":         {"spaces", "missing"},
+	}, "conventional", "gitmoji", "tabs", "spaces")
+
+	testCases := []struct {
+		name      string
+		adding    []*models.Rule
+		installed []*models.Rule
+		want      []string
+	}{
+		{name: "Both added", adding: []*models.Rule{rules["conventional"], rules["gitmoji"]}, want: []string{"conventional conflicts with gitmoji"}},
+		{name: "Declared by the installed rule", adding: []*models.Rule{rules["spaces"]}, installed: []*models.Rule{rules["tabs"]}, want: []string{"tabs conflicts with spaces"}},
+		{name: "Only installed", adding: []*models.Rule{rules["tabs"]}, installed: []*models.Rule{rules["conventional"], rules["gitmoji"]}, want: []string{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, conflict := range m.Conflicts(tc.adding, tc.installed) {
+				got = append(got, conflict.Error())
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Conflicts = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRequirementCycles(t *testing.T) {
+	m, _ := newDependencyManager(map[string][]string{
+		"c":    {"a"},
+		"a":    {"b"},
+		"b":    {"c", "d"},
+		"d":    {"missing"},
+		"self": {"self"},
+	}, nil, "c", "a", "b", "d", "self")
+
+	got := make([]string, 0)
+	for _, cycle := range m.RequirementCycles() {
+		got = append(got, strings.Join(CycleNames(cycle), " -> "))
+	}
+	if want := []string{"a -> b -> c -> a", "self -> self"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RequirementCycles = %v, want %v", got, want)
+	}
+}
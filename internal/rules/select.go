@@ -0,0 +1,86 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// TagPrefix marks a selector that matches rules by tag, as in "tag:security"
+const TagPrefix = "tag:"
+
+// Select returns the rules matched by any of the selectors, in load order.
+// A selector is a rule name or topic/name, a glob over topic/name such as
+// "go/**", a tag prefixed with "tag:", or a bundle prefixed with "bundle:".
+// Names and bundles must exist; globs and tags may match nothing.
+func (m *Manager) Select(selectors []string) ([]*models.Rule, error) {
+	matched := make(map[*models.Rule]bool)
+	for _, selector := range selectors {
+		selector = strings.TrimSpace(selector)
+		if selector == "" {
+			continue
+		}
+
+		rules, err := m.match(selector)
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range rules {
+			matched[rule] = true
+		}
+	}
+
+	selected := make([]*models.Rule, 0, len(matched))
+	for _, rule := range m.Rules {
+		if matched[rule] {
+			selected = append(selected, rule)
+		}
+	}
+	return selected, nil
+}
+
+// match resolves a single selector
+func (m *Manager) match(selector string) ([]*models.Rule, error) {
+	if name, ok := strings.CutPrefix(selector, BundlePrefix); ok {
+		return m.ResolveBundle(name)
+	}
+
+	if tag, ok := strings.CutPrefix(selector, TagPrefix); ok {
+		matched := make([]*models.Rule, 0)
+		for _, rule := range m.Rules {
+			if rule.HasTag(tag) {
+				matched = append(matched, rule)
+			}
+		}
+		return matched, nil
+	}
+
+	if strings.ContainsAny(selector, "*?[{") {
+		if !doublestar.ValidatePattern(selector) {
+			return nil, fmt.Errorf("invalid rule pattern %q", selector)
+		}
+		matched := make([]*models.Rule, 0)
+		for _, rule := range m.Rules {
+			if ok, _ := doublestar.Match(selector, ruleID(rule)); ok {
+				matched = append(matched, rule)
+			}
+		}
+		return matched, nil
+	}
+
+	rule := m.GetRuleByName(selector)
+	if rule == nil {
+		return nil, fmt.Errorf("rule not found: %s", selector)
+	}
+	return []*models.Rule{rule}, nil
+}
+
+// ruleID returns the topic/name identifier selectors are matched against
+func ruleID(rule *models.Rule) string {
+	if rule.Topic != "" {
+		return rule.Topic + "/" + rule.Name
+	}
+	return rule.Name
+}
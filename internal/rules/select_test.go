@@ -0,0 +1,55 @@
+package rules
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+func TestSelect(t *testing.T) {
+	m := NewManager("")
+	m.Rules = []*models.Rule{
+		{Name: "style", Topic: "go", Tags: []string{"go"}},
+		{Name: "testing", Topic: "go/testing", Tags: []string{"go", "Testing"}},
+		{Name: "review", Topic: "process"},
+		{Name: "secrets", Tags: []string{"security"}},
+	}
+
+	testCases := []struct {
+		name      string
+		selectors []string
+		want      []string
+		wantErr   bool
+	}{
+		{name: "Plain name", selectors: []string{"review"}, want: []string{"process/review"}},
+		{name: "Topic and name", selectors: []string{"go/style"}, want: []string{"go/style"}},
+		{name: "Topic glob", selectors: []string{"go/*"}, want: []string{"go/style"}},
+		{name: "Recursive topic glob", selectors: []string{"go/**"}, want: []string{"go/style", "go/testing/testing"}},
+		{name: "Tag ignores case", selectors: []string{"tag:testing"}, want: []string{"go/testing/testing"}},
+		{name: "Overlapping selectors", selectors: []string{"tag:go", "go/style", "secrets"}, want: []string{"go/style", "go/testing/testing", "secrets"}},
+		{name: "Glob matching nothing", selectors: []string{"rust/**"}, want: []string{}},
+		{name: "Unknown name", selectors: []string{"missing"}, wantErr: true},
+		{name: "Invalid glob", selectors: []string{"go/[*"}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			selected, err := m.Select(tc.selectors)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Select error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+
+			got := make([]string, 0, len(selected))
+			for _, rule := range selected {
+				got = append(got, ruleID(rule))
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Select = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
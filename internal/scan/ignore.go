@@ -0,0 +1,114 @@
+package scan
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// pattern is one line of a .gitignore file
+type pattern struct {
+	// glob is matched against paths relative to the directory of the .gitignore
+	glob    string
+	negate  bool
+	dirOnly bool
+}
+
+// parsePattern converts a .gitignore line into a pattern, reporting false
+// for blank lines and comments
+func parsePattern(line string) (pattern, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return pattern{}, false
+	}
+
+	var p pattern
+	if rest, ok := strings.CutPrefix(line, "!"); ok {
+		p.negate = true
+		line = rest
+	} else {
+		// A backslash escapes a leading # or !
+		line = strings.TrimPrefix(line, `\`)
+	}
+	if rest, ok := strings.CutSuffix(line, "/"); ok {
+		p.dirOnly = true
+		line = rest
+	}
+	if line == "" {
+		return pattern{}, false
+	}
+
+	// Patterns with a slash are relative to the .gitignore; others match a
+	// name at any depth below it
+	if strings.Contains(line, "/") {
+		p.glob = strings.TrimPrefix(line, "/")
+	} else {
+		p.glob = "**/" + line
+	}
+	return p, true
+}
+
+// readPatterns reads the patterns of an ignore file, returning none when it
+// does not exist
+func readPatterns(file string) ([]pattern, error) {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	patterns := make([]pattern, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if p, ok := parsePattern(scanner.Text()); ok {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns, scanner.Err()
+}
+
+// ignorer holds the ignore patterns of each directory walked so far, by
+// slash-separated path relative to the root
+type ignorer map[string][]pattern
+
+// ignored reports whether a path relative to the root is ignored. The
+// patterns of deeper directories take precedence, and within a file the
+// last matching pattern wins.
+func (ig ignorer) ignored(rel string, isDir bool) bool {
+	ignored := false
+	dir := ""
+	for {
+		if patterns, ok := ig[dirKey(dir)]; ok {
+			sub := strings.TrimPrefix(rel, dir)
+			for _, p := range patterns {
+				if p.dirOnly && !isDir {
+					continue
+				}
+				if ok, _ := doublestar.Match(p.glob, sub); ok {
+					ignored = !p.negate
+				}
+			}
+		}
+
+		next := strings.IndexByte(rel[len(dir):], '/')
+		if next < 0 {
+			return ignored
+		}
+		dir = rel[:len(dir)+next+1]
+	}
+}
+
+// dirKey returns the key of a directory given with a trailing slash, or
+// empty for the root
+func dirKey(dir string) string {
+	if dir == "" {
+		return "."
+	}
+	return path.Clean(dir)
+}
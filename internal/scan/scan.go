@@ -0,0 +1,56 @@
+package scan
+
+import (
+	"io/fs"
+	"path/filepath"
+)
+
+// Files returns the files of the project at root as slash-separated paths
+// relative to it, in lexical order. Files ignored by the project's
+// .gitignore files or .git/info/exclude are left out, as is the .git
+// directory.
+func Files(root string) ([]string, error) {
+	exclude, err := readPatterns(filepath.Join(root, ".git", "info", "exclude"))
+	if err != nil {
+		return nil, err
+	}
+	ig := ignorer{}
+
+	files := make([]string, 0)
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if rel != "." && (d.Name() == ".git" || ig.ignored(rel, true)) {
+				return filepath.SkipDir
+			}
+			patterns, err := readPatterns(filepath.Join(p, ".gitignore"))
+			if err != nil {
+				return err
+			}
+			if rel == "." {
+				patterns = append(exclude, patterns...)
+			}
+			if len(patterns) > 0 {
+				ig[rel] = patterns
+			}
+			return nil
+		}
+
+		if d.Type().IsRegular() && !ig.ignored(rel, false) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
@@ -0,0 +1,68 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFilesRespectsGitignore(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":            "# build output\n/build/\n*.log\n!keep.log\nnode_modules\n",
+		".git/HEAD":             "ref: refs/heads/main\n",
+		".git/info/exclude":     "scratch.txt\n",
+		"main.go":               "",
+		"debug.log":             "",
+		"keep.log":              "",
+		"scratch.txt":           "",
+		"build/out.bin":         "",
+		"cmd/build/main.go":     "",
+		"web/node_modules/x.js": "",
+		"web/app.ts":            "",
+		"web/.gitignore":        "*.gen.ts\n",
+		"web/api.gen.ts":        "",
+		"docs/api.gen.ts":       "",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := Files(root)
+	if err != nil {
+		t.Fatalf("Files failed: %v", err)
+	}
+	want := []string{".gitignore", "cmd/build/main.go", "docs/api.gen.ts", "keep.log", "main.go", "web/.gitignore", "web/app.ts"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Files() = %v, want %v", got, want)
+	}
+}
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		line string
+		want pattern
+		ok   bool
+	}{
+		{line: "*.log", want: pattern{glob: "**/*.log"}, ok: true},
+		{line: "/build/", want: pattern{glob: "build", dirOnly: true}, ok: true},
+		{line: "docs/*.md", want: pattern{glob: "docs/*.md"}, ok: true},
+		{line: "!keep.log", want: pattern{glob: "**/keep.log", negate: true}, ok: true},
+		{line: `\#notes`, want: pattern{glob: "**/#notes"}, ok: true},
+		{line: "# comment"},
+		{line: "   "},
+	}
+	for _, tt := range tests {
+		got, ok := parsePattern(tt.line)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parsePattern(%q) = %+v, %t; want %+v, %t", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package source
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// remoteSchemes are the URL schemes treated as git remotes
+var remoteSchemes = []string{"https://", "http://", "ssh://", "git://", "file://"}
+
+// scpLike matches scp-style remotes such as git@github.com:org/rules.git
+var scpLike = regexp.MustCompile(`^[\w.-]+@[\w.-]+:`)
+
+// commitSHA matches a full commit hash
+var commitSHA = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// Spec identifies a rules repository in a git remote. It is written as
+//
+//	<url>[//<subdir>][?ref=<branch, tag or commit>]
+//
+// for example https://github.com/org/rules.git//team/web?ref=v1.2.0
+type Spec struct {
+	// URL is the git remote, without subdirectory or ref
+	URL string
+	// Ref is the branch, tag or commit to check out; empty means the remote's default branch
+	Ref string
+	// Subdir is the rules repository's directory within the git repository
+	Subdir string
+}
+
+// IsRemote reports whether a rules repository path is a git URL rather than a local path
+func IsRemote(raw string) bool {
+	raw = strings.TrimPrefix(raw, "git+")
+	for _, scheme := range remoteSchemes {
+		if strings.HasPrefix(raw, scheme) {
+			return true
+		}
+	}
+	return scpLike.MatchString(raw)
+}
+
+// Parse parses a remote rules repository spec
+func Parse(raw string) (Spec, error) {
+	if !IsRemote(raw) {
+		return Spec{}, fmt.Errorf("not a git URL: %s", raw)
+	}
+	raw = strings.TrimPrefix(raw, "git+")
+
+	var spec Spec
+	if i := strings.Index(raw, "?"); i >= 0 {
+		query, err := url.ParseQuery(raw[i+1:])
+		if err != nil {
+			return Spec{}, fmt.Errorf("invalid query in %s: %w", raw, err)
+		}
+		for key := range query {
+			if key != "ref" {
+				return Spec{}, fmt.Errorf("unknown parameter %q in %s", key, raw)
+			}
+		}
+		spec.Ref = query.Get("ref")
+		raw = raw[:i]
+	}
+
+	// The subdirectory follows the first "//" after the scheme
+	start := 0
+	if i := strings.Index(raw, "://"); i >= 0 {
+		start = i + len("://")
+	}
+	if i := strings.Index(raw[start:], "//"); i >= 0 {
+		spec.Subdir = strings.Trim(raw[start+i+2:], "/")
+		raw = raw[:start+i]
+		if spec.Subdir != "" && !isLocalPath(spec.Subdir) {
+			return Spec{}, fmt.Errorf("invalid subdirectory %q", spec.Subdir)
+		}
+	}
+
+	spec.URL = raw
+	if spec.URL == "" {
+		return Spec{}, fmt.Errorf("missing repository URL")
+	}
+	return spec, nil
+}
+
+// isLocalPath reports whether a subdirectory stays inside the repository
+func isLocalPath(subdir string) bool {
+	clean := path.Clean(subdir)
+	return clean != ".." && !strings.HasPrefix(clean, "../") && !path.IsAbs(clean)
+}
+
+// String formats the spec in the form Parse accepts
+func (s Spec) String() string {
+	out := s.URL
+	if s.Subdir != "" {
+		out += "//" + s.Subdir
+	}
+	if s.Ref != "" {
+		out += "?ref=" + url.QueryEscape(s.Ref)
+	}
+	return out
+}
+
+// Checkout is a rules repository fetched from a git remote
+type Checkout struct {
+	Spec Spec
+	// Repo is the clone in the cache directory, shared by every ref of the URL
+	Repo string
+	// Dir is the worktree the commit is checked out in. It belongs to the
+	// commit alone and is never changed afterwards, so sources pinned to
+	// other refs and installed symlinks pointing into it stay as they were.
+	Dir string
+	// Root is the rules repository within the worktree
+	Root string
+	// Commit is the resolved commit SHA
+	Commit string
+	// FetchErr is set when the remote could not be reached and the cached
+	// clone was used as it was
+	FetchErr error
+}
+
+// Fetcher clones git remotes into a cache directory, one bare clone per URL
+// with one worktree per commit checked out
+type Fetcher struct {
+	CacheDir string
+	// Git is the git executable
+	Git string
+}
+
+// NewFetcher creates a fetcher that caches clones under cacheDir
+func NewFetcher(cacheDir string) *Fetcher {
+	return &Fetcher{CacheDir: cacheDir, Git: "git"}
+}
+
+// DefaultCacheDir returns the directory remote rules repositories are cached in
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "rule-tool", "repos")
+}
+
+// key identifies a URL in the cache directory
+func (f *Fetcher) key(spec Spec) string {
+	sum := sha256.Sum256([]byte(spec.URL))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Repo returns the cache directory a URL is cloned into
+func (f *Fetcher) Repo(spec Spec) string {
+	return filepath.Join(f.CacheDir, f.key(spec)+".git")
+}
+
+// Dir returns the cache directory a commit of a URL is checked out in
+func (f *Fetcher) Dir(spec Spec, commit string) string {
+	return filepath.Join(f.CacheDir, f.key(spec)+"-"+commit)
+}
+
+// Fetch clones or updates the spec's repository in the cache and checks out
+// its ref in the commit's own worktree. When the remote cannot be reached, a
+// cached clone that already has the ref is used and the fetch error is
+// reported in the checkout.
+func (f *Fetcher) Fetch(spec Spec) (*Checkout, error) {
+	repo := f.Repo(spec)
+	checkout := &Checkout{Spec: spec, Repo: repo}
+
+	if _, err := os.Stat(filepath.Join(repo, "HEAD")); err != nil {
+		if err := f.clone(spec.URL, repo); err != nil {
+			return nil, fmt.Errorf("failed to clone %s: %w", spec.URL, err)
+		}
+	} else if _, err := f.resolve(repo, spec.Ref); err != nil || !commitSHA.MatchString(spec.Ref) {
+		// A commit that is already cached cannot change, so only refs that
+		// may have moved are fetched
+		if err := f.fetch(repo); err != nil {
+			checkout.FetchErr = fmt.Errorf("failed to fetch %s: %w", spec.URL, err)
+		}
+	}
+
+	commit, err := f.resolve(repo, spec.Ref)
+	if err != nil {
+		if checkout.FetchErr != nil {
+			return nil, checkout.FetchErr
+		}
+		return nil, err
+	}
+	checkout.Commit = commit
+
+	checkout.Dir = f.Dir(spec, commit)
+	if _, err := os.Stat(filepath.Join(checkout.Dir, ".git")); err != nil {
+		if err := f.addWorktree(repo, checkout.Dir, commit); err != nil {
+			return nil, fmt.Errorf("failed to check out %s: %w", commit, err)
+		}
+	}
+
+	checkout.Root = filepath.Join(checkout.Dir, filepath.FromSlash(spec.Subdir))
+	if info, err := os.Stat(checkout.Root); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("directory %q does not exist in %s at %s", spec.Subdir, spec.URL, ShortCommit(commit))
+	}
+	return checkout, nil
+}
+
+// clone creates the bare clone of a URL, tracking its branches as
+// origin/<branch> so fetches move them forward. A failed clone is removed.
+func (f *Fetcher) clone(url, repo string) error {
+	if err := os.RemoveAll(repo); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(f.CacheDir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	err := func() error {
+		if _, err := f.git("", "clone", "--quiet", "--bare", url, repo); err != nil {
+			return err
+		}
+		if _, err := f.git(repo, "config", "remote.origin.fetch", "+refs/heads/*:refs/remotes/origin/*"); err != nil {
+			return err
+		}
+		return f.fetch(repo)
+	}()
+	if err != nil {
+		_ = os.RemoveAll(repo)
+	}
+	return err
+}
+
+// fetch updates the branches and tags of a clone and the remote's default branch
+func (f *Fetcher) fetch(repo string) error {
+	if _, err := f.git(repo, "fetch", "--quiet", "--tags", "--force", "--prune", "origin"); err != nil {
+		return err
+	}
+	_, err := f.git(repo, "remote", "set-head", "origin", "--auto")
+	return err
+}
+
+// addWorktree checks out a commit in dir, replacing a worktree that was left
+// incomplete
+func (f *Fetcher) addWorktree(repo, dir, commit string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if _, err := f.git(repo, "worktree", "prune"); err != nil {
+		return err
+	}
+	_, err := f.git(repo, "worktree", "add", "--quiet", "--detach", dir, commit)
+	return err
+}
+
+// resolve returns the commit a ref points to. Branches are looked up on the
+// remote first, so a fetch moves them forward.
+func (f *Fetcher) resolve(dir, ref string) (string, error) {
+	candidates := []string{"origin/HEAD"}
+	if ref != "" {
+		candidates = []string{"origin/" + ref, "refs/tags/" + ref, ref}
+	}
+	for _, candidate := range candidates {
+		out, err := f.git(dir, "rev-parse", "--verify", "--quiet", candidate+"^{commit}")
+		if err == nil {
+			return strings.TrimSpace(out), nil
+		}
+	}
+	if ref == "" {
+		return "", fmt.Errorf("could not resolve the default branch")
+	}
+	return "", fmt.Errorf("unknown ref %q", ref)
+}
+
+// git runs a git command in dir and returns its output
+func (f *Fetcher) git(dir string, args ...string) (string, error) {
+	cmd := exec.Command(f.Git, args...)
+	cmd.Dir = dir
+	// Fail instead of waiting on a credential prompt
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "LC_ALL=C")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// ShortCommit abbreviates a commit SHA for display
+func ShortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
@@ -0,0 +1,187 @@
+package source
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name    string
+		raw     string
+		want    Spec
+		wantErr bool
+	}{
+		{name: "HTTPS", raw: "https://github.com/org/rules.git", want: Spec{URL: "https://github.com/org/rules.git"}},
+		{name: "Ref and subdirectory", raw: "https://github.com/org/rules.git//team/web?ref=v1.2.0", want: Spec{URL: "https://github.com/org/rules.git", Ref: "v1.2.0", Subdir: "team/web"}},
+		{name: "File URL", raw: "file:///srv/rules.git//web", want: Spec{URL: "file:///srv/rules.git", Subdir: "web"}},
+		{name: "git+ prefix", raw: "git+ssh://git@github.com/org/rules.git?ref=main", want: Spec{URL: "ssh://git@github.com/org/rules.git", Ref: "main"}},
+		{name: "scp-like", raw: "git@github.com:org/rules.git//web", want: Spec{URL: "git@github.com:org/rules.git", Subdir: "web"}},
+		{name: "Local path", raw: "/srv/rules", wantErr: true},
+		{name: "Unknown parameter", raw: "https://github.com/org/rules.git?branch=main", wantErr: true},
+		{name: "Subdirectory escapes the repository", raw: "https://github.com/org/rules.git//../etc", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spec, err := Parse(tc.raw)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tc.raw, err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if spec != tc.want {
+				t.Errorf("Parse(%q) = %+v, want %+v", tc.raw, spec, tc.want)
+			}
+			if reparsed, err := Parse(spec.String()); err != nil || reparsed != spec {
+				t.Errorf("Parse(%q) = %+v, %v; want the spec back", spec.String(), reparsed, err)
+			}
+		})
+	}
+}
+
+// gitRepo creates a bare repository with two commits, tagging the first as
+// v1, and returns its file:// URL and a work tree to push more commits from
+func gitRepo(t *testing.T) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	tmpDir := t.TempDir()
+	bare := filepath.Join(tmpDir, "rules.git")
+	work := filepath.Join(tmpDir, "work")
+	runGit(t, tmpDir, "init", "--quiet", "--bare", "--initial-branch=main", bare)
+	runGit(t, tmpDir, "clone", "--quiet", bare, work)
+
+	commitFile(t, work, "team/web/rules/style.mdc", "# Style v1\n")
+	runGit(t, work, "tag", "v1")
+	commitFile(t, work, "team/web/rules/style.mdc", "# Style v2\n")
+	runGit(t, work, "push", "--quiet", "origin", "main", "v1")
+	return "file://" + filepath.ToSlash(bare), work
+}
+
+// commitFile writes a file in the work tree and commits it
+func commitFile(t *testing.T, work, name, content string) {
+	t.Helper()
+	path := filepath.Join(work, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	runGit(t, work, "add", ".")
+	runGit(t, work, "commit", "--quiet", "-m", "Update "+name)
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func readStyle(t *testing.T, checkout *Checkout) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(checkout.Root, "rules", "style.mdc"))
+	if err != nil {
+		t.Fatalf("Failed to read rule: %v", err)
+	}
+	return string(data)
+}
+
+func TestFetch(t *testing.T) {
+	remote, work := gitRepo(t)
+	f := NewFetcher(filepath.Join(t.TempDir(), "cache"))
+
+	// The default branch
+	checkout, err := f.Fetch(Spec{URL: remote, Subdir: "team/web"})
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if got := readStyle(t, checkout); got != "# Style v2\n" {
+		t.Errorf("Default branch rule = %q", got)
+	}
+	if want := runGit(t, work, "rev-parse", "HEAD"); checkout.Commit != want {
+		t.Errorf("Commit = %s, want %s", checkout.Commit, want)
+	}
+
+	// A tag reuses the cached clone, checked out in a worktree of its own
+	tagged, err := f.Fetch(Spec{URL: remote, Subdir: "team/web", Ref: "v1"})
+	if err != nil {
+		t.Fatalf("Fetch v1 failed: %v", err)
+	}
+	if tagged.Repo != checkout.Repo {
+		t.Errorf("Expected one clone per URL, got %s and %s", tagged.Repo, checkout.Repo)
+	}
+	if tagged.Dir == checkout.Dir {
+		t.Errorf("Expected one worktree per commit, got %s for both", tagged.Dir)
+	}
+	if got := readStyle(t, tagged); got != "# Style v1\n" {
+		t.Errorf("Tagged rule = %q", got)
+	}
+	if got := readStyle(t, checkout); got != "# Style v2\n" {
+		t.Errorf("Default branch rule after checking out v1 = %q", got)
+	}
+	v1 := tagged.Commit
+
+	// New commits on a branch are fetched
+	commitFile(t, work, "team/web/rules/style.mdc", "# Style v3\n")
+	runGit(t, work, "push", "--quiet", "origin", "main")
+	latest, err := f.Fetch(Spec{URL: remote, Subdir: "team/web", Ref: "main"})
+	if err != nil {
+		t.Fatalf("Fetch main failed: %v", err)
+	}
+	if got := readStyle(t, latest); got != "# Style v3\n" {
+		t.Errorf("Branch rule after push = %q", got)
+	}
+
+	// A cached commit is used even when the remote is gone
+	if err := os.RemoveAll(strings.TrimPrefix(remote, "file://")); err != nil {
+		t.Fatalf("Failed to remove remote: %v", err)
+	}
+	pinned, err := f.Fetch(Spec{URL: remote, Subdir: "team/web", Ref: v1})
+	if err != nil {
+		t.Fatalf("Fetch of a cached commit failed offline: %v", err)
+	}
+	if pinned.Commit != v1 || pinned.FetchErr != nil {
+		t.Errorf("Fetch = %s, %v; want %s without fetching", pinned.Commit, pinned.FetchErr, v1)
+	}
+
+	// A branch falls back to the cached clone and reports the fetch error
+	stale, err := f.Fetch(Spec{URL: remote, Subdir: "team/web", Ref: "main"})
+	if err != nil {
+		t.Fatalf("Fetch of a cached branch failed offline: %v", err)
+	}
+	if stale.FetchErr == nil {
+		t.Error("Expected the fetch error to be reported")
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	remote, _ := gitRepo(t)
+	f := NewFetcher(filepath.Join(t.TempDir(), "cache"))
+
+	if _, err := f.Fetch(Spec{URL: remote, Ref: "v9"}); err == nil || !strings.Contains(err.Error(), "unknown ref") {
+		t.Errorf("Expected an unknown ref error, got %v", err)
+	}
+	if _, err := f.Fetch(Spec{URL: remote, Subdir: "missing"}); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("Expected a missing subdirectory error, got %v", err)
+	}
+	if _, err := f.Fetch(Spec{URL: remote + ".missing"}); err == nil {
+		t.Error("Expected an error cloning a missing repository")
+	}
+}
@@ -0,0 +1,133 @@
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// Output formats supported by Write
+const (
+	FormatPlain  = "plain"
+	FormatTable  = "table"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+// Formats lists every supported output format
+var Formats = []string{FormatPlain, FormatTable, FormatJSON, FormatNDJSON}
+
+// Write renders the report in the requested format
+func Write(w io.Writer, report *Report, format string) error {
+	switch format {
+	case FormatPlain, "":
+		return writePlain(w, report)
+	case FormatTable:
+		return writeTable(w, report)
+	case FormatJSON:
+		return writeJSON(w, report)
+	case FormatNDJSON:
+		return writeNDJSON(w, report)
+	default:
+		return fmt.Errorf("unknown output format %q (expected one of: %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// writePlain prints one entry per line in state format destination form
+func writePlain(w io.Writer, report *Report) error {
+	for _, entry := range report.Entries {
+		line := fmt.Sprintf("%s %s %s", entry.State, entry.Format, entry.Destination)
+		if entry.Rule != "" {
+			line += " (" + entry.Rule + ")"
+		}
+		if entry.Detail != "" {
+			line += ": " + entry.Detail
+		}
+		if len(entry.Vars) > 0 {
+			line += " [" + formatVars(entry.Vars) + "]"
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTable prints the entries as aligned columns with a header and summary
+func writeTable(w io.Writer, report *Report) error {
+	// The VARS column is only shown when templates are installed
+	templated := false
+	for _, entry := range report.Entries {
+		templated = templated || len(entry.Vars) > 0
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := "STATE\tFORMAT\tRULE\tDESTINATION\tDETAIL"
+	if templated {
+		header += "\tVARS"
+	}
+	fmt.Fprintln(tw, header)
+	for _, entry := range report.Entries {
+		rule := entry.Rule
+		if rule == "" {
+			rule = "-"
+		}
+		row := fmt.Sprintf("%s\t%s\t%s\t%s\t%s", entry.State, entry.Format, rule, entry.Destination, entry.Detail)
+		if templated {
+			row += "\t" + formatVars(entry.Vars)
+		}
+		fmt.Fprintln(tw, row)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintln(w, "\n"+Summary(report))
+	return err
+}
+
+// formatVars renders template values as name=value pairs sorted by name
+func formatVars(vars map[string]string) string {
+	pairs := make([]string, 0, len(vars))
+	for _, name := range slices.Sorted(maps.Keys(vars)) {
+		pairs = append(pairs, name+"="+vars[name])
+	}
+	return strings.Join(pairs, " ")
+}
+
+// writeJSON prints the full report as a single JSON document
+func writeJSON(w io.Writer, report *Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// writeNDJSON prints each entry as a JSON object on its own line
+func writeNDJSON(w io.Writer, report *Report) error {
+	encoder := json.NewEncoder(w)
+	for _, entry := range report.Entries {
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Summary returns a one-line count of entries per state
+func Summary(report *Report) string {
+	states := []State{StateUpToDate, StateUpstreamModified, StateLocallyModified, StateBrokenSymlink, StateMissing, StateOrphaned, StateForeign}
+	parts := make([]string, 0, len(states))
+	for _, state := range states {
+		if count := report.Count(state); count > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", count, state))
+		}
+	}
+	if len(parts) == 0 {
+		return "No rules installed"
+	}
+	return strings.Join(parts, ", ")
+}
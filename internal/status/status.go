@@ -0,0 +1,399 @@
+package status
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/circleci/llm-agent-rules/internal/linker"
+	"github.com/circleci/llm-agent-rules/internal/lockfile"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// State classifies an installed rule, or a file in an editor's rules directory
+type State string
+
+const (
+	// StateUpToDate means the installed rule matches its source
+	StateUpToDate State = "up-to-date"
+	// StateUpstreamModified means the source rule changed since it was installed
+	StateUpstreamModified State = "upstream-modified"
+	// StateLocallyModified means the installed copy was edited in the target project
+	StateLocallyModified State = "locally-modified"
+	// StateBrokenSymlink means the installed symlink no longer resolves
+	StateBrokenSymlink State = "broken-symlink"
+	// StateMissing means the lockfile records a rule whose installed file is gone
+	StateMissing State = "missing"
+	// StateOrphaned means the installed rule's source was deleted or renamed
+	StateOrphaned State = "orphaned"
+	// StateForeign means a file in an editor's rules directory not managed by rule-tool
+	StateForeign State = "foreign"
+)
+
+// Entry is the status of one installed rule or unmanaged file
+type Entry struct {
+	Rule        string `json:"rule,omitempty"`
+	Format      string `json:"format"`
+	Destination string `json:"destination"`
+	State       State  `json:"state"`
+	Mode        string `json:"mode,omitempty"`
+	Detail      string `json:"detail,omitempty"`
+	// Vars are the values a template was rendered with when installed
+	Vars map[string]string `json:"vars,omitempty"`
+}
+
+// Report holds the status of everything installed in a target project
+type Report struct {
+	Entries []Entry `json:"entries"`
+}
+
+// Count returns the number of entries in the given state
+func (r *Report) Count(state State) int {
+	count := 0
+	for _, entry := range r.Entries {
+		if entry.State == state {
+			count++
+		}
+	}
+	return count
+}
+
+// Drifted reports whether any managed rule is not up to date. Foreign files
+// are not counted, since they belong to the user.
+func (r *Report) Drifted() bool {
+	for _, entry := range r.Entries {
+		if entry.State != StateUpToDate && entry.State != StateForeign {
+			return true
+		}
+	}
+	return false
+}
+
+// RuleStates returns the state of each installed rule for one format, keyed by rule ID
+func (r *Report) RuleStates(format string) map[string]State {
+	states := make(map[string]State)
+	for _, entry := range r.Entries {
+		if entry.Format == format && entry.Rule != "" {
+			states[entry.Rule] = entry.State
+		}
+	}
+	return states
+}
+
+// Checker compares the rules installed in a target project with the rules repository
+type Checker struct {
+	linker *linker.Linker
+	rules  []*models.Rule
+}
+
+// New creates a checker for the linker's target project and the given source
+// rules, which may include shadowed rules after the ones overriding them
+func New(l *linker.Linker, rules []*models.Rule) *Checker {
+	return &Checker{linker: l, rules: rules}
+}
+
+// Check classifies every rule installed for the given formats. With no
+// formats, every format recorded in the lockfile or with an existing rules
+// directory is checked.
+func (c *Checker) Check(formats ...string) (*Report, error) {
+	lock, err := c.linker.Lockfile()
+	if err != nil {
+		return nil, err
+	}
+
+	adapters, err := c.adapters(lock, formats)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Entries: make([]Entry, 0)}
+	for _, adapter := range adapters {
+		recorded := make(map[string]bool)
+		for _, entry := range lock.Entries(adapter.Name()) {
+			recorded[entry.Destination] = true
+			report.Entries = append(report.Entries, c.checkEntry(adapter, entry))
+		}
+
+		if _, ok := adapter.(linker.SectionAdapter); ok {
+			continue
+		}
+		unmanaged, err := c.checkUnrecorded(adapter, recorded)
+		if err != nil {
+			return nil, err
+		}
+		report.Entries = append(report.Entries, unmanaged...)
+	}
+
+	sort.SliceStable(report.Entries, func(i, j int) bool {
+		if report.Entries[i].Format != report.Entries[j].Format {
+			return report.Entries[i].Format < report.Entries[j].Format
+		}
+		return report.Entries[i].Destination < report.Entries[j].Destination
+	})
+	return report, nil
+}
+
+// adapters resolves the formats to check
+func (c *Checker) adapters(lock *lockfile.Lockfile, formats []string) ([]linker.EditorAdapter, error) {
+	if len(formats) > 0 {
+		adapters := make([]linker.EditorAdapter, 0, len(formats))
+		for _, format := range formats {
+			adapter, err := linker.LookupAdapter(format)
+			if err != nil {
+				return nil, err
+			}
+			adapters = append(adapters, adapter)
+		}
+		return adapters, nil
+	}
+
+	adapters := make([]linker.EditorAdapter, 0)
+	for _, adapter := range linker.Adapters() {
+		if len(lock.Entries(adapter.Name())) > 0 {
+			adapters = append(adapters, adapter)
+			continue
+		}
+		if _, ok := adapter.(linker.SectionAdapter); ok {
+			continue
+		}
+		if info, err := os.Stat(c.linker.RulesDir(adapter)); err == nil && info.IsDir() {
+			adapters = append(adapters, adapter)
+		}
+	}
+	return adapters, nil
+}
+
+// checkEntry classifies a rule recorded in the lockfile
+func (c *Checker) checkEntry(adapter linker.EditorAdapter, entry lockfile.Entry) Entry {
+	result := Entry{Rule: entry.ID, Format: entry.Format, Destination: entry.Destination, Mode: entry.Mode, Vars: entry.Vars}
+
+	rule, ok := c.linker.EntryRule(entry, c.rules)
+	if !ok {
+		result.State = StateOrphaned
+		result.Detail = "source rule " + entry.Path + " no longer exists"
+		return result
+	}
+
+	if section, ok := adapter.(linker.SectionAdapter); ok {
+		return c.templateDetail(rule, entry, c.checkBlock(section, rule, entry, result))
+	}
+
+	targetPath := filepath.Join(c.linker.TargetDir, filepath.FromSlash(entry.Destination))
+	info, err := os.Lstat(targetPath)
+	if err != nil {
+		result.State = StateMissing
+		result.Detail = "installed file was deleted"
+		return result
+	}
+
+	if entry.Mode == string(linker.ModeSymlink) {
+		if info.Mode()&os.ModeSymlink == 0 {
+			result.State = StateLocallyModified
+			result.Detail = "symlink was replaced by a file"
+			return result
+		}
+		if _, err := os.Stat(targetPath); err != nil {
+			result.State = StateBrokenSymlink
+			return result
+		}
+		// A symlink always shows the current source; report that it changed
+		// since the lockfile was written
+		if linker.ContentHash([]byte(rule.Content)) != entry.SourceHash {
+			result.State = StateUpstreamModified
+			result.Detail = detailSourceChanged
+			return result
+		}
+		result.State = StateUpToDate
+		return result
+	}
+
+	if _, _, err := c.linker.Rendered(rule, entry.Format); err != nil {
+		result.State = StateUpstreamModified
+		result.Detail = err.Error()
+		return result
+	}
+	result.State, result.Detail = c.copyState(rule, entry.Format, targetPath)
+	return c.templateDetail(rule, entry, result)
+}
+
+// templateDetail explains that a template changed upstream because it is now
+// rendered with different values
+func (c *Checker) templateDetail(rule *models.Rule, entry lockfile.Entry, result Entry) Entry {
+	if result.State == StateUpstreamModified && result.Detail == detailSourceChanged &&
+		linker.ContentHash([]byte(rule.Content)) == entry.SourceHash && c.linker.VarsChanged(rule, entry) {
+		result.Detail = "template variables changed"
+	}
+	return result
+}
+
+// checkBlock classifies a rule installed in a managed section
+func (c *Checker) checkBlock(adapter linker.SectionAdapter, rule *models.Rule, entry lockfile.Entry, result Entry) Entry {
+	block, ok, err := c.linker.InstalledBlock(adapter, entry.ID)
+	if err != nil {
+		result.State = StateLocallyModified
+		result.Detail = err.Error()
+		return result
+	}
+	if !ok {
+		result.State = StateMissing
+		result.Detail = "rule was removed from " + adapter.ManagedFile()
+		return result
+	}
+
+	rendered, _, err := c.linker.Rendered(rule, entry.Format)
+	if err != nil {
+		result.State = StateUpstreamModified
+		result.Detail = err.Error()
+		return result
+	}
+	expected, err := adapter.RenderBlock(rendered)
+	if err != nil {
+		result.State = StateUpstreamModified
+		result.Detail = err.Error()
+		return result
+	}
+
+	localEdit := linker.ContentHash([]byte(block)) != entry.InstalledHash
+	upstreamChange := linker.ContentHash([]byte(strings.TrimSpace(expected))) != entry.InstalledHash
+	result.State, result.Detail = classify(localEdit, upstreamChange)
+	return result
+}
+
+// copyState classifies a stamped copy
+func (c *Checker) copyState(rule *models.Rule, format, targetPath string) (State, string) {
+	state, err := c.linker.CopyStatus(rule, format, targetPath)
+	if err != nil {
+		return StateLocallyModified, "copy stamp is missing"
+	}
+
+	switch state {
+	case linker.CopyUnchanged:
+		return StateUpToDate, ""
+	case linker.CopyUpstreamChanged:
+		return classify(false, true)
+	case linker.CopyLocallyEdited:
+		return classify(true, false)
+	default:
+		return classify(true, true)
+	}
+}
+
+// Details of the changes classify reports, the same for every install mode
+const (
+	detailSourceChanged = "source changed since install"
+	detailLocallyEdited = "edited in the target project"
+	detailConflict      = "edited in the target project; source also changed upstream"
+)
+
+// classify maps local and upstream changes to a state. Local edits win, since
+// updating would overwrite them.
+func classify(localEdit, upstreamChange bool) (State, string) {
+	switch {
+	case localEdit && upstreamChange:
+		return StateLocallyModified, detailConflict
+	case localEdit:
+		return StateLocallyModified, detailLocallyEdited
+	case upstreamChange:
+		return StateUpstreamModified, detailSourceChanged
+	default:
+		return StateUpToDate, ""
+	}
+}
+
+// checkUnrecorded classifies files in the adapter's rules directory that the
+// lockfile does not know about: links and copies installed before the
+// lockfile existed, orphans, and files the user added themselves
+func (c *Checker) checkUnrecorded(adapter linker.EditorAdapter, recorded map[string]bool) ([]Entry, error) {
+	rulesDir := c.linker.RulesDir(adapter)
+	files, err := os.ReadDir(rulesDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0)
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		destination := adapter.TargetDir() + "/" + file.Name()
+		if recorded[destination] {
+			continue
+		}
+
+		result := Entry{Format: adapter.Name(), Destination: destination, Detail: "not recorded in " + lockfile.FileName}
+		targetPath := filepath.Join(rulesDir, file.Name())
+
+		if file.Type()&os.ModeSymlink != 0 {
+			result.Mode = string(linker.ModeSymlink)
+			result.Rule, result.State = c.classifySymlink(targetPath)
+			if result.State == StateForeign {
+				result.Detail = ""
+			}
+			entries = append(entries, result)
+			continue
+		}
+
+		stamp, ok := linker.ReadStamp(targetPath)
+		if !ok {
+			result.State = StateForeign
+			result.Detail = ""
+			entries = append(entries, result)
+			continue
+		}
+
+		result.Mode = string(linker.ModeCopy)
+		rule := c.ruleBySourceRef(stamp.Source)
+		if rule == nil {
+			result.State = StateOrphaned
+			result.Detail = "source rule " + stamp.Source + " no longer exists"
+			entries = append(entries, result)
+			continue
+		}
+		result.Rule = linker.RuleID(rule)
+		state, detail := c.copyState(rule, adapter.Name(), targetPath)
+		result.State = state
+		if detail != "" {
+			result.Detail = detail
+		}
+		entries = append(entries, result)
+	}
+	return entries, nil
+}
+
+// classifySymlink resolves an unrecorded symlink to a source rule
+func (c *Checker) classifySymlink(targetPath string) (string, State) {
+	source, err := os.Readlink(targetPath)
+	if err != nil {
+		return "", StateForeign
+	}
+	if !filepath.IsAbs(source) {
+		source = filepath.Join(filepath.Dir(targetPath), source)
+	}
+	source = filepath.Clean(source)
+
+	for _, rule := range c.rules {
+		rulePath, err := filepath.Abs(rule.Path)
+		if err == nil && filepath.Clean(rulePath) == source {
+			return linker.RuleID(rule), StateUpToDate
+		}
+	}
+
+	if _, err := os.Stat(source); err != nil {
+		return "", StateBrokenSymlink
+	}
+	return "", StateForeign
+}
+
+// ruleBySourceRef finds the rule a copy stamp refers to
+func (c *Checker) ruleBySourceRef(source string) *models.Rule {
+	for _, rule := range c.rules {
+		if c.linker.SourceRef(rule) == source {
+			return rule
+		}
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package status
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/circleci/llm-agent-rules/internal/linker"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+func writeRule(t *testing.T, repo, topic, name, content string) *models.Rule {
+	t.Helper()
+	path := filepath.Join(repo, "rules", topic, name+".mdc")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create rule directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write rule: %v", err)
+	}
+	rule, err := models.NewRule(path)
+	if err != nil {
+		t.Fatalf("NewRule failed: %v", err)
+	}
+	rule.Topic = topic
+	return rule
+}
+
+func TestCheck(t *testing.T) {
+	tmpDir := t.TempDir()
+	repo := filepath.Join(tmpDir, "repo")
+	target := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(target, 0755); err != nil {
+		t.Fatalf("Failed to create target: %v", err)
+	}
+
+	current := writeRule(t, repo, "go", "current", "# Current\n")
+	upstream := writeRule(t, repo, "go", "upstream", "# Upstream\n")
+	edited := writeRule(t, repo, "go", "edited", "# Edited\n")
+	deleted := writeRule(t, repo, "go", "deleted", "# Deleted\n")
+	section := writeRule(t, repo, "", "style", "# Style\n")
+
+	l := linker.NewLinker(target)
+	l.SetSourceRoot(repo)
+	if err := l.LinkRules([]*models.Rule{current, deleted}, "cursor"); err != nil {
+		t.Fatalf("LinkRules failed: %v", err)
+	}
+	l.SetMode(linker.ModeCopy)
+	if err := l.LinkRules([]*models.Rule{upstream, edited}, "cursor"); err != nil {
+		t.Fatalf("LinkRules failed: %v", err)
+	}
+	if err := l.LinkRule(section, "claude"); err != nil {
+		t.Fatalf("LinkRule failed: %v", err)
+	}
+
+	// Drift the target away from the repository in every way status reports
+	upstream = writeRule(t, repo, "go", "upstream", "# Upstream\nChanged.\n")
+	editedPath := filepath.Join(target, ".cursor", "rules", "go_edited.mdc")
+	content, _ := os.ReadFile(editedPath)
+	if err := os.WriteFile(editedPath, append(content, "Local.\n"...), 0644); err != nil {
+		t.Fatalf("Failed to edit copy: %v", err)
+	}
+	rulesDir := filepath.Join(target, ".cursor", "rules")
+	if err := os.WriteFile(filepath.Join(rulesDir, "mine.mdc"), []byte("# Mine\n"), 0644); err != nil {
+		t.Fatalf("Failed to write foreign rule: %v", err)
+	}
+	if err := os.Symlink("../../missing.mdc", filepath.Join(rulesDir, "broken.mdc")); err != nil {
+		t.Fatalf("Failed to create broken symlink: %v", err)
+	}
+
+	report, err := New(l, []*models.Rule{current, upstream, edited, section}).Check()
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+
+	want := map[string]State{
+		"CLAUDE.md":                     StateUpToDate,
+		".cursor/rules/broken.mdc":      StateBrokenSymlink,
+		".cursor/rules/go_current.mdc":  StateUpToDate,
+		".cursor/rules/go_deleted.mdc":  StateOrphaned,
+		".cursor/rules/go_edited.mdc":   StateLocallyModified,
+		".cursor/rules/go_upstream.mdc": StateUpstreamModified,
+		".cursor/rules/mine.mdc":        StateForeign,
+	}
+	got := make(map[string]State)
+	for _, entry := range report.Entries {
+		got[entry.Destination] = entry.State
+	}
+	for destination, state := range want {
+		if got[destination] != state {
+			t.Errorf("%s: state %q, want %q", destination, got[destination], state)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("Got %d entries, want %d: %+v", len(got), len(want), report.Entries)
+	}
+	if !report.Drifted() {
+		t.Error("Expected the report to show drift")
+	}
+}
+
+func TestCheckDetailsMatchAcrossModes(t *testing.T) {
+	tmpDir := t.TempDir()
+	repo := filepath.Join(tmpDir, "repo")
+	target := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(target, 0755); err != nil {
+		t.Fatalf("Failed to create target: %v", err)
+	}
+
+	linked := writeRule(t, repo, "", "linked", "# Linked\n")
+	copied := writeRule(t, repo, "", "copied", "# Copied\n")
+	edited := writeRule(t, repo, "", "edited", "# Edited\n")
+	conflict := writeRule(t, repo, "", "conflict", "# Conflict\n")
+
+	l := linker.NewLinker(target)
+	l.SetSourceRoot(repo)
+	if err := l.LinkRule(linked, "cursor"); err != nil {
+		t.Fatalf("LinkRule failed: %v", err)
+	}
+	l.SetMode(linker.ModeCopy)
+	if err := l.LinkRules([]*models.Rule{copied, edited, conflict}, "cursor"); err != nil {
+		t.Fatalf("LinkRules failed: %v", err)
+	}
+
+	linked = writeRule(t, repo, "", "linked", "# Linked\nChanged.\n")
+	copied = writeRule(t, repo, "", "copied", "# Copied\nChanged.\n")
+	conflict = writeRule(t, repo, "", "conflict", "# Conflict\nChanged.\n")
+	for _, name := range []string{"edited", "conflict"} {
+		path := filepath.Join(target, ".cursor", "rules", name+".mdc")
+		content, _ := os.ReadFile(path)
+		if err := os.WriteFile(path, append(content, "Local.\n"...), 0644); err != nil {
+			t.Fatalf("Failed to edit copy: %v", err)
+		}
+	}
+
+	report, err := New(l, []*models.Rule{linked, copied, edited, conflict}).Check()
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	details := make(map[string]string)
+	for _, entry := range report.Entries {
+		details[entry.Rule] = entry.Detail
+	}
+	if details["linked"] != details["copied"] || details["copied"] == "" {
+		t.Errorf("Upstream-modified details = %q (symlink) and %q (copy), want the same", details["linked"], details["copied"])
+	}
+	if details["edited"] == "" || details["conflict"] == "" || details["edited"] == details["conflict"] {
+		t.Errorf("Locally-modified details = %q and %q, want a distinct detail for each", details["edited"], details["conflict"])
+	}
+}
+
+func TestCheckSectionDrift(t *testing.T) {
+	tmpDir := t.TempDir()
+	rule := writeRule(t, tmpDir, "", "style", "# Style\n")
+
+	l := linker.NewLinker(tmpDir)
+	if err := l.LinkRule(rule, "agents"); err != nil {
+		t.Fatalf("LinkRule failed: %v", err)
+	}
+
+	agentsPath := filepath.Join(tmpDir, "AGENTS.md")
+	content, _ := os.ReadFile(agentsPath)
+	edited := strings.Replace(string(content), "# Style", "# Style (edited)", 1)
+	if err := os.WriteFile(agentsPath, []byte(edited), 0644); err != nil {
+		t.Fatalf("Failed to edit AGENTS.md: %v", err)
+	}
+
+	report, err := New(l, []*models.Rule{rule}).Check("agents")
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if len(report.Entries) != 1 || report.Entries[0].State != StateLocallyModified {
+		t.Errorf("Entries = %+v, want one locally-modified entry", report.Entries)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	report := &Report{Entries: []Entry{
+		{Rule: "go/testing", Format: "cursor", Destination: ".cursor/rules/go_testing.mdc", State: StateUpToDate, Mode: "symlink"},
+		{Format: "cursor", Destination: ".cursor/rules/mine.mdc", State: StateForeign},
+	}}
+
+	var plain bytes.Buffer
+	if err := Write(&plain, report, FormatPlain); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	wantPlain := "up-to-date cursor .cursor/rules/go_testing.mdc (go/testing)\nforeign cursor .cursor/rules/mine.mdc\n"
+	if plain.String() != wantPlain {
+		t.Errorf("Plain output =\n%s\nwant\n%s", plain.String(), wantPlain)
+	}
+
+	var table bytes.Buffer
+	if err := Write(&table, report, FormatTable); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if !strings.HasPrefix(table.String(), "STATE") || !strings.Contains(table.String(), "1 up-to-date, 1 foreign") {
+		t.Errorf("Unexpected table output:\n%s", table.String())
+	}
+
+	var out bytes.Buffer
+	if err := Write(&out, report, FormatJSON); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	if len(decoded.Entries) != 2 || decoded.Entries[0].State != StateUpToDate {
+		t.Errorf("Decoded %+v", decoded)
+	}
+
+	var lines bytes.Buffer
+	if err := Write(&lines, report, FormatNDJSON); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	records := strings.Split(strings.TrimSpace(lines.String()), "\n")
+	if len(records) != 2 {
+		t.Fatalf("NDJSON output has %d lines, want 2:\n%s", len(records), lines.String())
+	}
+	var entry Entry
+	if err := json.Unmarshal([]byte(records[1]), &entry); err != nil || entry.State != StateForeign {
+		t.Errorf("Decoded %+v (%v), want the foreign entry", entry, err)
+	}
+
+	if err := Write(&out, report, "xml"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestCheckTemplateVars(t *testing.T) {
+	tmpDir := t.TempDir()
+	rule := writeRule(t, tmpDir, "", "conventions", "---\ndescription: Conventions\nvars:\n  project: app\n---\n# {{ project }}\n")
+
+	l := linker.NewLinker(tmpDir)
+	if err := l.LinkRule(rule, "cursor"); err != nil {
+		t.Fatalf("LinkRule failed: %v", err)
+	}
+	report, err := New(l, []*models.Rule{rule}).Check("cursor")
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if len(report.Entries) != 1 || report.Entries[0].State != StateUpToDate || report.Entries[0].Vars["project"] != "app" {
+		t.Fatalf("Entries = %+v, want one up-to-date entry with its vars", report.Entries)
+	}
+
+	l = linker.NewLinker(tmpDir)
+	l.SetVars(map[string]string{"project": "billing"})
+	report, err = New(l, []*models.Rule{rule}).Check("cursor")
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if entry := report.Entries[0]; entry.State != StateUpstreamModified || entry.Detail != "template variables changed" {
+		t.Errorf("Entry = %+v, want upstream-modified because the template variables changed", entry)
+	}
+}
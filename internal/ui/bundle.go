@@ -0,0 +1,93 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/circleci/llm-agent-rules/internal/linker"
+	"github.com/circleci/llm-agent-rules/internal/rules"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// bundleItem represents a bundle of rules at the top of the list, selecting
+// or deselecting all its rules at once
+type bundleItem struct {
+	bundle *models.Bundle
+	rules  []*models.Rule
+	// err is why the bundle cannot be resolved, if it cannot
+	err error
+}
+
+// newBundleItems returns the list items of the bundles defined by the rules repository
+func newBundleItems(rulesManager *rules.Manager) []list.Item {
+	items := make([]list.Item, 0, len(rulesManager.Bundles))
+	for _, bundle := range rulesManager.Bundles {
+		selected, err := rulesManager.ResolveBundle(bundle.Name)
+		items = append(items, bundleItem{bundle: bundle, rules: selected, err: err})
+	}
+	return items
+}
+
+// FilterValue implements list.Item, so "bundle:" filters the bundles
+func (b bundleItem) FilterValue() string {
+	return rules.BundlePrefix + b.bundle.Name
+}
+
+// Title returns the bundle name with the number of rules in it
+func (b bundleItem) Title() string {
+	if b.err != nil {
+		return b.bundle.Name + " (invalid)"
+	}
+	return fmt.Sprintf("%s (%d rules)", b.bundle.Name, len(b.rules))
+}
+
+// Description returns the bundle description, or why it is invalid
+func (b bundleItem) Description() string {
+	if b.err != nil {
+		return b.err.Error()
+	}
+	return b.bundle.Description
+}
+
+// allSelected reports whether every rule of the bundle is selected
+func (b bundleItem) allSelected() bool {
+	for _, rule := range b.rules {
+		if !rule.Selected {
+			return false
+		}
+	}
+	return len(b.rules) > 0
+}
+
+// toggle selects every rule of the bundle, or deselects them all when they
+// already are
+func (b bundleItem) toggle() {
+	selected := !b.allSelected()
+	for _, rule := range b.rules {
+		rule.Selected = selected
+	}
+}
+
+// renderBundlePreview renders the description and rules of a bundle for the preview pane
+func renderBundlePreview(b bundleItem) string {
+	var s strings.Builder
+	s.WriteString(titleStyle.Render("Bundle: "+b.bundle.Name) + "\n")
+	if b.bundle.Description != "" {
+		s.WriteString(b.bundle.Description + "\n")
+	}
+	if b.err != nil {
+		s.WriteString("\nInvalid: " + b.err.Error() + "\n")
+		return s.String()
+	}
+
+	s.WriteString("\n")
+	for _, rule := range b.rules {
+		mark := "  "
+		if rule.Selected {
+			mark = "✓ "
+		}
+		s.WriteString(mark + linker.RuleID(rule) + "\n")
+	}
+	return s.String()
+}
@@ -0,0 +1,206 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/circleci/llm-agent-rules/internal/linker"
+	"github.com/circleci/llm-agent-rules/internal/status"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// ConfirmMsg asks the Manager to confirm an action in a modal. Msg is sent
+// on to the model when the user accepts.
+type ConfirmMsg struct {
+	Title   string
+	Message string
+	Msg     tea.Msg
+}
+
+// ConfirmCmd asks the user to confirm before msg is sent
+func ConfirmCmd(title, message string, msg tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return ConfirmMsg{Title: title, Message: message, Msg: msg}
+	}
+}
+
+// applyChangesMsg applies a confirmed change set
+type applyChangesMsg struct {
+	changes changeSet
+}
+
+// pruneMsg removes the confirmed broken and orphaned rules
+type pruneMsg struct {
+	entries []status.Entry
+}
+
+// changeSet is what applying the selection changes in the target project
+type changeSet struct {
+	// link holds the selected rules that are not installed
+	link []*models.Rule
+	// relink holds the selected installed rules whose source changed or
+	// whose symlink is broken
+	relink []*models.Rule
+	// unlink holds the installed rules that were deselected
+	unlink []*models.Rule
+	// requiredBy maps the rules kept or linked because a selected rule
+	// requires them to the rule requiring them
+	requiredBy map[*models.Rule]*models.Rule
+}
+
+// empty reports whether applying the change set would change nothing
+func (c changeSet) empty() bool {
+	return len(c.link)+len(c.relink)+len(c.unlink) == 0
+}
+
+// describe lists the changes, one rule per line
+func (c changeSet) describe() string {
+	var b strings.Builder
+	for _, group := range []struct {
+		mark  string
+		rules []*models.Rule
+	}{{"+ link", c.link}, {"~ relink", c.relink}, {"- unlink", c.unlink}} {
+		for _, rule := range group.rules {
+			fmt.Fprintf(&b, "%s %s", group.mark, linker.RuleID(rule))
+			if by, ok := c.requiredBy[rule]; ok {
+				fmt.Fprintf(&b, " (required by %s)", linker.RuleID(by))
+			}
+			b.WriteString("\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// summary describes the applied change set in one line
+func (c changeSet) summary() string {
+	parts := make([]string, 0, 3)
+	if len(c.link) > 0 {
+		parts = append(parts, fmt.Sprintf("linked %d", len(c.link)))
+	}
+	if len(c.relink) > 0 {
+		parts = append(parts, fmt.Sprintf("relinked %d", len(c.relink)))
+	}
+	if len(c.unlink) > 0 {
+		parts = append(parts, fmt.Sprintf("unlinked %d", len(c.unlink)))
+	}
+	return "✓ Successfully " + strings.Join(parts, ", ") + " rules!"
+}
+
+// planChanges compares the selection, and the rules it requires, with what
+// is installed. Rules that conflict with each other or with the installed
+// rules are an error.
+func (m *Model) planChanges() (changeSet, error) {
+	changes := changeSet{requiredBy: make(map[*models.Rule]*models.Rule)}
+	requirements, err := m.rulesManager.Requirements(m.rulesManager.GetSelectedRules())
+	if err != nil {
+		return changes, err
+	}
+	for _, requirement := range requirements {
+		changes.requiredBy[requirement.Rule] = requirement.RequiredBy
+	}
+
+	kept := make([]*models.Rule, 0)
+	for _, rule := range m.rulesManager.Rules {
+		_, required := changes.requiredBy[rule]
+		wanted := rule.Selected || required
+		switch {
+		case wanted && !rule.IsInstalled:
+			changes.link = append(changes.link, rule)
+		case wanted && needsRelink(m.states[linker.RuleID(rule)]):
+			changes.relink = append(changes.relink, rule)
+			kept = append(kept, rule)
+		case !wanted && rule.IsInstalled:
+			changes.unlink = append(changes.unlink, rule)
+		case rule.IsInstalled:
+			kept = append(kept, rule)
+		}
+	}
+
+	if conflicts := m.rulesManager.Conflicts(changes.link, kept); len(conflicts) > 0 {
+		messages := make([]string, 0, len(conflicts))
+		for _, conflict := range conflicts {
+			messages = append(messages, conflict.Error())
+		}
+		return changes, fmt.Errorf("cannot link conflicting rules: %s", strings.Join(messages, "; "))
+	}
+	return changes, nil
+}
+
+// selectRequired selects the rules kept or linked because selected rules
+// require them, once the change set is applied
+func (c changeSet) selectRequired() {
+	for rule := range c.requiredBy {
+		rule.Selected = true
+	}
+}
+
+// needsRelink reports whether linking an installed rule again brings it up
+// to date. Locally modified copies are left alone, since relinking would
+// overwrite the edits.
+func needsRelink(state status.State) bool {
+	return state == status.StateUpstreamModified || state == status.StateBrokenSymlink
+}
+
+// applyChanges unlinks and links the rules of the change set in one
+// transaction, so nothing changes if any of them fails
+func (m *Model) applyChanges(changes changeSet) error {
+	return m.linker.Transact(func() error {
+		// Unlink first, so the file names of removed rules are free again
+		for _, rule := range changes.unlink {
+			if err := m.linker.UnlinkRule(linker.RuleID(rule), m.editor); err != nil {
+				return fmt.Errorf("failed to unlink %s: %w", linker.RuleID(rule), err)
+			}
+		}
+
+		install := append(append([]*models.Rule{}, changes.link...), changes.relink...)
+		if len(install) == 0 {
+			return nil
+		}
+		return m.linker.LinkRules(install, m.editor)
+	})
+}
+
+// pruneCandidates returns the broken symlinks and orphaned rules installed
+// for the selected editor
+func (m *Model) pruneCandidates() []status.Entry {
+	candidates := make([]status.Entry, 0)
+	if m.statusReport == nil {
+		return candidates
+	}
+	for _, entry := range m.statusReport.Entries {
+		if entry.Format == m.editor && (entry.State == status.StateBrokenSymlink || entry.State == status.StateOrphaned) {
+			candidates = append(candidates, entry)
+		}
+	}
+	return candidates
+}
+
+// describePrune lists the entries to prune, one per line
+func describePrune(entries []status.Entry) string {
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		lines = append(lines, fmt.Sprintf("- %s (%s)", entry.Destination, entry.State))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// prune removes the given entries in one transaction. Rules recorded in the
+// lockfile are unlinked; files installed before the lockfile existed are
+// removed.
+func (m *Model) prune(entries []status.Entry) error {
+	return m.linker.Transact(func() error {
+		for _, entry := range entries {
+			var err error
+			if entry.Rule != "" {
+				err = m.linker.UnlinkRule(entry.Rule, entry.Format)
+			} else {
+				err = m.linker.RemoveUnrecorded(entry.Destination)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to prune %s: %w", entry.Destination, err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,16 @@
+package components
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Buttons of the confirmation modal
+const (
+	ConfirmButton = "Apply"
+	CancelButton  = "Cancel"
+)
+
+// NewConfirmModal creates a modal asking to confirm the changes described by message
+func NewConfirmModal(title, message string) tea.Model {
+	return NewModal(title, message, []string{ConfirmButton, CancelButton})
+}
@@ -4,10 +4,11 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-func NewEditorModal() tea.Model {
+// NewEditorModal creates a modal offering the given editors as choices
+func NewEditorModal(editors []string) tea.Model {
 	return NewModal(
 		"Select your editor",
 		"Please select your preferred editor:",
-		[]string{"Cursor", "Windsurf"},
+		editors,
 	)
 }
@@ -0,0 +1,14 @@
+package components
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// NewTagModal creates a modal offering the given tags as choices
+func NewTagModal(tags []string) tea.Model {
+	return NewModal(
+		"Filter by tag",
+		"Show the rules tagged:",
+		tags,
+	)
+}
@@ -0,0 +1,58 @@
+package components
+
+import (
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var textModalHintStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#A9A9A9"))
+
+// TextModal shows scrollable text, such as a diff, until it is closed
+type TextModal struct {
+	title    string
+	width    int
+	viewport viewport.Model
+}
+
+// NewTextModal creates a modal showing content in a window of the given size
+func NewTextModal(title, content string, width, height int) *TextModal {
+	// Leave room for the border, padding, title and hint
+	vp := viewport.New(max(width-modalStyle.GetHorizontalFrameSize(), 10), max(height-modalStyle.GetVerticalFrameSize()-3, 3))
+	vp.SetContent(content)
+	return &TextModal{title: title, width: width, viewport: vp}
+}
+
+func (m *TextModal) Init() tea.Cmd {
+	return nil
+}
+
+func (m *TextModal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "enter", "esc":
+			return m, CloseModalCmd("")
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		}
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m *TextModal) View() string {
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.viewport.View(),
+		"",
+		textModalHintStyle.Render("↑/↓ scroll • enter/esc close"),
+	)
+	return lipgloss.JoinVertical(
+		lipgloss.Center,
+		m.title,
+		modalStyle.Width(m.width).Render(content),
+	)
+}
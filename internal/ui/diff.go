@@ -0,0 +1,41 @@
+package ui
+
+import (
+	"errors"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/circleci/llm-agent-rules/internal/diff"
+	"github.com/circleci/llm-agent-rules/internal/linker"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// ShowTextMsg asks the Manager to show scrollable text in a modal
+type ShowTextMsg struct {
+	Title string
+	Text  string
+}
+
+// ShowTextCmd shows text in a modal
+func ShowTextCmd(title, text string) tea.Cmd {
+	return func() tea.Msg {
+		return ShowTextMsg{Title: title, Text: text}
+	}
+}
+
+// showDiff shows how the installed copy of rule differs from its source
+func (m *Model) showDiff(rule *models.Rule) tea.Cmd {
+	id := linker.RuleID(rule)
+	comparison, err := m.linker.Compare(rule, m.editor)
+	if errors.Is(err, linker.ErrNotInstalled) {
+		return m.showSuccess(id + " is not installed")
+	}
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	if !comparison.Changed() {
+		return m.showSuccess(id + " matches its source")
+	}
+
+	return ShowTextCmd("Diff: "+id, diff.Colorize(comparison.Unified()))
+}
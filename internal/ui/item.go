@@ -1,16 +1,23 @@
 package ui
 
 import (
+	"github.com/circleci/llm-agent-rules/internal/rules"
 	"github.com/circleci/llm-agent-rules/pkg/models"
 )
 
 // item represents a rule in the list
 type item struct {
 	rule *models.Rule
+	// layered is set when rules come from more than one source
+	layered bool
 }
 
-// FilterValue implements list.Item
+// FilterValue implements list.Item. With layered sources the source ID is
+// included, so "team:" filters the rules of one source.
 func (i item) FilterValue() string {
+	if i.layered {
+		return rules.QualifiedName(i.rule)
+	}
 	return i.getRuleName()
 }
 
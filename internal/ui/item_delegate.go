@@ -3,10 +3,12 @@ package ui
 import (
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/circleci/llm-agent-rules/internal/status"
 )
 
 // Custom delegate for item rendering
@@ -17,11 +19,19 @@ type itemDelegate struct {
 		SelectedTitle lipgloss.Style
 		SelectedDesc  lipgloss.Style
 		CheckMark     lipgloss.Style
+		Drift         lipgloss.Style
+		Source        lipgloss.Style
+		Recommended   lipgloss.Style
+		Bundle        lipgloss.Style
 	}
+	// states holds the install state of each rule by ID, kept up to date by the model
+	states map[string]status.State
+	// recommended holds the number of target files each recommended rule applies to by ID
+	recommended map[string]int
 }
 
-func newItemDelegate() itemDelegate {
-	d := itemDelegate{}
+func newItemDelegate(states map[string]status.State, recommended map[string]int) itemDelegate {
+	d := itemDelegate{states: states, recommended: recommended}
 
 	d.styles.NormalTitle = lipgloss.NewStyle().
 		Foreground(lipgloss.Color("#FF69B4")). // Hot pink
@@ -40,6 +50,19 @@ func newItemDelegate() itemDelegate {
 	d.styles.CheckMark = lipgloss.NewStyle().
 		Foreground(lipgloss.Color("#00FF00")) // Bright green
 
+	d.styles.Drift = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#FFA500")) // Orange
+
+	d.styles.Source = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#A9A9A9")) // Dark gray
+
+	d.styles.Recommended = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#FFD700")) // Gold
+
+	d.styles.Bundle = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#BA55D3")). // Medium orchid
+		Bold(true)
+
 	return d
 }
 
@@ -48,6 +71,10 @@ func (d itemDelegate) Spacing() int                              { return 1 } //
 func (d itemDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd { return nil }
 
 func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	if b, ok := listItem.(bundleItem); ok {
+		d.renderBundle(w, index == m.Index(), b)
+		return
+	}
 	i, ok := listItem.(item)
 	if !ok {
 		return
@@ -76,11 +103,47 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 	}
 
 	// Add appropriate indicator based on rule status
-	if rule.IsInstalled {
+	if state, ok := d.states[i.getRuleName()]; ok && rule.IsInstalled && state != status.StateUpToDate {
+		title = title + " [INSTALLED] " + d.styles.Drift.Render("["+string(state)+"]")
+	} else if rule.IsInstalled {
 		title = title + " [INSTALLED]"
 	} else if rule.Selected {
 		title = title + " ✓"
 	}
 
+	// Installed rules that were deselected are unlinked when the selection is applied
+	if rule.IsInstalled && !rule.Selected {
+		title = title + " " + d.styles.Drift.Render("✗")
+	}
+
+	// Show how many files of the target project a recommended rule applies to
+	if files := d.recommended[i.getRuleName()]; files > 0 {
+		title = title + " " + d.styles.Recommended.Render(fmt.Sprintf("★ %d files", files))
+	}
+
+	// Show where the rule comes from when sources are layered
+	if i.layered {
+		origin := "[" + rule.Source + "]"
+		if len(rule.Overrides) > 0 {
+			origin = "[" + rule.Source + ", overrides " + strings.Join(rule.Overrides, ", ") + "]"
+		}
+		title = title + " " + d.styles.Source.Render(origin)
+	}
+
 	_, _ = fmt.Fprintf(w, "%s\n%s", title, desc)
 }
+
+// renderBundle renders a bundle, marked when all its rules are selected
+func (d itemDelegate) renderBundle(w io.Writer, selected bool, b bundleItem) {
+	indent := "    "
+	titleStyle, descStyle := d.styles.Bundle, d.styles.NormalDesc
+	if selected {
+		titleStyle, descStyle = d.styles.SelectedTitle, d.styles.SelectedDesc
+	}
+
+	title := indent + titleStyle.Render("▸ "+b.Title())
+	if b.allSelected() {
+		title = title + " ✓"
+	}
+	_, _ = fmt.Fprintf(w, "%s\n%s", title, indent+descStyle.Render(b.Description()))
+}
@@ -2,6 +2,7 @@ package ui
 
 import (
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/circleci/llm-agent-rules/internal/linker"
 	"github.com/circleci/llm-agent-rules/internal/ui/components"
 	overlay "github.com/rmhubbert/bubbletea-overlay"
 )
@@ -12,6 +13,10 @@ type Manager struct {
 	background   tea.Model
 	overlay      *overlay.Model
 	showModal    bool
+	width        int
+	height       int
+	// onClose turns the button chosen in the current modal into a command
+	onClose func(choice string) tea.Cmd
 }
 
 // NewManager creates a new Manager instance
@@ -47,21 +52,54 @@ func (m *Manager) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		// Remember the window size for sizing modals, and pass it on
+		m.width, m.height = msg.Width, msg.Height
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "e":
 			if !m.showModal {
-				m.currentModal = components.NewEditorModal()
-				m.overlay.Foreground = m.currentModal
+				m.open(components.NewEditorModal(editorChoices()), func(choice string) tea.Cmd {
+					return ChangeEditorCmd(choice)
+				})
+			} else {
+				m.showModal = false
 			}
-			m.showModal = !m.showModal
 			return m, nil
+		case "esc":
+			if m.showModal {
+				m.showModal = false
+				return m, nil
+			}
 		case "q", "ctrl+c":
 			return m, tea.Quit
 		}
+		if m.showModal {
+			// Keys belong to the modal while it is open
+			return m, nil
+		}
+	case ConfirmMsg:
+		m.open(components.NewConfirmModal(msg.Title, msg.Message), func(choice string) tea.Cmd {
+			if choice != components.ConfirmButton {
+				return nil
+			}
+			return func() tea.Msg { return msg.Msg }
+		})
+		return m, nil
+	case ChooseTagMsg:
+		m.open(components.NewTagModal(msg.Choices), func(choice string) tea.Cmd {
+			return FilterTagCmd(msg.Tags[choice])
+		})
+		return m, nil
+	case ShowTextMsg:
+		m.open(components.NewTextModal(msg.Title, msg.Text, m.width*4/5, m.height*4/5), nil)
+		return m, nil
 	case components.CloseModalMsg:
 		m.showModal = false
-		return m, ChangeEditorCmd(string(msg))
+		if m.onClose == nil {
+			return m, nil
+		}
+		return m, m.onClose(string(msg))
 	}
 
 	bg, bgCmd := m.background.Update(msg)
@@ -70,6 +108,28 @@ func (m *Manager) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, bgCmd
 }
 
+// open shows modal over the background, calling onClose with the button chosen
+func (m *Manager) open(modal tea.Model, onClose func(choice string) tea.Cmd) {
+	m.currentModal = modal
+	m.overlay.Foreground = modal
+	m.onClose = onClose
+	m.showModal = true
+}
+
+// editorChoices returns the display names of every registered editor adapter,
+// marking the default one
+func editorChoices() []string {
+	choices := make([]string, 0)
+	for _, adapter := range linker.Adapters() {
+		name := adapter.DisplayName()
+		if adapter.Name() == linker.DefaultEditor {
+			name += " (default)"
+		}
+		choices = append(choices, name)
+	}
+	return choices
+}
+
 // View renders the Manager's view
 func (m *Manager) View() string {
 	if m.showModal {
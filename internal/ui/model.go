@@ -11,6 +11,8 @@ import (
 	"github.com/circleci/llm-agent-rules/internal/config"
 	"github.com/circleci/llm-agent-rules/internal/linker"
 	"github.com/circleci/llm-agent-rules/internal/rules"
+	"github.com/circleci/llm-agent-rules/internal/source"
+	"github.com/circleci/llm-agent-rules/internal/status"
 	"github.com/circleci/llm-agent-rules/pkg/models"
 )
 
@@ -60,22 +62,36 @@ type Model struct {
 	successMessage string
 	showingSuccess bool
 	successTimer   int
-	editor         string // Stores the selected editor
+	editor         string // Name of the selected editor adapter
+	states         map[string]status.State
+	statusReport   *status.Report
+	showingStatus  bool
+	preview        preview
+
+	// recommended holds how many target files each recommended rule applies
+	// to by ID, shared with the delegate, once the target has been scanned
+	recommended      map[string]int
+	recommendations  []rules.Recommendation
+	scanned          bool
+	recommendedFirst bool
 }
 
 // New creates a new UI model
-func New(cfg *config.Config, rulesManager *rules.Manager, linker *linker.Linker) *Model {
-	// Convert rules to list items with styles
-	items := []list.Item{}
+func New(cfg *config.Config, rulesManager *rules.Manager, ruleLinker *linker.Linker) *Model {
+	// Convert bundles and rules to list items with styles, bundles first
+	items := newBundleItems(rulesManager)
 
 	for _, rule := range rulesManager.Rules {
 		items = append(items, item{
-			rule: rule,
+			rule:    rule,
+			layered: rulesManager.Layered(),
 		})
 	}
 
-	// Create custom delegate
-	delegate := newItemDelegate()
+	// Create custom delegate, which shares the install states with the model
+	states := make(map[string]status.State)
+	recommended := make(map[string]int)
+	delegate := newItemDelegate(states, recommended)
 
 	// Create the list with custom styling
 	l := list.New(items, delegate, 20, 20) // Start with reasonable defaults
@@ -85,19 +101,34 @@ func New(cfg *config.Config, rulesManager *rules.Manager, linker *linker.Linker)
 	l.Styles.HelpStyle = helpStyle
 	l.SetShowStatusBar(true)
 	l.SetFilteringEnabled(true)
+	l.Filter = tagFilter(items)
 	l.SetShowHelp(true)
 
 	return &Model{
 		list:           l,
 		rulesManager:   rulesManager,
-		linker:         linker,
+		linker:         ruleLinker,
 		config:         cfg,
 		width:          80, // Default width
 		height:         24, // Default height
 		successMessage: "",
 		showingSuccess: false,
 		successTimer:   0,
-		editor:         "Cursor (default)", // Default editor value
+		editor:         linker.DefaultEditor, // Default editor value
+		states:         states,
+		preview:        newPreview(),
+		recommended:    recommended,
+	}
+}
+
+// SetEditor selects the editor adapter rules are linked with and resets the
+// selection to the rules installed for it, so a selection made for another
+// editor is never applied to this one
+func (m *Model) SetEditor(editor string) {
+	m.editor = editor
+	m.refreshInstallStatus()
+	for _, rule := range m.rulesManager.Rules {
+		rule.Selected = rule.IsInstalled
 	}
 }
 
@@ -114,13 +145,21 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 
-		m.setListHeight(m.height)
-		m.list.SetWidth(m.width)
+		m.resize()
 		return m, nil
 
 	case ChangeEditorMsg:
 		// Store the selected editor in the model
-		m.editor = string(msg)
+		adapter, err := linker.LookupAdapter(string(msg))
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.SetEditor(adapter.Name())
+		return m, nil
+
+	case FilterTagMsg:
+		m.filterByTag(string(msg))
 		return m, nil
 
 	case tea.KeyMsg:
@@ -142,7 +181,54 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "q", "ctrl+c":
 			return m, tea.Quit
 
+		case "s":
+			// Toggle the install status view
+			m.showingStatus = !m.showingStatus
+			if m.showingStatus {
+				m.refreshInstallStatus()
+			}
+			return m, nil
+
+		case "r":
+			// Toggle between the rendered rule and its raw file
+			m.preview.toggleRaw()
+			return m, nil
+
+		case "J", "K", "ctrl+d", "ctrl+u":
+			// Scroll the preview without moving the list cursor
+			switch msg.String() {
+			case "J":
+				m.preview.viewport.ScrollDown(1)
+			case "K":
+				m.preview.viewport.ScrollUp(1)
+			case "ctrl+d":
+				m.preview.viewport.HalfPageDown()
+			case "ctrl+u":
+				m.preview.viewport.HalfPageUp()
+			}
+			return m, nil
+
+		case "R":
+			// List the rules recommended for the target project's files first
+			return m, m.toggleRecommended()
+
+		case "t":
+			// Offer the tags to filter the rules by
+			return m, m.chooseTag()
+
+		case "D":
+			// Show how the highlighted installed rule differs from its source
+			if i, ok := m.list.SelectedItem().(item); ok {
+				return m, m.showDiff(i.rule)
+			}
+			return m, nil
+
 		case "enter":
+			if b, ok := m.list.SelectedItem().(bundleItem); ok {
+				// Select every rule of the bundle, or deselect them all
+				b.toggle()
+				return m, nil
+			}
 			i, ok := m.list.SelectedItem().(item)
 			if ok {
 				m.selectedRule = i.rule
@@ -171,30 +257,47 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 
 		case "l":
-			// Link selected rules
-			selected := m.rulesManager.GetSelectedRules()
-			if len(selected) > 0 {
-				err := m.linker.LinkRules(selected, m.getEditorPathName())
-				if err != nil {
-					m.err = err
-				} else {
-					// Update installation status for the newly linked rules
-					for _, rule := range selected {
-						rule.IsInstalled = true
-					}
-
-					// Show success message
-					m.successMessage = fmt.Sprintf("✓ Successfully linked %d rules!", len(selected))
-					m.showingSuccess = true
-
-					// Clear success message after a short delay
-					return m, tea.Tick(time.Second*2, func(time.Time) tea.Msg {
-						return tickMsg{}
-					})
-				}
+			// Confirm the links and unlinks that make the installed rules match the selection
+			changes, err := m.planChanges()
+			m.err = err
+			if err != nil {
+				return m, nil
+			}
+			if changes.empty() {
+				return m, m.showSuccess("Installed rules already match the selection")
 			}
+			return m, ConfirmCmd("Apply changes?", changes.describe(), applyChangesMsg{changes: changes})
+
+		case "p":
+			// Confirm removing broken symlinks and rules whose source is gone
+			m.refreshInstallStatus()
+			entries := m.pruneCandidates()
+			if len(entries) == 0 {
+				return m, m.showSuccess("Nothing to prune")
+			}
+			return m, ConfirmCmd("Prune these rules?", describePrune(entries), pruneMsg{entries: entries})
+		}
+
+	case applyChangesMsg:
+		if err := m.applyChanges(msg.changes); err != nil {
+			m.err = err
+			m.refreshInstallStatus()
+			return m, nil
+		}
+		m.err = nil
+		msg.changes.selectRequired()
+		m.refreshInstallStatus()
+		return m, m.showSuccess(msg.changes.summary())
+
+	case pruneMsg:
+		err := m.prune(msg.entries)
+		m.refreshInstallStatus()
+		if err != nil {
+			m.err = err
 			return m, nil
 		}
+		m.err = nil
+		return m, m.showSuccess(fmt.Sprintf("✓ Successfully pruned %d rules!", len(msg.entries)))
 	}
 
 	// Handle timer tick for clearing success message
@@ -212,9 +315,18 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 // Add a tick message type for handling the timer
 type tickMsg struct{}
 
+// showSuccess shows message in the status bar and clears it after a short delay
+func (m *Model) showSuccess(message string) tea.Cmd {
+	m.successMessage = message
+	m.showingSuccess = true
+	return tea.Tick(time.Second*2, func(time.Time) tea.Msg {
+		return tickMsg{}
+	})
+}
+
 // View renders the UI
 func (m *Model) View() string {
-	m.setListHeight(m.height)
+	m.resize()
 
 	// Create header with title
 	titleStyle := lipgloss.NewStyle().
@@ -246,8 +358,13 @@ func (m *Model) View() string {
 		status = statusStyle.Render(m.updateStatusText())
 	}
 
-	// Get the list view (main content)
+	// Get the list view (main content), or the install status in its place
 	listView := m.list.View()
+	if m.showingStatus {
+		listView = m.statusView()
+	} else if m.previewVisible() {
+		listView = lipgloss.JoinHorizontal(lipgloss.Top, listView, m.previewView())
+	}
 
 	// Calculate widths for the bottom panels
 	bottomWidth := max(m.width-4, 40)
@@ -296,6 +413,7 @@ func (m *Model) View() string {
 func (m *Model) updateStatusText() string {
 	installedCount := 0
 	newlySelectedCount := 0
+	deselectedCount := 0
 
 	for _, rule := range m.rulesManager.Rules {
 		// Count installed rules
@@ -307,10 +425,51 @@ func (m *Model) updateStatusText() string {
 		if rule.Selected && !rule.IsInstalled {
 			newlySelectedCount++
 		}
+
+		// If the rule is installed but no longer selected, it will be unlinked
+		if !rule.Selected && rule.IsInstalled {
+			deselectedCount++
+		}
+	}
+
+	return fmt.Sprintf("%d rules already installed • %d new rules selected • %d to unlink",
+		installedCount, newlySelectedCount, deselectedCount)
+}
+
+// previewVisible reports whether the window is wide enough for the preview pane
+func (m *Model) previewVisible() bool {
+	return m.width >= previewMinWidth
+}
+
+// resize lays out the list, and the preview pane next to it, for the window size
+func (m *Model) resize() {
+	m.setListHeight(m.height)
+	if !m.previewVisible() {
+		m.list.SetWidth(m.width)
+		return
 	}
 
-	return fmt.Sprintf("%d rules already installed • %d new rules selected",
-		installedCount, newlySelectedCount)
+	listWidth := m.width / 2
+	m.list.SetWidth(listWidth)
+	m.preview.setSize(
+		m.width-listWidth-previewStyle.GetHorizontalFrameSize(),
+		m.list.Height()-previewStyle.GetVerticalFrameSize(),
+	)
+}
+
+// previewView renders the preview pane for the highlighted rule or bundle
+func (m *Model) previewView() string {
+	if b, ok := m.list.SelectedItem().(bundleItem); ok {
+		m.preview.showBundle(b)
+		return previewStyle.Render(m.preview.View())
+	}
+
+	var rule *models.Rule
+	if i, ok := m.list.SelectedItem().(item); ok {
+		rule = i.rule
+	}
+	m.preview.show(rule)
+	return previewStyle.Render(m.preview.View())
 }
 
 func (m *Model) setListHeight(height int) {
@@ -330,6 +489,16 @@ func (m *Model) setListHeight(height int) {
 func (m *Model) createInfoContent() string {
 	// Get paths for display and ensure they're not empty
 	rulesRepoPath := m.config.RulesRepoPath
+	if m.config.RulesSource != "" {
+		rulesRepoPath = m.config.RulesSource + " @ " + source.ShortCommit(m.config.RulesCommit)
+	}
+	if m.rulesManager.Layered() {
+		ids := make([]string, 0, len(m.rulesManager.Sources))
+		for _, src := range m.rulesManager.Sources {
+			ids = append(ids, src.ID)
+		}
+		rulesRepoPath = fmt.Sprintf("%s (%d shadowed)", strings.Join(ids, " < "), len(m.rulesManager.Shadowed))
+	}
 	if rulesRepoPath == "" {
 		rulesRepoPath = "Not set"
 	}
@@ -348,11 +517,19 @@ func (m *Model) createInfoContent() string {
 	infoBuilder.WriteString(targetPath)
 	infoBuilder.WriteString("\n")
 	infoBuilder.WriteString("• Editor: ")
-	infoBuilder.WriteString(m.editor)
+	infoBuilder.WriteString(m.editorDisplayName())
+	infoBuilder.WriteString("\n")
+	infoBuilder.WriteString("• Mode: ")
+	infoBuilder.WriteString(string(m.linker.Mode))
 	infoBuilder.WriteString("\n\n")
 	infoBuilder.WriteString("Indicators:\n")
 	infoBuilder.WriteString("• [INSTALLED]: Rule is already installed\n")
-	infoBuilder.WriteString("• ✓: Rule is selected for installation")
+	infoBuilder.WriteString("• [upstream-modified], [locally-modified]: Installed rule differs from its source\n")
+	if m.rulesManager.Layered() {
+		infoBuilder.WriteString("• [team, overrides org]: Source of the rule and the sources it overrides\n")
+	}
+	infoBuilder.WriteString("• ✓: Rule is selected for installation, or every rule of a bundle is\n")
+	infoBuilder.WriteString("• ✗: Installed rule is deselected and will be unlinked")
 
 	return infoBuilder.String()
 }
@@ -360,24 +537,66 @@ func (m *Model) createInfoContent() string {
 func (m *Model) createHelpContent() string {
 	// Create content for both bottom panels
 	return "Controls:\n" +
-		"• Enter: Toggle selection\n" +
+		"• Enter: Toggle selection (all rules of a bundle)\n" +
 		"• a: Select all\n" +
 		"• d: Deselect all\n" +
 		"• e: Open editor modal\n" +
-		"• l: Link selected rules\n" +
-		"• /: Filter rules\n" +
+		"• l: Apply selection (link and unlink)\n" +
+		"• p: Prune broken and orphaned rules\n" +
+		"• s: Toggle install status\n" +
+		"• D: Diff installed rule with source\n" +
+		"• r: Toggle raw preview\n" +
+		"• J/K: Scroll preview\n" +
+		"• /: Filter rules (tag:name and -tag:name filter by tag)\n" +
+		"• t: Filter by tag\n" +
+		"• R: List recommended rules first\n" +
 		"• q: Quit"
 }
 
-func (m *Model) getEditorPathName() string {
-	var editor string
-	if strings.Contains(m.editor, "(default)") {
-		editor = strings.Replace(m.editor, " (default)", "", -1)
-	} else {
-		editor = m.editor
+// editorDisplayName returns the human readable name of the selected editor
+func (m *Model) editorDisplayName() string {
+	adapter, err := linker.LookupAdapter(m.editor)
+	if err != nil {
+		return m.editor
 	}
+	return adapter.DisplayName()
+}
 
-	editor = strings.ToLower(editor)
+// refreshInstallStatus recomputes which rules are installed for the selected
+// editor and how each installed rule compares with its source
+func (m *Model) refreshInstallStatus() {
+	for _, rule := range m.rulesManager.Rules {
+		rule.IsInstalled = m.linker.IsRuleLinked(rule, m.editor)
+	}
 
-	return fmt.Sprintf(".%s", editor)
+	for id := range m.states {
+		delete(m.states, id)
+	}
+	report, err := status.New(m.linker, m.rulesManager.All()).Check(m.editor)
+	if err != nil {
+		m.statusReport = nil
+		return
+	}
+	m.statusReport = report
+	for id, state := range report.RuleStates(m.editor) {
+		m.states[id] = state
+	}
+}
+
+// statusView renders the install status of the selected editor as a table
+func (m *Model) statusView() string {
+	if m.statusReport == nil {
+		return "Install status is unavailable"
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Install Status: "+m.editorDisplayName()) + "\n\n")
+	if len(m.statusReport.Entries) == 0 {
+		b.WriteString("No rules installed\n")
+		return b.String()
+	}
+	if err := status.Write(&b, m.statusReport, status.FormatTable); err != nil {
+		return fmt.Sprintf("Error rendering status: %v", err)
+	}
+	return b.String()
 }
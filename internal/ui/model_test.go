@@ -1,8 +1,16 @@
 package ui
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/circleci/llm-agent-rules/internal/config"
+	"github.com/circleci/llm-agent-rules/internal/linker"
+	"github.com/circleci/llm-agent-rules/internal/rules"
+	"github.com/circleci/llm-agent-rules/internal/status"
 	"github.com/circleci/llm-agent-rules/pkg/models"
 )
 
@@ -33,33 +41,572 @@ func TestFilterIncludesTopic(t *testing.T) {
 	}
 }
 
-func TestGetEditorPathName(t *testing.T) {
+func TestChangeEditorSelectsAdapter(t *testing.T) {
 	testCases := []struct {
-		name   string
-		editor string
-		want   string
+		name    string
+		choice  string
+		want    string
+		wantErr bool
 	}{
 		{
-			name:   "Default editor",
-			editor: "editor (default)",
-			want:   ".editor",
+			name:   "Default editor label",
+			choice: "Cursor (default)",
+			want:   "cursor",
 		},
 		{
-			name:   "Capitalized name should be lowercased",
-			editor: "Windsurf",
-			want:   ".windsurf",
+			name:   "Display name resolves to adapter name",
+			choice: "Windsurf",
+			want:   "windsurf",
+		},
+		{
+			name:    "Unknown editor keeps the current selection",
+			choice:  "Notepad",
+			want:    "cursor",
+			wantErr: true,
 		},
 	}
 
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
-			model := &Model{
-				editor: tt.editor,
-			}
+			model := New(&config.Config{}, rules.NewManager(t.TempDir()), linker.NewLinker(t.TempDir()))
 
-			if got := model.getEditorPathName(); got != tt.want {
-				t.Errorf("want %q, got %q", tt.want, got)
+			model.Update(ChangeEditorMsg(tt.choice))
+
+			if model.editor != tt.want {
+				t.Errorf("want %q, got %q", tt.want, model.editor)
+			}
+			if (model.err != nil) != tt.wantErr {
+				t.Errorf("wantErr %v, got %v", tt.wantErr, model.err)
 			}
 		})
 	}
 }
+
+func TestStatusViewShowsDrift(t *testing.T) {
+	tmpDir := t.TempDir()
+	rulesDir := filepath.Join(tmpDir, "rules")
+	if err := os.MkdirAll(rulesDir, 0755); err != nil {
+		t.Fatalf("Failed to create rules directory: %v", err)
+	}
+	rulePath := filepath.Join(rulesDir, "style.mdc")
+	if err := os.WriteFile(rulePath, []byte("# Style\n"), 0644); err != nil {
+		t.Fatalf("Failed to write rule: %v", err)
+	}
+
+	rulesManager := rules.NewManager(rulesDir)
+	if err := rulesManager.LoadRules(); err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+	ruleLinker := linker.NewLinker(t.TempDir())
+	ruleLinker.SetMode(linker.ModeCopy)
+	if err := ruleLinker.LinkRules(rulesManager.Rules, linker.DefaultEditor); err != nil {
+		t.Fatalf("LinkRules failed: %v", err)
+	}
+
+	// Change the source after the copy was installed
+	if err := os.WriteFile(rulePath, []byte("# Style\nChanged.\n"), 0644); err != nil {
+		t.Fatalf("Failed to update rule: %v", err)
+	}
+	if err := rulesManager.LoadRules(); err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+
+	model := New(&config.Config{}, rulesManager, ruleLinker)
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+
+	if !model.showingStatus {
+		t.Fatal("Expected s to show the status view")
+	}
+	if got := model.states["style"]; got != status.StateUpstreamModified {
+		t.Errorf("State = %q, want %q", got, status.StateUpstreamModified)
+	}
+	if view := model.statusView(); !strings.Contains(view, "upstream-modified") {
+		t.Errorf("Status view does not show the drift:\n%s", view)
+	}
+}
+
+func TestLayeredSourcesShowOverrides(t *testing.T) {
+	tmpDir := t.TempDir()
+	sources := []models.Source{
+		{ID: "org", Root: filepath.Join(tmpDir, "org")},
+		{ID: "team", Root: filepath.Join(tmpDir, "team")},
+	}
+	for _, source := range sources {
+		if err := os.MkdirAll(source.RulesDir(), 0755); err != nil {
+			t.Fatalf("Failed to create rules directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(source.RulesDir(), "style.mdc"), []byte("# Style from "+source.ID+"\n"), 0644); err != nil {
+			t.Fatalf("Failed to write rule: %v", err)
+		}
+	}
+
+	rulesManager := rules.NewLayeredManager(sources)
+	if err := rulesManager.LoadRules(); err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+	model := New(&config.Config{}, rulesManager, linker.NewLinker(t.TempDir()))
+
+	listItem := model.list.Items()[0]
+	if got := listItem.FilterValue(); got != "team:style" {
+		t.Errorf("FilterValue() = %q, want the source-qualified name", got)
+	}
+
+	var out strings.Builder
+	newItemDelegate(model.states, model.recommended).Render(&out, model.list, 0, listItem)
+	if !strings.Contains(out.String(), "[team, overrides org]") {
+		t.Errorf("Rendered item does not show the override:\n%s", out.String())
+	}
+	if info := model.createInfoContent(); !strings.Contains(info, "org < team (1 shadowed)") {
+		t.Errorf("Info panel does not list the sources:\n%s", info)
+	}
+}
+
+// loadTestRules writes rules with the given names and loads them
+func loadTestRules(t *testing.T, names ...string) (*rules.Manager, string) {
+	t.Helper()
+	rulesDir := filepath.Join(t.TempDir(), "rules")
+	if err := os.MkdirAll(rulesDir, 0755); err != nil {
+		t.Fatalf("Failed to create rules directory: %v", err)
+	}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(rulesDir, name+".mdc"), []byte("# "+name+"\n"), 0644); err != nil {
+			t.Fatalf("Failed to write rule: %v", err)
+		}
+	}
+	rulesManager := rules.NewManager(rulesDir)
+	if err := rulesManager.LoadRules(); err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+	return rulesManager, rulesDir
+}
+
+// confirm presses key and accepts the confirmation it asks for
+func confirm(t *testing.T, model *Model, key string) ConfirmMsg {
+	t.Helper()
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	if cmd == nil {
+		t.Fatalf("Expected %s to ask for confirmation", key)
+	}
+	msg, ok := cmd().(ConfirmMsg)
+	if !ok {
+		t.Fatalf("Expected %s to ask for confirmation, got %T", key, msg)
+	}
+	model.Update(msg.Msg)
+	return msg
+}
+
+func TestApplySelectionLinksAndUnlinks(t *testing.T) {
+	rulesManager, _ := loadTestRules(t, "kept", "removed", "added")
+	ruleLinker := linker.NewLinker(t.TempDir())
+	kept, removed, added := rulesManager.GetRuleByName("kept"), rulesManager.GetRuleByName("removed"), rulesManager.GetRuleByName("added")
+	if err := ruleLinker.LinkRules([]*models.Rule{kept, removed}, linker.DefaultEditor); err != nil {
+		t.Fatalf("LinkRules failed: %v", err)
+	}
+
+	model := New(&config.Config{}, rulesManager, ruleLinker)
+	model.SetEditor(linker.DefaultEditor)
+	removed.Selected = false
+	added.Selected = true
+
+	msg := confirm(t, model, "l")
+	for _, want := range []string{"+ link added", "- unlink removed"} {
+		if !strings.Contains(msg.Message, want) {
+			t.Errorf("Confirmation does not list %q:\n%s", want, msg.Message)
+		}
+	}
+	if strings.Contains(msg.Message, "kept") {
+		t.Errorf("Confirmation lists the unchanged rule:\n%s", msg.Message)
+	}
+
+	if model.err != nil {
+		t.Fatalf("Applying the changes failed: %v", model.err)
+	}
+	for rule, want := range map[*models.Rule]bool{kept: true, removed: false, added: true} {
+		if got := ruleLinker.IsRuleLinked(rule, linker.DefaultEditor); got != want {
+			t.Errorf("%s linked = %v, want %v", rule.Name, got, want)
+		}
+	}
+	if changes, err := model.planChanges(); err != nil || !changes.empty() {
+		t.Errorf("Installed rules do not match the selection after applying: %+v, %v", changes, err)
+	}
+}
+
+func TestChangeEditorResetsSelection(t *testing.T) {
+	rulesManager, _ := loadTestRules(t, "cursor-only", "claude-only")
+	ruleLinker := linker.NewLinker(t.TempDir())
+	cursorOnly, claudeOnly := rulesManager.GetRuleByName("cursor-only"), rulesManager.GetRuleByName("claude-only")
+	if err := ruleLinker.LinkRule(cursorOnly, "cursor"); err != nil {
+		t.Fatalf("LinkRule failed: %v", err)
+	}
+	if err := ruleLinker.LinkRule(claudeOnly, "claude"); err != nil {
+		t.Fatalf("LinkRule failed: %v", err)
+	}
+
+	model := New(&config.Config{}, rulesManager, ruleLinker)
+	model.SetEditor("cursor")
+	model.Update(ChangeEditorMsg("claude"))
+	if cursorOnly.Selected || !claudeOnly.Selected {
+		t.Errorf("Selection after changing editors: cursor-only %t, claude-only %t", cursorOnly.Selected, claudeOnly.Selected)
+	}
+
+	// Nothing changed for the new editor, so applying the selection is a no-op
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l")})
+	if !ruleLinker.IsRuleLinked(claudeOnly, "claude") || ruleLinker.IsRuleLinked(cursorOnly, "claude") {
+		t.Error("Applying the selection after changing editors changed the installed rules")
+	}
+	if changes, err := model.planChanges(); err != nil || !changes.empty() {
+		t.Errorf("Expected no changes after changing editors, got %+v, %v", changes, err)
+	}
+}
+
+func TestPruneRemovesBrokenAndOrphanedRules(t *testing.T) {
+	rulesManager, rulesDir := loadTestRules(t, "kept", "deleted")
+	targetDir := t.TempDir()
+	ruleLinker := linker.NewLinker(targetDir)
+	ruleLinker.SetMode(linker.ModeCopy)
+	if err := ruleLinker.LinkRules(rulesManager.Rules, linker.DefaultEditor); err != nil {
+		t.Fatalf("LinkRules failed: %v", err)
+	}
+
+	// Delete a linked rule from the repository, and add a link that does not resolve
+	if err := os.Remove(filepath.Join(rulesDir, "deleted.mdc")); err != nil {
+		t.Fatal(err)
+	}
+	if err := rulesManager.LoadRules(); err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+	broken := filepath.Join(targetDir, ".cursor", "rules", "broken.mdc")
+	if err := os.Symlink(filepath.Join(rulesDir, "missing.mdc"), broken); err != nil {
+		t.Fatal(err)
+	}
+
+	model := New(&config.Config{}, rulesManager, ruleLinker)
+	model.SetEditor(linker.DefaultEditor)
+	msg := confirm(t, model, "p")
+	for _, want := range []string{"broken.mdc (broken-symlink)", "deleted.mdc (orphaned)"} {
+		if !strings.Contains(msg.Message, want) {
+			t.Errorf("Confirmation does not list %q:\n%s", want, msg.Message)
+		}
+	}
+
+	if model.err != nil {
+		t.Fatalf("Pruning failed: %v", model.err)
+	}
+	for _, name := range []string{"broken.mdc", "deleted.mdc"} {
+		if _, err := os.Lstat(filepath.Join(targetDir, ".cursor", "rules", name)); !os.IsNotExist(err) {
+			t.Errorf("%s was not pruned: %v", name, err)
+		}
+	}
+	if !ruleLinker.IsRuleLinked(rulesManager.GetRuleByName("kept"), linker.DefaultEditor) {
+		t.Error("Pruning removed a rule that is up to date")
+	}
+	if len(model.pruneCandidates()) != 0 {
+		t.Errorf("Prune candidates remain: %+v", model.pruneCandidates())
+	}
+}
+
+func TestManagerConfirmModal(t *testing.T) {
+	manager := NewManager(New(&config.Config{}, rules.NewManager(t.TempDir()), linker.NewLinker(t.TempDir())))
+	manager.Update(ConfirmMsg{Title: "Apply changes?", Message: "+ link style", Msg: tickMsg{}})
+	if !manager.showModal || !strings.Contains(manager.View(), "+ link style") {
+		t.Fatalf("Expected the confirmation modal to show:\n%s", manager.View())
+	}
+
+	// Enter picks the first button, which applies the changes
+	_, cmd := manager.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	_, cmd = manager.Update(cmd())
+	if manager.showModal {
+		t.Error("Expected the modal to close")
+	}
+	if cmd == nil {
+		t.Fatal("Expected confirming to send the message on")
+	}
+	if _, ok := cmd().(tickMsg); !ok {
+		t.Error("Expected confirming to send the confirmed message")
+	}
+
+	// Cancelling sends nothing
+	manager.Update(ConfirmMsg{Title: "Apply changes?", Msg: tickMsg{}})
+	manager.Update(tea.KeyMsg{Type: tea.KeyDown})
+	_, cmd = manager.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if _, cmd = manager.Update(cmd()); cmd != nil {
+		t.Error("Expected cancelling to send nothing")
+	}
+}
+
+func TestPreviewShowsHighlightedRule(t *testing.T) {
+	rulesDir := filepath.Join(t.TempDir(), "rules")
+	if err := os.MkdirAll(rulesDir, 0755); err != nil {
+		t.Fatalf("Failed to create rules directory: %v", err)
+	}
+	content := "---\ndescription: Go style\nglobs: \"**/*.go\"\nalwaysApply: false\n---\n\n# Style\n\nUse **gofmt** before committing.\n"
+	if err := os.WriteFile(filepath.Join(rulesDir, "style.mdc"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write rule: %v", err)
+	}
+	rulesManager := rules.NewManager(rulesDir)
+	if err := rulesManager.LoadRules(); err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+
+	model := New(&config.Config{}, rulesManager, linker.NewLinker(t.TempDir()))
+	model.Update(tea.WindowSizeMsg{Width: 140, Height: 40})
+
+	view := model.View()
+	for _, want := range []string{"**/*.go", "gofmt", "before committing"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Preview does not show %q:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "globs:") || strings.Contains(view, "description:") {
+		t.Errorf("Preview shows the raw rule instead of rendering it:\n%s", view)
+	}
+
+	// r shows the raw rule file
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	view = model.View()
+	for _, want := range []string{"description: Go style", "globs: \"**/*.go\""} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Raw preview does not show %q:\n%s", want, view)
+		}
+	}
+
+	// Narrow windows leave the whole width to the list
+	model.Update(tea.WindowSizeMsg{Width: 80, Height: 40})
+	if view := model.View(); strings.Contains(view, "before committing") {
+		t.Errorf("Preview is shown in a narrow window:\n%s", view)
+	}
+}
+
+func TestDiffShowsLocalEdits(t *testing.T) {
+	rulesManager, _ := loadTestRules(t, "style")
+	targetDir := t.TempDir()
+	ruleLinker := linker.NewLinker(targetDir)
+	ruleLinker.SetMode(linker.ModeCopy)
+	if err := ruleLinker.LinkRules(rulesManager.Rules, linker.DefaultEditor); err != nil {
+		t.Fatalf("LinkRules failed: %v", err)
+	}
+	copyPath := filepath.Join(targetDir, ".cursor", "rules", "style.mdc")
+	content, err := os.ReadFile(copyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(copyPath, append(content, "Local edit\n"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewManager(New(&config.Config{}, rulesManager, ruleLinker))
+	_, cmd := manager.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("D")})
+	if cmd == nil {
+		t.Fatal("Expected D to show the diff")
+	}
+	msg, ok := cmd().(ShowTextMsg)
+	if !ok {
+		t.Fatalf("Expected D to show the diff, got %T", msg)
+	}
+	if !strings.Contains(msg.Text, "-Local edit") || !strings.Contains(msg.Text, ".cursor/rules/style.mdc (installed)") {
+		t.Errorf("Diff does not show the local edit:\n%s", msg.Text)
+	}
+
+	manager.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	manager.Update(msg)
+	if view := manager.View(); !manager.showModal || !strings.Contains(view, "-Local edit") {
+		t.Errorf("Expected the diff modal to show:\n%s", view)
+	}
+	manager.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if manager.showModal {
+		t.Error("Expected esc to close the diff modal")
+	}
+}
+
+func TestFilterByTag(t *testing.T) {
+	rulesManager := rules.NewManager("")
+	rulesManager.Rules = []*models.Rule{
+		{Name: "style", Topic: "go", Tags: []string{"golang"}},
+		{Name: "testing", Topic: "go", Tags: []string{"golang", "testing"}},
+		{Name: "jest", Topic: "js", Tags: []string{"testing"}},
+	}
+	model := New(&config.Config{}, rulesManager, linker.NewLinker(t.TempDir()))
+
+	visible := func() []string {
+		names := make([]string, 0)
+		for _, listItem := range model.list.VisibleItems() {
+			names = append(names, listItem.(item).getRuleName())
+		}
+		return names
+	}
+
+	testCases := []struct {
+		query string
+		want  []string
+	}{
+		{query: "tag:golang tag:testing", want: []string{"go/testing"}},
+		{query: "tag:testing -tag:golang", want: []string{"js/jest"}},
+		{query: "tag:golang sty", want: []string{"go/style"}},
+	}
+	for _, tc := range testCases {
+		model.list.SetFilterText(tc.query)
+		if got := visible(); strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("Filter %q shows %v, want %v", tc.query, got, tc.want)
+		}
+	}
+
+	// t offers the tags, and choosing one filters by it
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	msg, ok := cmd().(ChooseTagMsg)
+	if !ok {
+		t.Fatalf("Expected t to offer the tags, got %T", msg)
+	}
+	if want := []string{allTagsChoice, "golang (2)", "testing (2)"}; strings.Join(msg.Choices, ",") != strings.Join(want, ",") {
+		t.Errorf("Tag choices = %v, want %v", msg.Choices, want)
+	}
+	model.Update(FilterTagCmd(msg.Tags["golang (2)"])())
+	if got := visible(); len(got) != 2 || model.list.FilterValue() != "tag:golang" {
+		t.Errorf("Choosing golang shows %v with filter %q", got, model.list.FilterValue())
+	}
+	model.Update(FilterTagCmd(msg.Tags[allTagsChoice])())
+	if got := visible(); len(got) != 3 {
+		t.Errorf("Choosing all rules shows %v", got)
+	}
+}
+
+func TestRecommendedRulesFirst(t *testing.T) {
+	rulesManager := rules.NewManager("")
+	rulesManager.Rules = []*models.Rule{
+		{Name: "review"},
+		{Name: "react", Topic: "js", Globs: []string{"*.tsx"}},
+		{Name: "style", Topic: "go", Globs: []string{"*.go"}},
+	}
+	targetDir := t.TempDir()
+	for _, name := range []string{"main.go", "app.tsx", "cmd/tool.go", "generated.go"} {
+		path := filepath.Join(targetDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(targetDir, ".gitignore"), []byte("generated.go\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	model := New(&config.Config{}, rulesManager, linker.NewLinker(targetDir))
+
+	order := func() string {
+		names := make([]string, 0)
+		for _, listItem := range model.list.Items() {
+			names = append(names, listItem.(item).getRuleName())
+		}
+		return strings.Join(names, ",")
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("R")})
+	if got, want := order(), "go/style,js/react,review"; got != want {
+		t.Errorf("Recommended order = %s, want %s", got, want)
+	}
+	if model.recommended["go/style"] != 2 || model.recommended["js/react"] != 1 {
+		t.Errorf("Recommended file counts = %v", model.recommended)
+	}
+
+	var out strings.Builder
+	newItemDelegate(model.states, model.recommended).Render(&out, model.list, 0, model.list.Items()[0])
+	if !strings.Contains(out.String(), "★ 2 files") {
+		t.Errorf("Rendered item does not show the file count:\n%s", out.String())
+	}
+
+	// Tag and fuzzy filters still find the reordered rules
+	model.list.SetFilterText("reac")
+	if visible := model.list.VisibleItems(); len(visible) != 1 || visible[0].(item).rule.Name != "react" {
+		t.Errorf("Filter shows %v", visible)
+	}
+	model.list.ResetFilter()
+
+	model.Update(tickMsg{})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("R")})
+	if got, want := order(), "review,js/react,go/style"; got != want {
+		t.Errorf("Restored order = %s, want %s", got, want)
+	}
+}
+
+func TestBundleSelectsItsRules(t *testing.T) {
+	rulesManager := rules.NewManager("")
+	rulesManager.Rules = []*models.Rule{
+		{Name: "review"},
+		{Name: "style", Topic: "go"},
+		{Name: "testing", Topic: "go"},
+	}
+	rulesManager.Bundles = []*models.Bundle{
+		{Name: "go-service", Description: "Go services", Rules: []string{"go/**"}},
+	}
+	model := New(&config.Config{}, rulesManager, linker.NewLinker(t.TempDir()))
+	model.Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+
+	b, ok := model.list.SelectedItem().(bundleItem)
+	if !ok || b.Title() != "go-service (2 rules)" {
+		t.Fatalf("Expected the bundle listed first, got %v", model.list.SelectedItem())
+	}
+	if preview := model.previewView(); !strings.Contains(preview, "Go services") || !strings.Contains(preview, "go/testing") {
+		t.Errorf("Preview does not show the bundle rules:\n%s", preview)
+	}
+
+	// Enter selects every rule of the bundle, and deselects them all once selected
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	selected := func() string {
+		names := make([]string, 0)
+		for _, rule := range rulesManager.Rules {
+			if rule.Selected {
+				names = append(names, linker.RuleID(rule))
+			}
+		}
+		return strings.Join(names, ",")
+	}
+	if got, want := selected(), "go/style,go/testing"; got != want {
+		t.Errorf("Selected %s, want %s", got, want)
+	}
+
+	var out strings.Builder
+	newItemDelegate(model.states, model.recommended).Render(&out, model.list, 0, b)
+	if !strings.Contains(out.String(), "✓") {
+		t.Errorf("Rendered bundle is not marked as selected:\n%s", out.String())
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := selected(); got != "" {
+		t.Errorf("Selected %s after deselecting the bundle", got)
+	}
+
+	// Bundles stay on top when the rules are reordered
+	if _, ok := model.orderItems([]string{"review"})[0].(bundleItem); !ok {
+		t.Error("Expected the bundle to stay first when the rules are reordered")
+	}
+}
+
+func TestApplySelectionLinksRequirements(t *testing.T) {
+	rulesManager, _ := loadTestRules(t, "unit", "logging", "gitmoji")
+	ruleLinker := linker.NewLinker(t.TempDir())
+	unit, logging, gitmoji := rulesManager.GetRuleByName("unit"), rulesManager.GetRuleByName("logging"), rulesManager.GetRuleByName("gitmoji")
+	unit.Requires = []string{"logging"}
+	logging.Conflicts = []string{"gitmoji"}
+
+	model := New(&config.Config{}, rulesManager, ruleLinker)
+	model.SetEditor(linker.DefaultEditor)
+	unit.Selected = true
+	gitmoji.Selected = true
+
+	// A requirement conflicting with the selection is not linked
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l")})
+	if model.err == nil || !strings.Contains(model.err.Error(), "logging conflicts with gitmoji") {
+		t.Errorf("Expected the conflict to be reported, got %v", model.err)
+	}
+
+	gitmoji.Selected = false
+	msg := confirm(t, model, "l")
+	if !strings.Contains(msg.Message, "+ link logging (required by unit)") {
+		t.Errorf("Confirmation does not list the requirement:\n%s", msg.Message)
+	}
+	if model.err != nil {
+		t.Fatalf("Applying the changes failed: %v", model.err)
+	}
+	if !ruleLinker.IsRuleLinked(logging, linker.DefaultEditor) || !logging.Selected {
+		t.Errorf("Required rule not linked and selected")
+	}
+}
@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/circleci/llm-agent-rules/internal/linker"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+	"github.com/muesli/termenv"
+)
+
+// previewMinWidth is the narrowest window the preview pane is shown in
+const previewMinWidth = 100
+
+var (
+	previewStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#666666")).
+			Padding(0, 1)
+
+	alwaysApplyBadgeStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#000000")).
+				Background(lipgloss.Color("#00FF00")).
+				Padding(0, 1)
+
+	globBadgeStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFFFFF")).
+			Background(lipgloss.Color("#0000AA")).
+			Padding(0, 1)
+
+	tagBadgeStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#000000")).
+			Background(lipgloss.Color("#87CEEB")).
+			Padding(0, 1)
+
+	requestBadgeStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#FFFFFF")).
+				Background(lipgloss.Color("#666666")).
+				Padding(0, 1)
+)
+
+// preview is the scrollable pane showing the highlighted rule: its
+// frontmatter as badges above its body rendered as markdown, or the raw
+// rule file
+type preview struct {
+	viewport viewport.Model
+	raw      bool
+
+	// What the viewport content was rendered for, so it is only rendered again when that changes
+	rule        *models.Rule
+	bundle      *models.Bundle
+	width       int
+	renderedRaw bool
+}
+
+// newPreview creates an empty preview pane
+func newPreview() preview {
+	return preview{viewport: viewport.New(0, 0)}
+}
+
+// setSize sets the size of the pane content
+func (p *preview) setSize(width, height int) {
+	p.viewport.Width = max(width, 0)
+	p.viewport.Height = max(height, 0)
+}
+
+// show renders rule into the pane and scrolls to its top, unless the pane
+// already shows it
+func (p *preview) show(rule *models.Rule) {
+	if rule == p.rule && p.bundle == nil && p.viewport.Width == p.width && p.raw == p.renderedRaw {
+		return
+	}
+	p.rule, p.bundle, p.width, p.renderedRaw = rule, nil, p.viewport.Width, p.raw
+	p.viewport.SetContent(renderPreview(rule, p.viewport.Width, p.raw))
+	p.viewport.GotoTop()
+}
+
+// showBundle renders a bundle into the pane, scrolling to its top when the
+// pane showed something else. The bundle is rendered every time, as it marks
+// which of its rules are selected.
+func (p *preview) showBundle(b bundleItem) {
+	wrap := lipgloss.NewStyle().Width(p.viewport.Width)
+	p.viewport.SetContent(wrap.Render(renderBundlePreview(b)))
+	if b.bundle != p.bundle {
+		p.viewport.GotoTop()
+	}
+	p.rule, p.bundle = nil, b.bundle
+}
+
+// toggleRaw switches between the rendered rule and its raw file
+func (p *preview) toggleRaw() {
+	p.raw = !p.raw
+}
+
+// View renders the pane content
+func (p *preview) View() string {
+	return p.viewport.View()
+}
+
+// renderPreview renders a rule for a pane of the given width
+func renderPreview(rule *models.Rule, width int, raw bool) string {
+	if rule == nil {
+		return "No rule highlighted"
+	}
+
+	wrap := lipgloss.NewStyle().Width(width)
+	if raw {
+		return wrap.Render(rule.Content)
+	}
+
+	header := titleStyle.Render(linker.RuleID(rule)) + "\n" + wrap.Render(renderBadges(rule))
+	body, err := renderMarkdown(rule.Body, width)
+	if err != nil {
+		// Fall back to the plain body rather than showing nothing
+		body = wrap.Render(rule.Body)
+	}
+	return header + "\n" + body
+}
+
+// renderBadges renders when the rule applies: always, for the files matching
+// its globs, or when requested, followed by its tags
+func renderBadges(rule *models.Rule) string {
+	badges := make([]string, 0)
+	if rule.AlwaysApply {
+		badges = append(badges, alwaysApplyBadgeStyle.Render("alwaysApply"))
+	}
+	for _, glob := range rule.Globs {
+		badges = append(badges, globBadgeStyle.Render(glob))
+	}
+	if !rule.AlwaysApply && len(rule.Globs) == 0 {
+		badges = append(badges, requestBadgeStyle.Render("on request"))
+	}
+	for _, tag := range rule.Tags {
+		badges = append(badges, tagBadgeStyle.Render("#"+tag))
+	}
+	return strings.Join(badges, " ")
+}
+
+// renderMarkdown renders a rule body as styled markdown wrapped to width,
+// without colors under the mono theme
+func renderMarkdown(body string, width int) (string, error) {
+	style := styles.DarkStyle
+	if lipgloss.ColorProfile() == termenv.Ascii {
+		style = styles.NoTTYStyle
+	}
+
+	renderer, err := glamour.NewTermRenderer(
+		glamour.WithStandardStyle(style),
+		glamour.WithWordWrap(width),
+	)
+	if err != nil {
+		return "", err
+	}
+	return renderer.Render(body)
+}
@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/circleci/llm-agent-rules/internal/linker"
+	"github.com/circleci/llm-agent-rules/internal/rules"
+	"github.com/circleci/llm-agent-rules/internal/scan"
+)
+
+// toggleRecommended lists the rules recommended for the files of the target
+// project first, ranked by how many files they apply to, or restores the
+// original order. The target project is scanned the first time.
+func (m *Model) toggleRecommended() tea.Cmd {
+	if m.recommendedFirst {
+		m.recommendedFirst = false
+		m.list.Title = "Available Rules"
+		return m.list.SetItems(m.orderItems(nil))
+	}
+
+	if !m.scanned {
+		files, err := scan.Files(m.linker.TargetDir)
+		if err != nil {
+			m.err = fmt.Errorf("failed to scan the target project: %w", err)
+			return nil
+		}
+		m.recommendations = rules.Recommend(m.rulesManager.Rules, files)
+		for _, recommendation := range m.recommendations {
+			m.recommended[linker.RuleID(recommendation.Rule)] = len(recommendation.Files)
+		}
+		m.scanned = true
+	}
+	if len(m.recommendations) == 0 {
+		return m.showSuccess("No rule globs match the files of the target project")
+	}
+
+	m.recommendedFirst = true
+	m.list.Title = "Available Rules (recommended first)"
+	order := make([]string, 0, len(m.recommendations))
+	for _, recommendation := range m.recommendations {
+		order = append(order, linker.RuleID(recommendation.Rule))
+	}
+	return tea.Batch(
+		m.list.SetItems(m.orderItems(order)),
+		m.showSuccess(fmt.Sprintf("%d rules recommended for the files of the target project", len(order))),
+	)
+}
+
+// orderItems returns the list items of the bundles followed by the rules,
+// with the rules whose IDs are in first listed before the others, in that order
+func (m *Model) orderItems(first []string) []list.Item {
+	rank := make(map[string]int, len(first))
+	for i, id := range first {
+		rank[id] = i + 1
+	}
+
+	ruleItems := make([]list.Item, len(first), len(m.rulesManager.Rules))
+	for _, rule := range m.rulesManager.Rules {
+		listItem := item{rule: rule, layered: m.rulesManager.Layered()}
+		if i, ok := rank[linker.RuleID(rule)]; ok {
+			ruleItems[i-1] = listItem
+		} else {
+			ruleItems = append(ruleItems, listItem)
+		}
+	}
+	return append(newBundleItems(m.rulesManager), ruleItems...)
+}
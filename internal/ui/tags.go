@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/circleci/llm-agent-rules/internal/rules"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// allTagsChoice is the tag modal choice that clears the tag filter
+const allTagsChoice = "All rules"
+
+// ChooseTagMsg asks the Manager to offer the tags in a modal. Tags maps each
+// choice to its tag.
+type ChooseTagMsg struct {
+	Choices []string
+	Tags    map[string]string
+}
+
+// FilterTagMsg filters the rule list down to the rules with a tag, or shows
+// every rule when the tag is empty
+type FilterTagMsg string
+
+// FilterTagCmd filters the rule list by tag
+func FilterTagCmd(tag string) tea.Cmd {
+	return func() tea.Msg {
+		return FilterTagMsg(tag)
+	}
+}
+
+// chooseTag offers the tags of the rules, with the number of rules having each
+func (m *Model) chooseTag() tea.Cmd {
+	tags, counts := m.rulesManager.Tags()
+	if len(tags) == 0 {
+		return m.showSuccess("No rules are tagged")
+	}
+
+	msg := ChooseTagMsg{Choices: []string{allTagsChoice}, Tags: map[string]string{allTagsChoice: ""}}
+	for _, tag := range tags {
+		choice := fmt.Sprintf("%s (%d)", tag, counts[tag])
+		msg.Choices = append(msg.Choices, choice)
+		msg.Tags[choice] = tag
+	}
+	return func() tea.Msg { return msg }
+}
+
+// filterByTag sets the list filter to the tag, or clears it
+func (m *Model) filterByTag(tag string) {
+	if tag == "" {
+		m.list.ResetFilter()
+		return
+	}
+	m.list.SetFilterText(rules.TagPrefix + tag)
+}
+
+// tagFilter returns the list filter: "tag:name" terms keep the rules with
+// that tag, "-tag:name" terms drop them, and the rest of the query is
+// matched fuzzily as usual. The rules of the targets are looked up by their
+// filter value in items, so the list can be reordered.
+func tagFilter(items []list.Item) list.FilterFunc {
+	byValue := make(map[string]*models.Rule, len(items))
+	for _, listItem := range items {
+		if i, ok := listItem.(item); ok {
+			byValue[i.FilterValue()] = i.rule
+		}
+	}
+
+	return func(term string, targets []string) []list.Rank {
+		filter, rest := rules.ParseTagTerms(term)
+		if filter.Empty() {
+			return list.DefaultFilter(term, targets)
+		}
+
+		indexes := make([]int, 0, len(targets))
+		tagged := make([]string, 0, len(targets))
+		for index, target := range targets {
+			if rule, ok := byValue[target]; ok && filter.Matches(rule) {
+				indexes = append(indexes, index)
+				tagged = append(tagged, target)
+			}
+		}
+
+		if rest == "" {
+			ranks := make([]list.Rank, 0, len(indexes))
+			for _, index := range indexes {
+				ranks = append(ranks, list.Rank{Index: index})
+			}
+			return ranks
+		}
+		ranks := list.DefaultFilter(rest, tagged)
+		for i := range ranks {
+			ranks[i].Index = indexes[ranks[i].Index]
+		}
+		return ranks
+	}
+}
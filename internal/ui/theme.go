@@ -0,0 +1,34 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Theme names
+const (
+	// ThemeDefault uses the full color palette
+	ThemeDefault = "default"
+	// ThemeMono renders without colors, for terminals and screen readers
+	// that do not handle them well
+	ThemeMono = "mono"
+)
+
+// Themes lists the supported theme names
+var Themes = []string{ThemeDefault, ThemeMono}
+
+// ApplyTheme switches the color theme used by every style. An empty name
+// keeps the default theme.
+func ApplyTheme(name string) error {
+	switch name {
+	case "", ThemeDefault:
+		return nil
+	case ThemeMono:
+		lipgloss.SetColorProfile(termenv.Ascii)
+		return nil
+	}
+	return fmt.Errorf("unknown theme %q, supported themes: %s", name, strings.Join(Themes, ", "))
+}
@@ -0,0 +1,111 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Output formats supported by Write
+const (
+	FormatText   = "text"
+	FormatJSON   = "json"
+	FormatGitHub = "github"
+	FormatNDJSON = "ndjson"
+)
+
+// Formats lists every supported output format
+var Formats = []string{FormatText, FormatJSON, FormatGitHub, FormatNDJSON}
+
+// Write renders the result in the requested format
+func Write(w io.Writer, result *Result, format string) error {
+	switch format {
+	case FormatText, "":
+		return writeText(w, result)
+	case FormatJSON:
+		return writeJSON(w, result)
+	case FormatGitHub:
+		return writeGitHub(w, result)
+	case FormatNDJSON:
+		return writeNDJSON(w, result)
+	default:
+		return fmt.Errorf("unknown output format %q (expected one of: %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// writeText prints one issue per line in file:line: severity: message form
+func writeText(w io.Writer, result *Result) error {
+	for _, issue := range result.Issues {
+		location := issue.Path
+		if issue.Line > 0 {
+			location = fmt.Sprintf("%s:%d", location, issue.Line)
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s: %s [%s]\n", location, issue.Severity, issue.Message, issue.Code); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "Checked %d rules: %d errors, %d warnings\n",
+		result.Rules, result.ErrorCount(), result.WarningCount())
+	return err
+}
+
+// writeJSON prints the full result as a single JSON document
+func writeJSON(w io.Writer, result *Result) error {
+	output := struct {
+		*Result
+		Errors   int `json:"errors"`
+		Warnings int `json:"warnings"`
+	}{
+		Result:   result,
+		Errors:   result.ErrorCount(),
+		Warnings: result.WarningCount(),
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
+
+// writeNDJSON prints each issue as a JSON object on its own line
+func writeNDJSON(w io.Writer, result *Result) error {
+	encoder := json.NewEncoder(w)
+	for _, issue := range result.Issues {
+		if err := encoder.Encode(issue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeGitHub prints workflow commands that GitHub Actions turns into
+// inline annotations. The same lines are readable in CircleCI step output.
+func writeGitHub(w io.Writer, result *Result) error {
+	for _, issue := range result.Issues {
+		params := "file=" + escapeProperty(issue.Path)
+		if issue.Line > 0 {
+			params += fmt.Sprintf(",line=%d", issue.Line)
+		}
+		params += ",title=" + escapeProperty(issue.Code)
+
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", issue.Severity, params, escapeData(issue.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeData escapes a workflow command message
+func escapeData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+// escapeProperty escapes a workflow command property value
+func escapeProperty(s string) string {
+	s = escapeData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
@@ -0,0 +1,381 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/circleci/llm-agent-rules/internal/linker"
+	"github.com/circleci/llm-agent-rules/internal/rules"
+	"github.com/circleci/llm-agent-rules/pkg/models"
+)
+
+// Severity describes how serious a validation issue is
+type Severity string
+
+const (
+	// SeverityError marks issues that fail validation
+	SeverityError Severity = "error"
+	// SeverityWarning marks issues that are reported but do not fail validation
+	SeverityWarning Severity = "warning"
+)
+
+// Issue codes reported by the validator
+const (
+	CodeInvalidFrontmatter = "invalid-frontmatter"
+	CodeMissingFrontmatter = "missing-frontmatter"
+	CodeEmptyDescription   = "empty-description"
+	CodeInvalidGlob        = "invalid-glob"
+	CodeUnknownKey         = "unknown-key"
+	CodeDuplicateName      = "duplicate-name"
+	CodeFlattenedCollision = "flattened-collision"
+	CodeInvalidBundle      = "invalid-bundle"
+	CodeUnknownReference   = "unknown-reference"
+	CodeRequirementCycle   = "requirement-cycle"
+	CodeUndeclaredVariable = "undeclared-variable"
+)
+
+// Issue is a single problem found in the rules repository
+type Issue struct {
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`
+	Path     string   `json:"path"`
+	Line     int      `json:"line,omitempty"`
+	Rule     string   `json:"rule,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// Result holds all issues found during a validation run
+type Result struct {
+	Issues []Issue `json:"issues"`
+	Rules  int     `json:"rules"`
+}
+
+// ErrorCount returns the number of error-level issues
+func (r *Result) ErrorCount() int {
+	return r.count(SeverityError)
+}
+
+// WarningCount returns the number of warning-level issues
+func (r *Result) WarningCount() int {
+	return r.count(SeverityWarning)
+}
+
+func (r *Result) count(severity Severity) int {
+	n := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// Validator lints every rule file in a rules directory
+type Validator struct {
+	rulesManager *rules.Manager
+	// BasePath is used to report issue paths relative to the repository root
+	BasePath string
+}
+
+// New creates a validator for the rules directory managed by rulesManager
+func New(rulesManager *rules.Manager, basePath string) *Validator {
+	return &Validator{
+		rulesManager: rulesManager,
+		BasePath:     basePath,
+	}
+}
+
+// Validate walks the rules and bundles directories and returns every issue found.
+// The returned error is only set when the directory itself cannot be read.
+func (v *Validator) Validate() (*Result, error) {
+	result := &Result{Issues: make([]Issue, 0)}
+	loaded := make([]*models.Rule, 0)
+
+	err := v.rulesManager.WalkRuleFiles(func(path, topic string) error {
+		result.Rules++
+
+		rule, err := models.NewRule(path)
+		if err != nil {
+			var parseErr *models.ParseError
+			if !errors.As(err, &parseErr) {
+				return err
+			}
+			result.Issues = append(result.Issues, Issue{
+				Severity: SeverityError,
+				Code:     CodeInvalidFrontmatter,
+				Path:     v.relPath(path),
+				Line:     parseErr.Line,
+				Rule:     ruleID(topic, ruleName(path)),
+				Message:  parseErr.Msg,
+			})
+			return nil
+		}
+
+		rule.Topic = topic
+		result.Issues = append(result.Issues, v.checkRule(rule)...)
+		loaded = append(loaded, rule)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Issues = append(result.Issues, v.checkDuplicates(loaded)...)
+	result.Issues = append(result.Issues, v.checkCollisions(loaded)...)
+	result.Issues = append(result.Issues, v.checkReferences(loaded)...)
+	bundleIssues, err := v.checkBundles(loaded)
+	if err != nil {
+		return nil, err
+	}
+	result.Issues = append(result.Issues, bundleIssues...)
+
+	sort.SliceStable(result.Issues, func(i, j int) bool {
+		if result.Issues[i].Path != result.Issues[j].Path {
+			return result.Issues[i].Path < result.Issues[j].Path
+		}
+		return result.Issues[i].Line < result.Issues[j].Line
+	})
+
+	return result, nil
+}
+
+// checkRule runs the per-file checks on a parsed rule
+func (v *Validator) checkRule(rule *models.Rule) []Issue {
+	issues := make([]Issue, 0)
+	newIssue := func(severity Severity, code string, line int, msg string) {
+		issues = append(issues, Issue{
+			Severity: severity,
+			Code:     code,
+			Path:     v.relPath(rule.Path),
+			Line:     line,
+			Rule:     ruleID(rule.Topic, rule.Name),
+			Message:  msg,
+		})
+	}
+
+	if !rule.HasFrontmatter() {
+		newIssue(SeverityError, CodeMissingFrontmatter, 1, "rule has no frontmatter block")
+		return issues
+	}
+
+	if rule.Description == "" {
+		line := rule.KeyLine(models.KeyDescription)
+		if line == 0 {
+			line = 1
+		}
+		newIssue(SeverityError, CodeEmptyDescription, line, "description is empty")
+	}
+
+	for _, glob := range rule.Globs {
+		if !doublestar.ValidatePattern(glob) {
+			newIssue(SeverityError, CodeInvalidGlob, rule.KeyLine(models.KeyGlobs),
+				fmt.Sprintf("invalid glob pattern %q", glob))
+		}
+	}
+
+	unknown := make([]string, 0, len(rule.Extra))
+	for key := range rule.Extra {
+		unknown = append(unknown, key)
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		newIssue(SeverityWarning, CodeUnknownKey, rule.KeyLine(key),
+			fmt.Sprintf("unknown frontmatter key %q", key))
+	}
+
+	// Placeholders of undeclared variables are installed as they are, which
+	// in a template is most likely a typo
+	if rule.Templated() {
+		declared := make(map[string]bool, len(rule.Vars))
+		for _, variable := range rule.Vars {
+			declared[variable.Name] = true
+		}
+		for _, name := range rule.Placeholders() {
+			if !declared[name] {
+				newIssue(SeverityWarning, CodeUndeclaredVariable, rule.KeyLine(models.KeyVars),
+					fmt.Sprintf("placeholder {{ %s }} is not declared under vars", name))
+			}
+		}
+	}
+
+	return issues
+}
+
+// checkDuplicates reports rules that share a name across topics, which makes
+// linking by plain name ambiguous
+func (v *Validator) checkDuplicates(loaded []*models.Rule) []Issue {
+	byName := make(map[string][]*models.Rule)
+	for _, rule := range loaded {
+		byName[rule.Name] = append(byName[rule.Name], rule)
+	}
+
+	issues := make([]Issue, 0)
+	for name, group := range byName {
+		if len(group) < 2 {
+			continue
+		}
+		for _, rule := range group {
+			issues = append(issues, Issue{
+				Severity: SeverityWarning,
+				Code:     CodeDuplicateName,
+				Path:     v.relPath(rule.Path),
+				Rule:     ruleID(rule.Topic, rule.Name),
+				Message: fmt.Sprintf("rule name %q is also used by %s",
+					name, strings.Join(otherIDs(group, rule), ", ")),
+			})
+		}
+	}
+	return issues
+}
+
+// checkCollisions reports rules that flatten to the same file name when linked
+func (v *Validator) checkCollisions(loaded []*models.Rule) []Issue {
+	byTarget := make(map[string][]*models.Rule)
+	for _, rule := range loaded {
+		target := linker.TargetFileName(rule)
+		byTarget[target] = append(byTarget[target], rule)
+	}
+
+	issues := make([]Issue, 0)
+	for target, group := range byTarget {
+		if len(group) < 2 {
+			continue
+		}
+		for _, rule := range group {
+			issues = append(issues, Issue{
+				Severity: SeverityError,
+				Code:     CodeFlattenedCollision,
+				Path:     v.relPath(rule.Path),
+				Rule:     ruleID(rule.Topic, rule.Name),
+				Message: fmt.Sprintf("links as %s, colliding with %s",
+					target, strings.Join(otherIDs(group, rule), ", ")),
+			})
+		}
+	}
+	return issues
+}
+
+// checkReferences reports requires and conflicts entries naming rules that
+// do not exist, and rules that require each other in a cycle
+func (v *Validator) checkReferences(loaded []*models.Rule) []Issue {
+	resolver := rules.NewManager("")
+	resolver.Rules = loaded
+
+	issues := make([]Issue, 0)
+	for _, rule := range loaded {
+		for _, key := range []string{models.KeyRequires, models.KeyConflicts} {
+			names := rule.Requires
+			if key == models.KeyConflicts {
+				names = rule.Conflicts
+			}
+			for _, name := range names {
+				if resolver.GetRuleByName(name) != nil {
+					continue
+				}
+				issues = append(issues, Issue{
+					Severity: SeverityError,
+					Code:     CodeUnknownReference,
+					Path:     v.relPath(rule.Path),
+					Line:     rule.KeyLine(key),
+					Rule:     ruleID(rule.Topic, rule.Name),
+					Message:  fmt.Sprintf("%s names unknown rule %q", key, name),
+				})
+			}
+		}
+	}
+
+	for _, cycle := range resolver.RequirementCycles() {
+		rule := cycle[0]
+		issues = append(issues, Issue{
+			Severity: SeverityError,
+			Code:     CodeRequirementCycle,
+			Path:     v.relPath(rule.Path),
+			Line:     rule.KeyLine(models.KeyRequires),
+			Rule:     ruleID(rule.Topic, rule.Name),
+			Message:  "rules require each other: " + strings.Join(rules.CycleNames(cycle), " -> "),
+		})
+	}
+	return issues
+}
+
+// checkBundles reports bundles that cannot be parsed, or that select rules
+// or include bundles that do not exist, or include themselves
+func (v *Validator) checkBundles(loaded []*models.Rule) ([]Issue, error) {
+	issues := make([]Issue, 0)
+	resolver := rules.NewManager("")
+	resolver.Rules = loaded
+
+	err := v.rulesManager.WalkBundleFiles(func(path string) error {
+		bundle, err := models.NewBundle(path)
+		if err != nil {
+			var parseErr *models.ParseError
+			if !errors.As(err, &parseErr) {
+				return err
+			}
+			issues = append(issues, Issue{
+				Severity: SeverityError,
+				Code:     CodeInvalidBundle,
+				Path:     v.relPath(path),
+				Line:     parseErr.Line,
+				Message:  parseErr.Msg,
+			})
+			return nil
+		}
+		resolver.Bundles = append(resolver.Bundles, bundle)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, bundle := range resolver.Bundles {
+		if _, err := resolver.ResolveBundle(bundle.Name); err != nil {
+			issues = append(issues, Issue{
+				Severity: SeverityError,
+				Code:     CodeInvalidBundle,
+				Path:     v.relPath(bundle.Path),
+				Message:  err.Error(),
+			})
+		}
+	}
+	return issues, nil
+}
+
+// relPath makes a rule path relative to the base path where possible
+func (v *Validator) relPath(path string) string {
+	if v.BasePath == "" {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(v.BasePath, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// otherIDs returns the IDs of every rule in group except rule, sorted
+func otherIDs(group []*models.Rule, rule *models.Rule) []string {
+	ids := make([]string, 0, len(group)-1)
+	for _, other := range group {
+		if other != rule {
+			ids = append(ids, ruleID(other.Topic, other.Name))
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func ruleID(topic, name string) string {
+	if topic != "" {
+		return topic + "/" + name
+	}
+	return name
+}
+
+func ruleName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
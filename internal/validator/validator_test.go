@@ -0,0 +1,170 @@
+package validator
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/circleci/llm-agent-rules/internal/rules"
+)
+
+func writeRule(t *testing.T, dir, relPath, content string) {
+	t.Helper()
+	path := filepath.Join(dir, relPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create rule directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write rule %s: %v", relPath, err)
+	}
+}
+
+func TestValidateReportsIssues(t *testing.T) {
+	repoDir := t.TempDir()
+	rulesDir := filepath.Join(repoDir, "rules")
+
+	writeRule(t, rulesDir, "good.mdc", "---\ndescription: A good rule\nglobs: *.go\n---\n# Good\n")
+	writeRule(t, rulesDir, "nofm.mdc", "# No frontmatter\n")
+	writeRule(t, rulesDir, "unterminated.mdc", "---\ndescription: never closed\n")
+	writeRule(t, rulesDir, "empty.mdc", "---\ndescription:\nowner: me\n---\n")
+	writeRule(t, rulesDir, "badglob.mdc", "---\ndescription: Bad glob\nglobs: [\"src/[a-\"]\n---\n")
+	writeRule(t, rulesDir, "a_b/c.mdc", "---\ndescription: One\n---\n")
+	writeRule(t, rulesDir, "a/b_c.mdc", "---\ndescription: Two\n---\n")
+	writeRule(t, rulesDir, "x/good.mdc", "---\ndescription: Same name as root rule\n---\n")
+	writeRule(t, rulesDir, "template.mdc", "---\ndescription: Template\nvars:\n  project: app\n---\n# {{ project }} uses {{ projet }}\n")
+
+	v := New(rules.NewManager(rulesDir), repoDir)
+	result, err := v.Validate()
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	if result.Rules != 9 {
+		t.Errorf("Expected 9 rules checked, got %d", result.Rules)
+	}
+
+	want := map[string]string{
+		"rules/nofm.mdc":         CodeMissingFrontmatter,
+		"rules/unterminated.mdc": CodeInvalidFrontmatter,
+		"rules/badglob.mdc":      CodeInvalidGlob,
+		"rules/a_b/c.mdc":        CodeFlattenedCollision,
+		"rules/a/b_c.mdc":        CodeFlattenedCollision,
+		"rules/x/good.mdc":       CodeDuplicateName,
+		"rules/template.mdc":     CodeUndeclaredVariable,
+	}
+	for path, code := range want {
+		if !hasIssue(result, path, code) {
+			t.Errorf("Expected %s issue for %s, got %+v", code, path, result.Issues)
+		}
+	}
+
+	if !hasIssue(result, "rules/empty.mdc", CodeEmptyDescription) || !hasIssue(result, "rules/empty.mdc", CodeUnknownKey) {
+		t.Errorf("Expected empty description and unknown key issues for rules/empty.mdc, got %+v", result.Issues)
+	}
+
+	for _, issue := range result.Issues {
+		if issue.Path == "rules/good.mdc" && issue.Code != CodeDuplicateName {
+			t.Errorf("Unexpected issue for valid rule: %+v", issue)
+		}
+	}
+}
+
+func TestWriteFormats(t *testing.T) {
+	result := &Result{
+		Rules: 1,
+		Issues: []Issue{{
+			Severity: SeverityError,
+			Code:     CodeEmptyDescription,
+			Path:     "rules/x.mdc",
+			Line:     2,
+			Message:  "description is empty",
+		}},
+	}
+
+	testCases := []struct {
+		format string
+		want   string
+	}{
+		{format: FormatText, want: "rules/x.mdc:2: error: description is empty [empty-description]"},
+		{format: FormatJSON, want: `"code": "empty-description"`},
+		{format: FormatGitHub, want: "::error file=rules/x.mdc,line=2,title=empty-description::description is empty"},
+		{format: FormatNDJSON, want: `{"severity":"error","code":"empty-description","path":"rules/x.mdc","line":2,"message":"description is empty"}` + "\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, result, tc.format); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			if !strings.Contains(buf.String(), tc.want) {
+				t.Errorf("Output %q does not contain %q", buf.String(), tc.want)
+			}
+		})
+	}
+
+	if err := Write(&bytes.Buffer{}, result, "xml"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+func hasIssue(result *Result, path, code string) bool {
+	for _, issue := range result.Issues {
+		if issue.Path == path && issue.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func TestValidateReportsBrokenBundles(t *testing.T) {
+	repoDir := t.TempDir()
+	rulesDir := filepath.Join(repoDir, "rules")
+	writeRule(t, rulesDir, "go/style.mdc", "---\ndescription: Style\n---\n# Style\n")
+	writeRule(t, repoDir, "bundles/go.yaml", "description: Go\nrules: [go/style]\n")
+	writeRule(t, repoDir, "bundles/typo.yaml", "description: Typo\nrulez: [go/style]\n")
+	writeRule(t, repoDir, "bundles/dangling.yaml", "rules: [go/missing]\n")
+	writeRule(t, repoDir, "bundles/cycle.yaml", "include: [cycle]\n")
+
+	result, err := New(rules.NewManager(rulesDir), repoDir).Validate()
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	for _, path := range []string{"bundles/typo.yaml", "bundles/dangling.yaml", "bundles/cycle.yaml"} {
+		if !hasIssue(result, path, CodeInvalidBundle) {
+			t.Errorf("Expected %s issue for %s, got %+v", CodeInvalidBundle, path, result.Issues)
+		}
+	}
+	if hasIssue(result, "bundles/go.yaml", CodeInvalidBundle) {
+		t.Errorf("Unexpected issue for valid bundle: %+v", result.Issues)
+	}
+	for _, issue := range result.Issues {
+		if issue.Path == "bundles/typo.yaml" && (issue.Line != 2 || !strings.Contains(issue.Message, "field rulez not found")) {
+			t.Errorf("Unknown bundle key reported as %+v, want line 2", issue)
+		}
+	}
+}
+
+func TestValidateReportsBrokenReferences(t *testing.T) {
+	rulesDir := t.TempDir()
+	writeRule(t, rulesDir, "go/testing.mdc", "---\ndescription: Testing\nrequires: [go/logging]\n---\n# Testing\n")
+	writeRule(t, rulesDir, "go/logging.mdc", "---\ndescription: Logging\nrequires: [go/testing]\n---\n# Logging\n")
+	writeRule(t, rulesDir, "commits.mdc", "---\ndescription: Commits\nconflicts: [gitmoji]\n---\n# Commits\n")
+	writeRule(t, rulesDir, "style.mdc", "---\ndescription: Style\nrequires: [commits]\n---\n# Style\n")
+
+	result, err := New(rules.NewManager(rulesDir), rulesDir).Validate()
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if !hasIssue(result, "commits.mdc", CodeUnknownReference) {
+		t.Errorf("Expected %s issue for commits.mdc, got %+v", CodeUnknownReference, result.Issues)
+	}
+	if !hasIssue(result, "go/logging.mdc", CodeRequirementCycle) {
+		t.Errorf("Expected %s issue for go/logging.mdc, got %+v", CodeRequirementCycle, result.Issues)
+	}
+	if hasIssue(result, "style.mdc", CodeUnknownReference) || hasIssue(result, "go/testing.mdc", CodeRequirementCycle) {
+		t.Errorf("Unexpected issues: %+v", result.Issues)
+	}
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// BundleExt is the extension of bundle files in a rules repository's bundles directory
+const BundleExt = ".yaml"
+
+// Bundle is a named group of rules defined in a rules repository, such as
+// the rules every Go service links
+type Bundle struct {
+	// Name is the bundle file name without its extension
+	Name        string `yaml:"-"`
+	Description string `yaml:"description"`
+	// Rules selects rules by name, topic/name, topic glob ("go/**") or tag ("tag:security")
+	Rules []string `yaml:"rules"`
+	// Include names other bundles whose rules belong to this one too
+	Include []string `yaml:"include"`
+	Path    string   `yaml:"-"`
+	// Source is the ID of the rules source the bundle was loaded from
+	Source string `yaml:"-"`
+}
+
+// NewBundle reads a bundle file
+func NewBundle(path string) (*Bundle, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseBundle(path, content)
+}
+
+// ParseBundle decodes a bundle file. Unknown keys are rejected so typos do
+// not silently drop rules from the bundle.
+func ParseBundle(path string, content []byte) (*Bundle, error) {
+	bundle := &Bundle{
+		Name: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		Path: path,
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(bundle); err != nil && !errors.Is(err, io.EOF) {
+		parseErr := yamlError(err, 0)
+		parseErr.Path = path
+		return nil, parseErr
+	}
+	return bundle, nil
+}
@@ -0,0 +1,300 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Known frontmatter keys in the .mdc format
+const (
+	KeyDescription = "description"
+	KeyGlobs       = "globs"
+	KeyAlwaysApply = "alwaysApply"
+	KeyTags        = "tags"
+	KeyRequires    = "requires"
+	KeyConflicts   = "conflicts"
+	KeyVars        = "vars"
+)
+
+// ParseError describes a problem found while parsing a rule file.
+// Line is 1-based and refers to the line in the rule file, or 0 when
+// the problem cannot be attributed to a single line.
+type ParseError struct {
+	Path string
+	Line int
+	Msg  string
+}
+
+// Error implements the error interface
+func (e *ParseError) Error() string {
+	location := e.Path
+	if location == "" {
+		location = "<rule>"
+	}
+	if e.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, e.Line)
+	}
+	return fmt.Sprintf("%s: %s", location, e.Msg)
+}
+
+// Frontmatter holds the decoded metadata block at the top of a rule file
+type Frontmatter struct {
+	Description string
+	Globs       []string
+	AlwaysApply bool
+	Tags        []string
+	// Requires and Conflicts name the rules this rule must be installed with,
+	// or must not be
+	Requires  []string
+	Conflicts []string
+	// Vars are the template variables of the rule body, in declaration order
+	Vars []Variable
+	// Extra holds any keys not covered by the typed fields above
+	Extra map[string]interface{}
+	// Lines maps each top-level key to the line it appears on in the rule file
+	Lines map[string]int
+}
+
+// yamlLineRe extracts the line number from yaml.v3 syntax errors, and from
+// the first of the errors decoding into a struct
+var yamlLineRe = regexp.MustCompile(`(?s)^yaml: (?:unmarshal errors:\s*)?line (\d+): (.*)$`)
+
+// SplitFrontmatter separates the frontmatter block from the body of a rule.
+// The frontmatter must start on the first line with "---" and is closed by
+// the next "---" line. It returns the raw frontmatter (without delimiters),
+// the body, and whether a frontmatter block was present.
+func SplitFrontmatter(content string) (frontmatter, body string, found bool, err error) {
+	content = strings.TrimPrefix(content, "\ufeff")
+	lines := strings.SplitAfter(content, "\n")
+	if len(lines) == 0 || strings.TrimRight(lines[0], " \t\r\n") != "---" {
+		return "", content, false, nil
+	}
+
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimRight(lines[i], " \t\r\n") == "---" {
+			frontmatter = strings.Join(lines[1:i], "")
+			body = strings.Join(lines[i+1:], "")
+			return frontmatter, body, true, nil
+		}
+	}
+
+	return "", content, true, &ParseError{Line: 1, Msg: "frontmatter is not terminated by a closing ---"}
+}
+
+// ParseFrontmatter decodes a raw frontmatter block. The block is assumed to
+// start on line 2 of the rule file, which is where line numbers in returned
+// errors are anchored.
+func ParseFrontmatter(raw string) (*Frontmatter, error) {
+	// Frontmatter content starts after the opening --- on line 1
+	const lineOffset = 1
+
+	fm := &Frontmatter{
+		Extra: make(map[string]interface{}),
+		Lines: make(map[string]int),
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(quoteBareGlobs(raw)), &doc); err != nil {
+		return nil, yamlError(err, lineOffset)
+	}
+
+	// An empty frontmatter block is valid and has no keys
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		return fm, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind == yaml.ScalarNode && root.Tag == "!!null" {
+		return fm, nil
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil, &ParseError{Line: root.Line + lineOffset, Msg: "frontmatter must be a mapping of keys to values"}
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		keyNode, valueNode := root.Content[i], root.Content[i+1]
+		key := keyNode.Value
+		line := keyNode.Line + lineOffset
+
+		if _, dup := fm.Lines[key]; dup {
+			return nil, &ParseError{Line: line, Msg: fmt.Sprintf("duplicate key %q", key)}
+		}
+		fm.Lines[key] = line
+
+		switch key {
+		case KeyDescription:
+			desc, err := decodeString(valueNode, key, lineOffset)
+			if err != nil {
+				return nil, err
+			}
+			fm.Description = strings.TrimSpace(desc)
+
+		case KeyGlobs:
+			globs, err := decodeList(valueNode, key, lineOffset)
+			if err != nil {
+				return nil, err
+			}
+			fm.Globs = globs
+
+		case KeyTags:
+			tags, err := decodeList(valueNode, key, lineOffset)
+			if err != nil {
+				return nil, err
+			}
+			fm.Tags = tags
+
+		case KeyRequires:
+			requires, err := decodeList(valueNode, key, lineOffset)
+			if err != nil {
+				return nil, err
+			}
+			fm.Requires = requires
+
+		case KeyConflicts:
+			conflicts, err := decodeList(valueNode, key, lineOffset)
+			if err != nil {
+				return nil, err
+			}
+			fm.Conflicts = conflicts
+
+		case KeyVars:
+			vars, err := decodeVars(valueNode, lineOffset)
+			if err != nil {
+				return nil, err
+			}
+			fm.Vars = vars
+
+		case KeyAlwaysApply:
+			if isNull(valueNode) {
+				continue
+			}
+			if valueNode.Kind != yaml.ScalarNode || valueNode.Tag != "!!bool" {
+				return nil, &ParseError{
+					Line: valueNode.Line + lineOffset,
+					Msg:  fmt.Sprintf("%s must be true or false, got %q", key, valueNode.Value),
+				}
+			}
+			b, _ := strconv.ParseBool(valueNode.Value)
+			fm.AlwaysApply = b
+
+		default:
+			var value interface{}
+			if err := valueNode.Decode(&value); err != nil {
+				return nil, &ParseError{Line: valueNode.Line + lineOffset, Msg: fmt.Sprintf("invalid value for %q: %v", key, err)}
+			}
+			fm.Extra[key] = value
+		}
+	}
+
+	return fm, nil
+}
+
+// quoteBareGlobs quotes unquoted single-line globs values. Cursor writes
+// globs as a bare comma-separated list (globs: *.ts,src/**/*.go), which is
+// not valid YAML because of the leading '*', so it is quoted before decoding.
+func quoteBareGlobs(raw string) string {
+	lines := strings.Split(raw, "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(line, KeyGlobs+":") {
+			continue
+		}
+
+		value := strings.TrimSpace(strings.TrimPrefix(line, KeyGlobs+":"))
+		value = strings.TrimSuffix(value, "\r")
+		if value == "" || value == "~" || value == "null" || strings.ContainsAny(value[:1], `[{"'|>#&!`) {
+			continue
+		}
+
+		lines[i] = KeyGlobs + ": '" + strings.ReplaceAll(value, "'", "''") + "'"
+	}
+	return strings.Join(lines, "\n")
+}
+
+// decodeString decodes a scalar node into a string
+func decodeString(node *yaml.Node, key string, lineOffset int) (string, error) {
+	if isNull(node) {
+		return "", nil
+	}
+	if node.Kind != yaml.ScalarNode {
+		return "", &ParseError{Line: node.Line + lineOffset, Msg: fmt.Sprintf("%s must be a string", key)}
+	}
+	return node.Value, nil
+}
+
+// decodeList accepts a comma-separated string, a flow list or a block list
+func decodeList(node *yaml.Node, key string, lineOffset int) ([]string, error) {
+	if isNull(node) {
+		return nil, nil
+	}
+
+	var values []string
+	switch node.Kind {
+	case yaml.ScalarNode:
+		for _, value := range strings.Split(node.Value, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				return nil, &ParseError{Line: item.Line + lineOffset, Msg: key + " entries must be strings"}
+			}
+			if value := strings.TrimSpace(item.Value); value != "" {
+				values = append(values, value)
+			}
+		}
+	default:
+		return nil, &ParseError{Line: node.Line + lineOffset, Msg: key + " must be a string or a list of strings"}
+	}
+
+	return values, nil
+}
+
+// decodeVars decodes a mapping of variable names to default values, where a
+// null value marks a variable without default
+func decodeVars(node *yaml.Node, lineOffset int) ([]Variable, error) {
+	if isNull(node) {
+		return nil, nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil, &ParseError{Line: node.Line + lineOffset, Msg: KeyVars + " must be a mapping of variable names to default values"}
+	}
+
+	vars := make([]Variable, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		if !variableNameRe.MatchString(keyNode.Value) {
+			return nil, &ParseError{Line: keyNode.Line + lineOffset, Msg: fmt.Sprintf("invalid variable name %q", keyNode.Value)}
+		}
+		if valueNode.Kind != yaml.ScalarNode {
+			return nil, &ParseError{Line: valueNode.Line + lineOffset, Msg: fmt.Sprintf("default of variable %q must be a string", keyNode.Value)}
+		}
+		variable := Variable{Name: keyNode.Value, Default: valueNode.Value, Required: isNull(valueNode)}
+		if variable.Required {
+			variable.Default = ""
+		}
+		vars = append(vars, variable)
+	}
+	return vars, nil
+}
+
+// isNull reports whether a node holds an explicit or implicit null value
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+// yamlError converts a yaml.v3 error into a ParseError with file line numbers
+func yamlError(err error, lineOffset int) *ParseError {
+	msg := strings.TrimSpace(err.Error())
+	if m := yamlLineRe.FindStringSubmatch(msg); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return &ParseError{Line: line + lineOffset, Msg: m[2]}
+	}
+	// yaml.v3 omits the line number for problems on the first line
+	return &ParseError{Line: 1 + lineOffset, Msg: strings.TrimPrefix(msg, "yaml: ")}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,11 +12,25 @@ type Rule struct {
 	Name        string
 	Description string
 	Globs       []string
+	AlwaysApply bool
+	Tags        []string
+	Requires    []string               // Rules to install along with this one
+	Conflicts   []string               // Rules that must not be installed along with this one
+	Vars        []Variable             // Template variables of the rule, rendered at install time
+	Extra       map[string]interface{} // Frontmatter keys not covered by the typed fields
+	Frontmatter string                 // Raw frontmatter block, without the --- delimiters
+	Body        string                 // Rule content following the frontmatter
 	Path        string
 	Content     string
 	Selected    bool
-	Topic       string // Represents the subfolder/category the rule belongs to
-	IsInstalled bool   // Tracks if the rule is already installed in the target
+	Topic       string   // Represents the subfolder/category the rule belongs to
+	IsInstalled bool     // Tracks if the rule is already installed in the target
+	Source      string   // ID of the rules source the rule was loaded from
+	Overrides   []string // IDs of earlier sources whose rule with the same topic/name this one hides
+	ShadowedBy  string   // ID of the later source whose rule hides this one
+
+	hasFrontmatter bool
+	keyLines       map[string]int
 }
 
 // NewRule creates a new Rule instance from a file path
@@ -25,45 +40,86 @@ func NewRule(path string) (*Rule, error) {
 		return nil, err
 	}
 
+	return ParseRule(path, string(content))
+}
+
+// ParseRule creates a new Rule from the content of a rule file.
+// Frontmatter problems are reported as a *ParseError carrying the path and line.
+func ParseRule(path, content string) (*Rule, error) {
 	name := filepath.Base(path)
 	name = strings.TrimSuffix(name, filepath.Ext(name))
 
 	rule := &Rule{
 		Name:     name,
 		Path:     path,
-		Content:  string(content),
+		Content:  content,
 		Selected: false,
 		Topic:    "", // Default to empty topic
 	}
 
-	// Parse the content to extract description and globs
-	rule.parseContent()
+	// Parse the content to extract the frontmatter and body
+	if err := rule.parseContent(); err != nil {
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			parseErr.Path = path
+		}
+		return nil, err
+	}
 
 	return rule, nil
 }
 
-// parseContent extracts metadata from rule content
-func (r *Rule) parseContent() {
-	lines := strings.Split(r.Content, "\n")
-	inFrontmatter := false
+// HasFrontmatter reports whether the rule file starts with a frontmatter block
+func (r *Rule) HasFrontmatter() bool {
+	return r.hasFrontmatter
+}
 
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
+// KeyLine returns the line in the rule file where a frontmatter key is
+// defined, or 0 if the key is not present
+func (r *Rule) KeyLine(key string) int {
+	return r.keyLines[key]
+}
 
-		if line == "---" {
-			inFrontmatter = !inFrontmatter
-			continue
-		}
+// parseContent splits the rule content and decodes its frontmatter
+func (r *Rule) parseContent() error {
+	raw, body, found, err := SplitFrontmatter(r.Content)
+	if err != nil {
+		return err
+	}
+
+	r.Frontmatter = raw
+	r.Body = body
+	r.hasFrontmatter = found
+	r.Extra = make(map[string]interface{})
+
+	if !found {
+		return nil
+	}
+
+	fm, err := ParseFrontmatter(raw)
+	if err != nil {
+		return err
+	}
+
+	r.Description = fm.Description
+	r.Globs = fm.Globs
+	r.AlwaysApply = fm.AlwaysApply
+	r.Tags = fm.Tags
+	r.Requires = fm.Requires
+	r.Conflicts = fm.Conflicts
+	r.Vars = fm.Vars
+	r.Extra = fm.Extra
+	r.keyLines = fm.Lines
+
+	return nil
+}
 
-		if inFrontmatter {
-			if strings.HasPrefix(line, "description:") {
-				r.Description = strings.TrimSpace(strings.TrimPrefix(line, "description:"))
-			} else if strings.HasPrefix(line, "globs:") {
-				globsStr := strings.TrimSpace(strings.TrimPrefix(line, "globs:"))
-				if globsStr != "" {
-					r.Globs = append(r.Globs, globsStr)
-				}
-			}
+// HasTag reports whether the rule is tagged with tag, ignoring case
+func (r *Rule) HasTag(tag string) bool {
+	for _, t := range r.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
 		}
 	}
+	return false
 }
@@ -0,0 +1,167 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseRuleFrontmatter(t *testing.T) {
+	testCases := []struct {
+		name            string
+		content         string
+		wantDescription string
+		wantGlobs       []string
+		wantAlwaysApply bool
+		wantTags        []string
+		wantRequires    []string
+		wantConflicts   []string
+		wantExtra       map[string]interface{}
+		wantBody        string
+	}{
+		{
+			name:            "Cursor style bare globs",
+			content:         "---\ndescription: Go rules\nglobs: *.go, internal/**/*.go\nalwaysApply: false\n---\n# Body\n",
+			wantDescription: "Go rules",
+			wantGlobs:       []string{"*.go", "internal/**/*.go"},
+			wantBody:        "# Body\n",
+		},
+		{
+			name:            "Flow list globs and quoted description",
+			content:         "---\ndescription: \"Uses: colons\"\nglobs: [\"*.go\", \"*.md\"]\nalwaysApply: true\n---\nbody",
+			wantDescription: "Uses: colons",
+			wantGlobs:       []string{"*.go", "*.md"},
+			wantAlwaysApply: true,
+			wantBody:        "body",
+		},
+		{
+			name:            "Block list globs and multi-line description",
+			content:         "---\ndescription: >\n  First line\n  second line\nglobs:\n  - \"*.ts\"\n  - src/**/*.tsx\n---\n",
+			wantDescription: "First line second line",
+			wantGlobs:       []string{"*.ts", "src/**/*.tsx"},
+		},
+		{
+			name:            "Empty globs and unknown keys",
+			content:         "---\ndescription: Cat rule\nglobs: \nalwaysApply: true\nowner: platform\n---\n# Rule\n",
+			wantDescription: "Cat rule",
+			wantAlwaysApply: true,
+			wantExtra:       map[string]interface{}{"owner": "platform"},
+			wantBody:        "# Rule\n",
+		},
+		{
+			name:            "Tags as a list",
+			content:         "---\ndescription: Tagged\ntags: [go, testing]\n---\n",
+			wantDescription: "Tagged",
+			wantTags:        []string{"go", "testing"},
+		},
+		{
+			name:            "Comma-separated tags",
+			content:         "---\ndescription: Tagged\ntags: security, go\n---\n",
+			wantDescription: "Tagged",
+			wantTags:        []string{"security", "go"},
+		},
+		{
+			name:            "Requirements and conflicts",
+			content:         "---\ndescription: Testing\nrequires: [go/logging]\nconflicts: commits-gitmoji, commits-plain\n---\n",
+			wantDescription: "Testing",
+			wantRequires:    []string{"go/logging"},
+			wantConflicts:   []string{"commits-gitmoji", "commits-plain"},
+		},
+		{
+			name:     "No frontmatter",
+			content:  "# Just a body\n",
+			wantBody: "# Just a body\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, err := ParseRule("rules/test.mdc", tc.content)
+			if err != nil {
+				t.Fatalf("ParseRule failed: %v", err)
+			}
+
+			if rule.Name != "test" {
+				t.Errorf("Name = %q, want %q", rule.Name, "test")
+			}
+			if rule.Description != tc.wantDescription {
+				t.Errorf("Description = %q, want %q", rule.Description, tc.wantDescription)
+			}
+			if !reflect.DeepEqual(rule.Globs, tc.wantGlobs) {
+				t.Errorf("Globs = %#v, want %#v", rule.Globs, tc.wantGlobs)
+			}
+			if rule.AlwaysApply != tc.wantAlwaysApply {
+				t.Errorf("AlwaysApply = %v, want %v", rule.AlwaysApply, tc.wantAlwaysApply)
+			}
+			if !reflect.DeepEqual(rule.Tags, tc.wantTags) {
+				t.Errorf("Tags = %#v, want %#v", rule.Tags, tc.wantTags)
+			}
+			if !reflect.DeepEqual(rule.Requires, tc.wantRequires) || !reflect.DeepEqual(rule.Conflicts, tc.wantConflicts) {
+				t.Errorf("Requires = %#v, Conflicts = %#v, want %#v and %#v", rule.Requires, rule.Conflicts, tc.wantRequires, tc.wantConflicts)
+			}
+			if tc.wantExtra == nil {
+				tc.wantExtra = map[string]interface{}{}
+			}
+			if !reflect.DeepEqual(rule.Extra, tc.wantExtra) {
+				t.Errorf("Extra = %#v, want %#v", rule.Extra, tc.wantExtra)
+			}
+			if rule.Body != tc.wantBody {
+				t.Errorf("Body = %q, want %q", rule.Body, tc.wantBody)
+			}
+		})
+	}
+}
+
+func TestParseRuleErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		wantLine int
+	}{
+		{
+			name:     "Unterminated frontmatter",
+			content:  "---\ndescription: never closed\n",
+			wantLine: 1,
+		},
+		{
+			name:     "Invalid alwaysApply",
+			content:  "---\ndescription: x\nalwaysApply: sometimes\n---\n",
+			wantLine: 3,
+		},
+		{
+			name:     "Invalid YAML",
+			content:  "---\nalwaysApply: true\ndescription: Use this: always\n---\n",
+			wantLine: 3,
+		},
+		{
+			name:     "Invalid YAML on the first frontmatter line",
+			content:  "---\ndescription: Use this: always\n---\n",
+			wantLine: 2,
+		},
+		{
+			name:     "Globs of wrong type",
+			content:  "---\ndescription: x\nglobs:\n  pattern: \"*.go\"\n---\n",
+			wantLine: 4,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseRule("rules/bad.mdc", tc.content)
+			if err == nil {
+				t.Fatal("Expected parse error but got none")
+			}
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected *ParseError, got %T: %v", err, err)
+			}
+			if parseErr.Path != "rules/bad.mdc" {
+				t.Errorf("Path = %q, want %q", parseErr.Path, "rules/bad.mdc")
+			}
+			if parseErr.Line != tc.wantLine {
+				t.Errorf("Line = %d, want %d (%v)", parseErr.Line, tc.wantLine, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package models
+
+import "path/filepath"
+
+// SourceSeparator separates a source ID from a rule name, as in "team:go/testing"
+const SourceSeparator = ":"
+
+// Source is one rules repository in a layered set of sources. Rules in later
+// sources override rules with the same topic/name in earlier ones.
+type Source struct {
+	// ID names the source in listings and in "source:topic/name" references
+	ID string
+	// Root is the local path of the repository
+	Root string
+	// URL and Commit identify a repository fetched from git
+	URL    string
+	Commit string
+}
+
+// RulesDir returns the directory rules are loaded from
+func (s Source) RulesDir() string {
+	return filepath.Join(s.Root, "rules")
+}
+
+// BundlesDir returns the directory bundles are loaded from
+func (s Source) BundlesDir() string {
+	return filepath.Join(s.Root, "bundles")
+}
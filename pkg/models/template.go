@@ -0,0 +1,166 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Variable is a template variable declared in the vars frontmatter key
+type Variable struct {
+	Name    string
+	Default string
+	// Required is set for variables declared without a default, which must
+	// be given a value
+	Required bool
+}
+
+// variableNameRe matches the names of template variables
+var variableNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// placeholderRe matches a template placeholder such as {{ project }}
+var placeholderRe = regexp.MustCompile(`{{\s*([A-Za-z_][A-Za-z0-9_]*)\s*}}`)
+
+// ValidVariableName reports whether name can be used as a template variable
+func ValidVariableName(name string) bool {
+	return variableNameRe.MatchString(name)
+}
+
+// Templated reports whether the rule declares template variables, so it is
+// rendered when installed
+func (r *Rule) Templated() bool {
+	return len(r.Vars) > 0
+}
+
+// Placeholders returns the names of the placeholders in the rule, in order
+// of first appearance
+func (r *Rule) Placeholders() []string {
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	for _, match := range placeholderRe.FindAllStringSubmatch(r.Content, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// ResolveVars returns the value of every variable of the rule: the value in
+// values, or its default. Only the rule's own variables are returned, and a
+// required variable without a value is an error.
+func (r *Rule) ResolveVars(values map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(r.Vars))
+	missing := make([]string, 0)
+	for _, variable := range r.Vars {
+		value, ok := values[variable.Name]
+		switch {
+		case ok:
+			resolved[variable.Name] = value
+		case variable.Required:
+			missing = append(missing, variable.Name)
+		default:
+			resolved[variable.Name] = variable.Default
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("no value for template variables %s (set them with --set name=value or under vars in the project configuration)", strings.Join(missing, ", "))
+	}
+	return resolved, nil
+}
+
+// Render returns a copy of the rule with the placeholders of its variables
+// replaced by their resolved values, along with those values. The rule is
+// rendered after it is parsed: values are substituted into the decoded
+// frontmatter strings, which are encoded again, and into the body, so a value
+// cannot change the structure of the frontmatter. Placeholders of undeclared
+// variables are left as they are, so rules showing other template languages
+// are not changed.
+func (r *Rule) Render(values map[string]string) (*Rule, map[string]string, error) {
+	resolved, err := r.ResolveVars(values)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	replace := func(text string) string {
+		return placeholderRe.ReplaceAllStringFunc(text, func(placeholder string) string {
+			name := placeholderRe.FindStringSubmatch(placeholder)[1]
+			if value, ok := resolved[name]; ok {
+				return value
+			}
+			return placeholder
+		})
+	}
+	frontmatter, err := renderFrontmatter(r.Frontmatter, replace)
+	if err != nil {
+		return nil, nil, err
+	}
+	rendered, err := ParseRule(r.Path, "---\n"+frontmatter+"---\n"+replace(r.Body))
+	if err != nil {
+		return nil, nil, fmt.Errorf("rendered rule is invalid: %w", err)
+	}
+
+	rendered.Topic = r.Topic
+	rendered.Selected = r.Selected
+	rendered.IsInstalled = r.IsInstalled
+	rendered.Source = r.Source
+	rendered.Overrides = r.Overrides
+	rendered.ShadowedBy = r.ShadowedBy
+	return rendered, resolved, nil
+}
+
+// renderFrontmatter applies replace to the string values of a raw
+// frontmatter block, except the variable declarations. The block is only
+// encoded again when a value changed, and is otherwise returned as written.
+func renderFrontmatter(raw string, replace func(string) string) (string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(quoteBareGlobs(raw)), &doc); err != nil {
+		return "", yamlError(err, 1)
+	}
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		return raw, nil
+	}
+
+	changed := false
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		switch node.Kind {
+		case yaml.ScalarNode:
+			if node.ShortTag() != "!!str" {
+				return
+			}
+			if value := replace(node.Value); value != node.Value {
+				// An explicit tag keeps values such as "true" or "42" strings
+				node.Value, node.Tag, changed = value, "!!str", true
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node == doc.Content[0] && node.Content[i].Value == KeyVars {
+					continue
+				}
+				walk(node.Content[i+1])
+			}
+		default:
+			for _, child := range node.Content {
+				walk(child)
+			}
+		}
+	}
+	walk(doc.Content[0])
+	if !changed {
+		return raw, nil
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc.Content[0]); err != nil {
+		return "", fmt.Errorf("failed to encode rendered frontmatter: %w", err)
+	}
+	return out.String(), nil
+}
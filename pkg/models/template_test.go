@@ -0,0 +1,91 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	content := "---\ndescription: Conventions for {{ project }}\nvars:\n  project: ~\n  language: Go\n---\n" +
+		"# {{project}} in {{ language }}\n\nKeep {{ .Name }} and {{ unknown }} as they are.\n"
+	rule, err := ParseRule("rules/go/project.mdc", content)
+	if err != nil {
+		t.Fatalf("ParseRule failed: %v", err)
+	}
+	rule.Topic = "go"
+	wantVars := []Variable{{Name: "project", Required: true}, {Name: "language", Default: "Go"}}
+	if !rule.Templated() || !reflect.DeepEqual(rule.Vars, wantVars) {
+		t.Fatalf("Vars = %+v, want %+v", rule.Vars, wantVars)
+	}
+	if got, want := rule.Placeholders(), []string{"project", "language", "unknown"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Placeholders = %v, want %v", got, want)
+	}
+
+	if _, _, err := rule.Render(nil); err == nil || !strings.Contains(err.Error(), "no value for template variables project") {
+		t.Errorf("Render without a required value returned %v", err)
+	}
+
+	rendered, values, err := rule.Render(map[string]string{"project": "billing", "other": "ignored"})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if want := map[string]string{"project": "billing", "language": "Go"}; !reflect.DeepEqual(values, want) {
+		t.Errorf("Render values = %v, want %v", values, want)
+	}
+	if rendered.Description != "Conventions for billing" || rendered.Topic != "go" {
+		t.Errorf("Rendered description %q, topic %q", rendered.Description, rendered.Topic)
+	}
+	if want := "# billing in Go\n\nKeep {{ .Name }} and {{ unknown }} as they are.\n"; rendered.Body != want {
+		t.Errorf("Rendered body = %q, want %q", rendered.Body, want)
+	}
+	if strings.Contains(rule.Body, "billing") {
+		t.Error("Render changed the template rule")
+	}
+}
+
+func TestRenderValuesDoNotChangeFrontmatter(t *testing.T) {
+	content := "---\ndescription: Rules for {{ project }}\nglobs: src/{{ project }}/**\ntags: [team, '{{ project }}']\nalwaysApply: false\nvars:\n  project:\n---\n# {{ project }}\n"
+	rule, err := ParseRule("rules/project.mdc", content)
+	if err != nil {
+		t.Fatalf("ParseRule failed: %v", err)
+	}
+
+	for _, value := range []string{"Acme: Payments", "#1 team", `say "hi" it's`, "two\nlines", "true", "[draft]"} {
+		t.Run(value, func(t *testing.T) {
+			rendered, _, err := rule.Render(map[string]string{"project": value})
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			if want := "Rules for " + value; rendered.Description != want {
+				t.Errorf("Description = %q, want %q", rendered.Description, want)
+			}
+			if want := []string{"src/" + value + "/**"}; !reflect.DeepEqual(rendered.Globs, want) {
+				t.Errorf("Globs = %q, want %q", rendered.Globs, want)
+			}
+			if want := []string{"team", value}; !reflect.DeepEqual(rendered.Tags, want) {
+				t.Errorf("Tags = %q, want %q", rendered.Tags, want)
+			}
+			if rendered.AlwaysApply || !reflect.DeepEqual(rendered.Vars, rule.Vars) {
+				t.Errorf("Rendered alwaysApply %t, vars %+v", rendered.AlwaysApply, rendered.Vars)
+			}
+			if want := "# " + value + "\n"; rendered.Body != want {
+				t.Errorf("Body = %q, want %q", rendered.Body, want)
+			}
+		})
+	}
+}
+
+func TestParseVarsErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"Not a mapping":    "---\nvars: [project]\n---\n",
+		"Invalid name":     "---\nvars:\n  project-name: x\n---\n",
+		"Non-scalar value": "---\nvars:\n  project: [a, b]\n---\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseRule("rules/test.mdc", content); err == nil {
+				t.Error("Expected a parse error")
+			}
+		})
+	}
+}
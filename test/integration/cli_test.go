@@ -0,0 +1,608 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runBinary runs rule-tool with args and returns its stdout, stderr and exit code
+func runBinary(t *testing.T, binaryPath string, args ...string) (string, string, int) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(binaryPath, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return stdout.String(), stderr.String(), 0
+	case errors.As(err, &exitErr):
+		return stdout.String(), stderr.String(), exitErr.ExitCode()
+	}
+	t.Fatalf("Failed to run %v: %v", args, err)
+	return "", "", 0
+}
+
+// writeRulesRepo creates a rules repository with a single go/testing rule
+func writeRulesRepo(t *testing.T) string {
+	t.Helper()
+	repoDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repoDir, "rules", "go"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	rule := "---\ndescription: Testing conventions\n---\n\n# Testing\n"
+	if err := os.WriteFile(filepath.Join(repoDir, "rules", "go", "testing.mdc"), []byte(rule), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return repoDir
+}
+
+// TestSubcommandExitCodes checks the exit codes and output streams of the subcommands
+func TestSubcommandExitCodes(t *testing.T) {
+	binaryPath, err := findBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths := []string{"--repo-path", writeRulesRepo(t), "--target-path", t.TempDir()}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{"list", append([]string{"list"}, paths...), 0, "go/testing: Testing conventions", ""},
+		{"show", append([]string{"show"}, append(paths, "testing")...), 0, "Description:  Testing conventions", ""},
+		{"show unknown rule", append([]string{"show"}, append(paths, "missing")...), 1, "", "Rule not found: missing"},
+		{"link dry run", append([]string{"link", "--dry-run"}, append(paths, "go/testing")...), 0, "Would link rule: go/testing", ""},
+		{"link partial failure", append([]string{"link", "--dry-run"}, append(paths, "go/testing", "missing")...), 3, "Would link rule", "Rule not found: missing"},
+		{"link unknown rule", append([]string{"link"}, append(paths, "missing")...), 1, "", "Rule not found: missing"},
+		{"link without rules", append([]string{"link"}, paths...), 2, "", "Usage: rule-tool link"},
+		{"unlink dry run of a rule that is not installed", append([]string{"unlink", "--dry-run"}, append(paths, "go/testing")...), 1, "", "rule go/testing is not linked"},
+		{"unlink a rule that is not installed", append([]string{"unlink"}, append(paths, "go/testing")...), 1, "", "rule go/testing is not linked"},
+		{"unknown flag", []string{"list", "--bogus"}, 2, "", "flag provided but not defined"},
+		{"unknown command", []string{"frobnicate"}, 2, "", "Unknown command: frobnicate"},
+		{"help", []string{"help", "link"}, 0, "", "Usage: rule-tool link"},
+		{"command help", []string{"status", "-h"}, 0, "", "Usage: rule-tool status"},
+		{"status plain output", append([]string{"status", "--output", "plain"}, paths...), 0, "", ""},
+		{"status unknown output", append([]string{"status", "--output", "yaml"}, paths...), 2, "", "unknown output format"},
+		{"deprecated list flag", append([]string{"--list", "--quiet"}, paths...), 0, "go/testing", "--list is deprecated"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout, stderr, code := runBinary(t, binaryPath, tc.args...)
+			if code != tc.wantCode {
+				t.Errorf("Exit code = %d, want %d\nstdout: %s\nstderr: %s", code, tc.wantCode, stdout, stderr)
+			}
+			if !strings.Contains(stdout, tc.wantStdout) {
+				t.Errorf("Stdout does not contain %q:\n%s", tc.wantStdout, stdout)
+			}
+			if !strings.Contains(stderr, tc.wantStderr) {
+				t.Errorf("Stderr does not contain %q:\n%s", tc.wantStderr, stderr)
+			}
+			if strings.Contains(stdout, "Rule Tool CLI") {
+				t.Errorf("Stdout contains the banner:\n%s", stdout)
+			}
+		})
+	}
+}
+
+func TestInvalidRulesAreSkipped(t *testing.T) {
+	binaryPath, err := findBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repoDir := writeRulesRepo(t)
+	if err := os.WriteFile(filepath.Join(repoDir, "rules", "bare.mdc"), []byte("---\ndescription: Use this: always\n---\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, code := runBinary(t, binaryPath, "list", "--repo-path", repoDir, "--target-path", t.TempDir())
+	if code != 0 || !strings.Contains(stdout, "go/testing") || !strings.Contains(stderr, "Warning: skipping") || !strings.Contains(stderr, "bare.mdc:2") {
+		t.Errorf("List exit code %d\nstdout: %s\nstderr: %s", code, stdout, stderr)
+	}
+	if _, _, code := runBinary(t, binaryPath, "validate", "--repo-path", repoDir); code != 1 {
+		t.Errorf("Validate exit code = %d, want 1", code)
+	}
+}
+
+// TestStructuredOutput checks the json and ndjson records of list and link
+func TestStructuredOutput(t *testing.T) {
+	binaryPath, err := findBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := []string{"--repo-path", writeRulesRepo(t), "--target-path", t.TempDir()}
+
+	stdout, stderr, code := runBinary(t, binaryPath, append([]string{"link", "--output", "ndjson"}, append(paths, "go/testing", "missing")...)...)
+	if code != 3 {
+		t.Errorf("Exit code = %d, want 3\nstderr: %s", code, stderr)
+	}
+	type result struct {
+		Rule        string `json:"rule"`
+		Destination string `json:"destination"`
+		OK          bool   `json:"ok"`
+		Error       string `json:"error"`
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Got %d records, want 2:\n%s", len(lines), stdout)
+	}
+	var failed, linked result
+	if err := json.Unmarshal([]byte(lines[0]), &failed); err != nil || failed.Rule != "missing" || failed.OK || failed.Error == "" {
+		t.Errorf("First record = %+v (%v), want the failure to find missing", failed, err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &linked); err != nil || !linked.OK || linked.Destination != ".cursor/rules/go_testing.mdc" {
+		t.Errorf("Second record = %+v (%v), want go/testing linked", linked, err)
+	}
+
+	stdout, stderr, code = runBinary(t, binaryPath, append([]string{"list", "--output", "json"}, paths...)...)
+	if code != 0 {
+		t.Fatalf("Exit code = %d, want 0\nstderr: %s", code, stderr)
+	}
+	var document struct {
+		Rules []struct {
+			ID          string   `json:"id"`
+			Description string   `json:"description"`
+			Globs       []string `json:"globs"`
+			Installed   bool     `json:"installed"`
+		} `json:"rules"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &document); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, stdout)
+	}
+	if len(document.Rules) != 1 || document.Rules[0].ID != "go/testing" || !document.Rules[0].Installed || document.Rules[0].Globs == nil {
+		t.Errorf("Decoded %+v, want go/testing installed", document)
+	}
+}
+
+// TestDiff checks the diff of an edited copy and the exit code of --check
+func TestDiff(t *testing.T) {
+	binaryPath, err := findBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	targetDir := t.TempDir()
+	paths := []string{"--repo-path", writeRulesRepo(t), "--target-path", targetDir}
+
+	if _, stderr, code := runBinary(t, binaryPath, append([]string{"link", "--mode", "copy"}, append(paths, "go/testing")...)...); code != 0 {
+		t.Fatalf("Link exit code = %d\nstderr: %s", code, stderr)
+	}
+	stdout, stderr, code := runBinary(t, binaryPath, append([]string{"diff", "--check"}, paths...)...)
+	if code != 0 || stdout != "" {
+		t.Errorf("Diff of an unchanged copy: exit code %d, stdout %q\nstderr: %s", code, stdout, stderr)
+	}
+
+	copyPath := filepath.Join(targetDir, ".cursor", "rules", "go_testing.mdc")
+	content, err := os.ReadFile(copyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.Replace(string(content), "description: Testing conventions", "description: Edited", 1)
+	if err := os.WriteFile(copyPath, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, code = runBinary(t, binaryPath, append([]string{"diff", "--check"}, paths...)...)
+	if code != 1 {
+		t.Errorf("Exit code = %d, want 1\nstderr: %s", code, stderr)
+	}
+	for _, want := range []string{"--- .cursor/rules/go_testing.mdc (installed)", "+++ rules/go/testing.mdc (upstream)", "-description: Edited", "+description: Testing conventions"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Diff does not contain %q:\n%s", want, stdout)
+		}
+	}
+}
+
+// TestTagFilters checks that --tag and --exclude-tag narrow list and link
+func TestTagFilters(t *testing.T) {
+	binaryPath, err := findBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repoDir := writeRulesRepo(t)
+	rules := map[string]string{
+		"go/style.mdc": "---\ndescription: Go style\ntags: [golang]\n---\n\n# Style\n",
+		"go/fuzz.mdc":  "---\ndescription: Fuzzing\ntags: [golang, testing]\n---\n\n# Fuzzing\n",
+		"js/jest.mdc":  "---\ndescription: Jest\ntags: [testing, slow]\n---\n\n# Jest\n",
+	}
+	for name, content := range rules {
+		path := filepath.Join(repoDir, "rules", filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	targetDir := t.TempDir()
+	paths := []string{"--repo-path", repoDir, "--target-path", targetDir, "--quiet"}
+
+	stdout, stderr, code := runBinary(t, binaryPath, append([]string{"list", "--tag", "testing", "--exclude-tag", "slow"}, paths...)...)
+	if code != 0 || !strings.Contains(stdout, "go/fuzz") || strings.Contains(stdout, "js/jest") || strings.Contains(stdout, "go/style") {
+		t.Errorf("List exit code %d, stdout:\n%s\nstderr: %s", code, stdout, stderr)
+	}
+
+	stdout, stderr, code = runBinary(t, binaryPath, append([]string{"link", "--tag", "golang,testing"}, paths...)...)
+	if code != 0 || strings.TrimSpace(stdout) != "Linked rule: go/fuzz" {
+		t.Errorf("Link exit code %d, stdout:\n%s\nstderr: %s", code, stdout, stderr)
+	}
+	if _, err := os.Lstat(filepath.Join(targetDir, ".cursor", "rules", "go_fuzz.mdc")); err != nil {
+		t.Errorf("Tagged rule was not linked: %v", err)
+	}
+
+	_, stderr, code = runBinary(t, binaryPath, append([]string{"link", "--tag", "rust"}, paths...)...)
+	if code != 1 || !strings.Contains(stderr, "No rules match tag:rust") {
+		t.Errorf("Link of an unused tag: exit code %d, stderr: %s", code, stderr)
+	}
+}
+
+// TestRecommend checks that recommend ranks rules by the files their globs
+// match, ignoring files ignored by git
+func TestRecommend(t *testing.T) {
+	binaryPath, err := findBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repoDir := t.TempDir()
+	rules := map[string]string{
+		"go/style.mdc": "---\ndescription: Go style\nglobs: \"*.go\"\n---\n\n# Style\n",
+		"js/react.mdc": "---\ndescription: React\nglobs: \"**/*.tsx\"\n---\n\n# React\n",
+		"rust.mdc":     "---\ndescription: Rust\nglobs: \"*.rs\"\n---\n\n# Rust\n",
+	}
+	targetDir := t.TempDir()
+	files := map[string]string{
+		".gitignore":     "vendor/\n",
+		"main.go":        "",
+		"cmd/tool.go":    "",
+		"vendor/a.go":    "",
+		"vendor/b.go":    "",
+		"web/app.tsx":    "",
+		"web/widget.tsx": "",
+		"web/page.tsx":   "",
+	}
+	for dir, contents := range map[string]map[string]string{filepath.Join(repoDir, "rules"): rules, targetDir: files} {
+		for name, content := range contents {
+			path := filepath.Join(dir, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	paths := []string{"--repo-path", repoDir, "--target-path", targetDir, "--quiet"}
+
+	stdout, stderr, code := runBinary(t, binaryPath, append([]string{"recommend", "--output", "json"}, paths...)...)
+	if code != 0 {
+		t.Fatalf("Exit code = %d\nstderr: %s", code, stderr)
+	}
+	var document struct {
+		Recommendations []struct {
+			Rule  string `json:"rule"`
+			Files int    `json:"files"`
+		} `json:"recommendations"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &document); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, stdout)
+	}
+	got := make([]string, 0)
+	for _, recommendation := range document.Recommendations {
+		got = append(got, fmt.Sprintf("%s:%d", recommendation.Rule, recommendation.Files))
+	}
+	if want := "js/react:3,go/style:2"; strings.Join(got, ",") != want {
+		t.Errorf("Recommendations = %v, want %s", got, want)
+	}
+
+	stdout, stderr, code = runBinary(t, binaryPath, append([]string{"recommend", "--link", "--limit", "1"}, paths...)...)
+	if code != 0 || !strings.Contains(stdout, "Linked rule: js/react") || strings.Contains(stdout, "Linked rule: go/style") {
+		t.Errorf("Recommend --link exit code %d, stdout:\n%s\nstderr: %s", code, stdout, stderr)
+	}
+}
+
+// TestExplain checks which installed rules explain reports for a file
+func TestExplain(t *testing.T) {
+	binaryPath, err := findBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repoDir := writeRulesRepo(t)
+	rules := map[string]string{
+		"go/style.mdc": "---\ndescription: Go style\nglobs: \"*.py, internal/**/*.go\"\n---\n\n# Style\n",
+		"base.mdc":     "---\nalwaysApply: true\n---\n\n# Base\n",
+		"js/react.mdc": "---\ndescription: React\nglobs: \"*.tsx\"\n---\n\n# React\n",
+		"unlinked.mdc": "---\nalwaysApply: true\n---\n\n# Not installed\n",
+	}
+	for name, content := range rules {
+		path := filepath.Join(repoDir, "rules", filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	targetDir := t.TempDir()
+	paths := []string{"--repo-path", repoDir, "--target-path", targetDir, "--quiet"}
+	if _, stderr, code := runBinary(t, binaryPath, append([]string{"link"}, append(paths, "go/style", "base", "js/react", "go/testing")...)...); code != 0 {
+		t.Fatalf("Link exit code = %d\nstderr: %s", code, stderr)
+	}
+
+	stdout, stderr, code := runBinary(t, binaryPath, append([]string{"explain", "--output", "json"}, append(paths, filepath.Join(targetDir, "internal", "scan", "scan.go"))...)...)
+	if code != 0 {
+		t.Fatalf("Exit code = %d\nstderr: %s", code, stderr)
+	}
+	var document struct {
+		Rules []struct {
+			Rule       string `json:"rule"`
+			File       string `json:"file"`
+			Activation string `json:"activation"`
+			Glob       string `json:"glob"`
+		} `json:"rules"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &document); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, stdout)
+	}
+	got := make([]string, 0)
+	for _, rule := range document.Rules {
+		if rule.File != "internal/scan/scan.go" {
+			t.Errorf("File = %q, want the path relative to the target project", rule.File)
+		}
+		got = append(got, rule.Activation+" "+rule.Rule+" "+rule.Glob)
+	}
+	want := []string{"always base ", "glob go/style internal/**/*.go", "agent-requested go/testing "}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Explained %v, want %v", got, want)
+	}
+
+	stdout, _, code = runBinary(t, binaryPath, append([]string{"explain", "--all"}, append(paths, "web/app.py")...)...)
+	if code != 0 || !strings.Contains(stdout, "matches *.py") || !strings.Contains(stdout, "no-match") {
+		t.Errorf("Explain --all exit code %d, stdout:\n%s", code, stdout)
+	}
+
+	_, _, code = runBinary(t, binaryPath, append([]string{"explain"}, append(paths, "../outside.go")...)...)
+	if code != 2 {
+		t.Errorf("Explain of a file outside the target: exit code %d, want 2", code)
+	}
+
+	// Other editors apply the installed rules their own way
+	for _, tc := range []struct {
+		format string
+		want   []string
+	}{
+		// CLAUDE.md and Roo Code's rules directory are read whole
+		{format: "claude", want: []string{"always go/style ", "always go/testing ", "always js/react "}},
+		{format: "roo", want: []string{"always go/style ", "always go/testing ", "always js/react "}},
+		// Cline rules without paths are always active
+		{format: "cline", want: []string{"always go/testing ", "no-match go/style ", "no-match js/react "}},
+		// Copilot rules without applyTo are only used when attached
+		{format: "copilot", want: []string{"manual go/testing ", "no-match go/style ", "no-match js/react "}},
+	} {
+		format := append(paths, "--target-format", tc.format)
+		if _, stderr, code := runBinary(t, binaryPath, append([]string{"link"}, append(format, "go/style", "js/react", "go/testing")...)...); code != 0 {
+			t.Fatalf("Link for %s exit code = %d\nstderr: %s", tc.format, code, stderr)
+		}
+		stdout, stderr, code := runBinary(t, binaryPath, append([]string{"explain", "--all", "--output", "json"}, append(format, "src/a.go")...)...)
+		if code != 0 {
+			t.Fatalf("Explain for %s exit code = %d\nstderr: %s", tc.format, code, stderr)
+		}
+		document.Rules = nil
+		if err := json.Unmarshal([]byte(stdout), &document); err != nil {
+			t.Fatalf("Output is not valid JSON: %v\n%s", err, stdout)
+		}
+		got := make([]string, 0)
+		for _, rule := range document.Rules {
+			got = append(got, rule.Activation+" "+rule.Rule+" "+rule.Glob)
+		}
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("Explained for %s %v, want %v", tc.format, got, tc.want)
+		}
+	}
+}
+
+// TestBundles checks linking the rules of a bundle and validating bundles
+func TestBundles(t *testing.T) {
+	binaryPath, err := findBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repoDir := writeRulesRepo(t)
+	files := map[string]string{
+		"rules/go/style.mdc":      "---\ndescription: Go style\n---\n\n# Style\n",
+		"rules/review.mdc":        "---\ndescription: Review\n---\n\n# Review\n",
+		"bundles/base.yaml":       "description: Every project\nrules: [review]\n",
+		"bundles/go-service.yaml": "description: Go services\nrules: [\"go/*\"]\ninclude: [base]\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(repoDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	targetDir := t.TempDir()
+	paths := []string{"--repo-path", repoDir, "--target-path", targetDir, "--quiet"}
+
+	stdout, _, code := runBinary(t, binaryPath, append([]string{"list"}, paths...)...)
+	if code != 0 || !strings.Contains(stdout, "Bundles:") || !strings.Contains(stdout, "go-service") {
+		t.Errorf("List exit code %d, stdout:\n%s", code, stdout)
+	}
+
+	if _, stderr, code := runBinary(t, binaryPath, append([]string{"link", "--bundle", "go-service"}, paths...)...); code != 0 {
+		t.Fatalf("Link exit code = %d\nstderr: %s", code, stderr)
+	}
+	for _, name := range []string{"go_style.mdc", "go_testing.mdc", "review.mdc"} {
+		if _, err := os.Lstat(filepath.Join(targetDir, ".cursor", "rules", name)); err != nil {
+			t.Errorf("Bundle rule %s not linked: %v", name, err)
+		}
+	}
+
+	if _, _, code := runBinary(t, binaryPath, append([]string{"link", "--bundle", "missing"}, paths...)...); code != 1 {
+		t.Errorf("Link of an unknown bundle exit code = %d, want 1", code)
+	}
+
+	if err := os.WriteFile(filepath.Join(repoDir, "bundles", "dangling.yaml"), []byte("include: [missing]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _, code = runBinary(t, binaryPath, "validate", "--repo-path", repoDir)
+	if code == 0 || !strings.Contains(stdout, "invalid-bundle") {
+		t.Errorf("Validate exit code %d, stdout:\n%s", code, stdout)
+	}
+}
+
+// TestRequirementsAndConflicts checks that linking pulls in required rules
+// and refuses conflicting ones
+func TestRequirementsAndConflicts(t *testing.T) {
+	binaryPath, err := findBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repoDir := writeRulesRepo(t)
+	rules := map[string]string{
+		"go/testing.mdc":   "---\ndescription: Testing conventions\nrequires: [go/logging]\n---\n\n# Testing\n",
+		"go/logging.mdc":   "---\ndescription: Logging\nrequires: [errors]\n---\n\n# Logging\n",
+		"errors.mdc":       "---\ndescription: Errors\n---\n\n# Errors\n",
+		"conventional.mdc": "---\ndescription: Conventional commits\nconflicts: [gitmoji]\n---\n\n# Conventional\n",
+		"gitmoji.mdc":      "---\ndescription: Gitmoji commits\n---\n\n# Gitmoji\n",
+	}
+	for name, content := range rules {
+		path := filepath.Join(repoDir, "rules", filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	targetDir := t.TempDir()
+	paths := []string{"--repo-path", repoDir, "--target-path", targetDir, "--quiet"}
+
+	// Without --yes, the requirements are listed and the link cancelled
+	stdout, _, code := runBinary(t, binaryPath, append([]string{"link"}, append(paths, "go/testing")...)...)
+	if code != 1 || !strings.Contains(stdout, "go/logging  (required by go/testing)") || !strings.Contains(stdout, "errors      (required by go/logging)") {
+		t.Errorf("Link exit code %d, stdout:\n%s", code, stdout)
+	}
+
+	if _, stderr, code := runBinary(t, binaryPath, append([]string{"link", "--yes"}, append(paths, "go/testing", "conventional")...)...); code != 0 {
+		t.Fatalf("Link exit code = %d\nstderr: %s", code, stderr)
+	}
+	for _, name := range []string{"go_testing.mdc", "go_logging.mdc", "errors.mdc"} {
+		if _, err := os.Lstat(filepath.Join(targetDir, ".cursor", "rules", name)); err != nil {
+			t.Errorf("Rule %s not linked: %v", name, err)
+		}
+	}
+
+	_, stderr, code := runBinary(t, binaryPath, append([]string{"link"}, append(paths, "gitmoji")...)...)
+	if code != 1 || !strings.Contains(stderr, "conventional (installed) declares a conflict with gitmoji") {
+		t.Errorf("Link of a conflicting rule exit code %d, stderr:\n%s", code, stderr)
+	}
+	if _, err := os.Lstat(filepath.Join(targetDir, ".cursor", "rules", "gitmoji.mdc")); err == nil {
+		t.Error("Conflicting rule was linked")
+	}
+
+	// The deprecated --link flag goes through the same checks
+	_, stderr, code = runBinary(t, binaryPath, append([]string{"--link", "gitmoji"}, paths...)...)
+	if code != 1 || !strings.Contains(stderr, "conventional (installed) declares a conflict with gitmoji") {
+		t.Errorf("Legacy link of a conflicting rule exit code %d, stderr:\n%s", code, stderr)
+	}
+
+	// recommend --link confirms the requirements like link does
+	otherTarget := t.TempDir()
+	if err := os.WriteFile(filepath.Join(otherTarget, "main_test.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "rules", "go", "testing.mdc"), []byte("---\ndescription: Testing conventions\nglobs: \"*_test.go\"\nrequires: [go/logging]\n---\n\n# Testing\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	otherPaths := []string{"--repo-path", repoDir, "--target-path", otherTarget, "--quiet"}
+	stdout, _, code = runBinary(t, binaryPath, append([]string{"recommend", "--link"}, otherPaths...)...)
+	if code != 1 || !strings.Contains(stdout, "go/logging  (required by go/testing)") {
+		t.Errorf("Recommend --link without --yes exit code %d, stdout:\n%s", code, stdout)
+	}
+	if _, stderr, code := runBinary(t, binaryPath, append([]string{"recommend", "--link", "--yes"}, otherPaths...)...); code != 0 {
+		t.Errorf("Recommend --link --yes exit code = %d\nstderr: %s", code, stderr)
+	}
+	if _, err := os.Lstat(filepath.Join(otherTarget, ".cursor", "rules", "go_logging.mdc")); err != nil {
+		t.Errorf("Required rule not linked by recommend: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(repoDir, "rules", "errors.mdc"), []byte("---\ndescription: Errors\nrequires: [go/testing, missing]\n---\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _, code = runBinary(t, binaryPath, "validate", "--repo-path", repoDir)
+	if code != 1 || !strings.Contains(stdout, "requirement-cycle") || !strings.Contains(stdout, "unknown-reference") {
+		t.Errorf("Validate exit code %d, stdout:\n%s", code, stdout)
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	binaryPath, err := findBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repoDir := writeRulesRepo(t)
+	rulePath := filepath.Join(repoDir, "rules", "go", "conventions.mdc")
+	if err := os.MkdirAll(filepath.Dir(rulePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	template := "---\ndescription: Conventions\nvars:\n  project:\n  owner: platform\n---\n\n# {{ project }}\nAsk {{ owner }}.\n"
+	if err := os.WriteFile(rulePath, []byte(template), 0644); err != nil {
+		t.Fatal(err)
+	}
+	targetDir := t.TempDir()
+	paths := []string{"--repo-path", repoDir, "--target-path", targetDir, "--quiet"}
+	installedPath := filepath.Join(targetDir, ".cursor", "rules", "go_conventions.mdc")
+
+	_, stderr, code := runBinary(t, binaryPath, append([]string{"link"}, append(paths, "go/conventions")...)...)
+	if code != 1 || !strings.Contains(stderr, "no value for template variables project") {
+		t.Errorf("Link without values exit code %d, stderr:\n%s", code, stderr)
+	}
+
+	if _, stderr, code := runBinary(t, binaryPath, append([]string{"link", "--set", "project=billing"}, append(paths, "go/conventions")...)...); code != 0 {
+		t.Fatalf("Link exit code = %d\nstderr: %s", code, stderr)
+	}
+	if info, err := os.Lstat(installedPath); err != nil || !info.Mode().IsRegular() {
+		t.Fatalf("Expected the template to be copied, got %v", err)
+	}
+	if content, _ := os.ReadFile(installedPath); !strings.Contains(string(content), "# billing\nAsk platform.") {
+		t.Errorf("Installed copy was not rendered:\n%s", content)
+	}
+	if lock, _ := os.ReadFile(filepath.Join(targetDir, ".rule-tool.lock")); !strings.Contains(string(lock), `"project": "billing"`) {
+		t.Errorf("Lockfile does not record the values:\n%s", lock)
+	}
+
+	stdout, _, code := runBinary(t, binaryPath, append([]string{"status", "--check"}, paths...)...)
+	if code != 0 || !strings.Contains(stdout, "owner=platform project=billing") {
+		t.Errorf("Status exit code %d, stdout:\n%s", code, stdout)
+	}
+
+	// Values in the project configuration override the recorded ones
+	if err := os.WriteFile(filepath.Join(targetDir, ".rule-tool.yaml"), []byte("rules: [go/conventions]\nvars:\n  project: payments\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _, code = runBinary(t, binaryPath, append([]string{"status", "--check"}, paths...)...)
+	if code != 1 || !strings.Contains(stdout, "template variables changed") {
+		t.Errorf("Status after changing the values exit code %d, stdout:\n%s", code, stdout)
+	}
+	if _, stderr, code := runBinary(t, binaryPath, append([]string{"update"}, paths...)...); code != 0 {
+		t.Fatalf("Update exit code = %d\nstderr: %s", code, stderr)
+	}
+	if content, _ := os.ReadFile(installedPath); !strings.Contains(string(content), "# payments\n") {
+		t.Errorf("Update did not render the new value:\n%s", content)
+	}
+}